@@ -0,0 +1,44 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // add field
+  collection.fields.addAt(24, new Field({
+    "hidden": false,
+    "id": "select1889323689",
+    "maxSelect": 1,
+    "name": "keyword_mode",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "contains",
+      "not_contains",
+      "regex"
+    ]
+  }))
+
+  // add field
+  collection.fields.addAt(25, new Field({
+    "hidden": false,
+    "id": "bool3888446037",
+    "name": "keyword_ignore_case",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "bool"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // remove field
+  collection.fields.removeById("select1889323689")
+
+  // remove field
+  collection.fields.removeById("bool3888446037")
+
+  return app.save(collection)
+})
@@ -0,0 +1,193 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // add field
+  collection.fields.addAt(26, new Field({
+    "hidden": false,
+    "id": "select1160503975",
+    "maxSelect": 1,
+    "name": "http_method",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "GET",
+      "POST",
+      "PUT",
+      "PATCH",
+      "DELETE",
+      "HEAD",
+      "OPTIONS"
+    ]
+  }))
+
+  // add field
+  collection.fields.addAt(27, new Field({
+    "hidden": false,
+    "id": "json3710336466",
+    "maxSize": 0,
+    "name": "request_headers",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "json"
+  }))
+
+  // add field
+  collection.fields.addAt(28, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text2752457800",
+    "max": 0,
+    "min": 0,
+    "name": "request_body",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(29, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text1519838004",
+    "max": 0,
+    "min": 0,
+    "name": "request_content_type",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(30, new Field({
+    "hidden": false,
+    "id": "select2734208925",
+    "maxSelect": 1,
+    "name": "auth_type",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "none",
+      "basic",
+      "bearer"
+    ]
+  }))
+
+  // add field
+  collection.fields.addAt(31, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text3701768689",
+    "max": 0,
+    "min": 0,
+    "name": "auth_username",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(32, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text288932179",
+    "max": 0,
+    "min": 0,
+    "name": "auth_password",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(33, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text2467688526",
+    "max": 0,
+    "min": 0,
+    "name": "auth_token",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(34, new Field({
+    "hidden": false,
+    "id": "json4200230295",
+    "maxSize": 0,
+    "name": "query_params",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "json"
+  }))
+
+  // add field
+  collection.fields.addAt(35, new Field({
+    "hidden": false,
+    "id": "bool3560661025",
+    "name": "ignore_tls_errors",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "bool"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // remove field
+  collection.fields.removeById("select1160503975")
+
+  // remove field
+  collection.fields.removeById("json3710336466")
+
+  // remove field
+  collection.fields.removeById("text2752457800")
+
+  // remove field
+  collection.fields.removeById("text1519838004")
+
+  // remove field
+  collection.fields.removeById("select2734208925")
+
+  // remove field
+  collection.fields.removeById("text3701768689")
+
+  // remove field
+  collection.fields.removeById("text288932179")
+
+  // remove field
+  collection.fields.removeById("text2467688526")
+
+  // remove field
+  collection.fields.removeById("json4200230295")
+
+  // remove field
+  collection.fields.removeById("bool3560661025")
+
+  return app.save(collection)
+})
@@ -0,0 +1,105 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_67962705")
+
+  // add field
+  collection.fields.addAt(13, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text431720897",
+    "max": 0,
+    "min": 0,
+    "name": "dns_lookup",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(14, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text2326407984",
+    "max": 0,
+    "min": 0,
+    "name": "tcp_connect",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(15, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text1988928028",
+    "max": 0,
+    "min": 0,
+    "name": "tls_handshake",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(16, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text117105678",
+    "max": 0,
+    "min": 0,
+    "name": "ttfb",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(17, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text310593839",
+    "max": 0,
+    "min": 0,
+    "name": "content_transfer",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_67962705")
+
+  // remove field
+  collection.fields.removeById("text431720897")
+
+  // remove field
+  collection.fields.removeById("text2326407984")
+
+  // remove field
+  collection.fields.removeById("text1988928028")
+
+  // remove field
+  collection.fields.removeById("text117105678")
+
+  // remove field
+  collection.fields.removeById("text310593839")
+
+  return app.save(collection)
+})
@@ -0,0 +1,25 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // add field
+  collection.fields.addAt(36, new Field({
+    "hidden": false,
+    "id": "json377481602",
+    "maxSize": 0,
+    "name": "json_assertions",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "json"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // remove field
+  collection.fields.removeById("json377481602")
+
+  return app.save(collection)
+})
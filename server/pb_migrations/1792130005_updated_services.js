@@ -0,0 +1,65 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // add field
+  collection.fields.addAt(37, new Field({
+    "hidden": false,
+    "id": "select1116754985",
+    "maxSelect": 1,
+    "name": "redirect_policy",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "follow",
+      "none"
+    ]
+  }))
+
+  // add field
+  collection.fields.addAt(38, new Field({
+    "hidden": false,
+    "id": "number2832899277",
+    "max": null,
+    "min": null,
+    "name": "max_redirects",
+    "onlyInt": true,
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "number"
+  }))
+
+  // add field
+  collection.fields.addAt(39, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text3861152028",
+    "max": 0,
+    "min": 0,
+    "name": "expected_final_url",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // remove field
+  collection.fields.removeById("select1116754985")
+
+  // remove field
+  collection.fields.removeById("number2832899277")
+
+  // remove field
+  collection.fields.removeById("text3861152028")
+
+  return app.save(collection)
+})
@@ -0,0 +1,49 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // add field
+  collection.fields.addAt(40, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text510880559",
+    "max": 0,
+    "min": 0,
+    "name": "nameserver",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(41, new Field({
+    "hidden": false,
+    "id": "select2938148331",
+    "maxSelect": 1,
+    "name": "dns_transport",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "auto",
+      "udp",
+      "tcp"
+    ]
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // remove field
+  collection.fields.removeById("text510880559")
+
+  // remove field
+  collection.fields.removeById("select2938148331")
+
+  return app.save(collection)
+})
@@ -0,0 +1,39 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // add field
+  collection.fields.addAt(42, new Field({
+    "hidden": false,
+    "id": "select1945787962",
+    "maxSelect": 1,
+    "name": "dns_query_type",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "A",
+      "AAAA",
+      "MX",
+      "TXT",
+      "CNAME",
+      "NS",
+      "SOA",
+      "SRV",
+      "CAA",
+      "PTR",
+      "DS",
+      "DNSKEY"
+    ]
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // remove field
+  collection.fields.removeById("select1945787962")
+
+  return app.save(collection)
+})
@@ -0,0 +1,50 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // add field
+  collection.fields.addAt(43, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text3623156663",
+    "max": 0,
+    "min": 0,
+    "name": "dns_expected",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(44, new Field({
+    "hidden": false,
+    "id": "select492981074",
+    "maxSelect": 1,
+    "name": "dns_match_mode",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "exact",
+      "subset",
+      "cidr",
+      "regex"
+    ]
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // remove field
+  collection.fields.removeById("text3623156663")
+
+  // remove field
+  collection.fields.removeById("select492981074")
+
+  return app.save(collection)
+})
@@ -0,0 +1,79 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // update field
+  collection.fields.addAt(45, new Field({
+    "hidden": false,
+    "id": "select1802631506",
+    "maxSelect": 1,
+    "name": "dns_mode",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "query",
+      "propagation",
+      "dnssec"
+    ]
+  }))
+
+  // add field
+  collection.fields.addAt(46, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text3140350773",
+    "max": 0,
+    "min": 0,
+    "name": "dnssec_trust_anchor",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(47, new Field({
+    "hidden": false,
+    "id": "number1182412583",
+    "max": null,
+    "min": null,
+    "name": "dnssec_expiry_warn_days",
+    "onlyInt": true,
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "number"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // update field
+  collection.fields.addAt(45, new Field({
+    "hidden": false,
+    "id": "select1802631506",
+    "maxSelect": 1,
+    "name": "dns_mode",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "query",
+      "propagation"
+    ]
+  }))
+
+  // remove field
+  collection.fields.removeById("text3140350773")
+
+  // remove field
+  collection.fields.removeById("number1182412583")
+
+  return app.save(collection)
+})
@@ -0,0 +1,30 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_285611395")
+
+  // add field
+  collection.fields.addAt(11, new Field({
+    "hidden": false,
+    "id": "select757890407",
+    "maxSelect": 1,
+    "name": "dnssec_status",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "secure",
+      "insecure",
+      "bogus"
+    ]
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_285611395")
+
+  // remove field
+  collection.fields.removeById("select757890407")
+
+  return app.save(collection)
+})
@@ -0,0 +1,66 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // update field
+  collection.fields.addAt(12, new Field({
+    "hidden": false,
+    "id": "select1117643717",
+    "maxSelect": 1,
+    "name": "service_type",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "http",
+      "tcp",
+      "ping",
+      "dns",
+      "mail_auth"
+    ]
+  }))
+
+  // add field
+  collection.fields.addAt(48, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text589244517",
+    "max": 0,
+    "min": 0,
+    "name": "dkim_selectors",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // update field
+  collection.fields.addAt(12, new Field({
+    "hidden": false,
+    "id": "select1117643717",
+    "maxSelect": 1,
+    "name": "service_type",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "http",
+      "tcp",
+      "ping",
+      "dns"
+    ]
+  }))
+
+  // remove field
+  collection.fields.removeById("text589244517")
+
+  return app.save(collection)
+})
@@ -0,0 +1,118 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // add field
+  collection.fields.addAt(50, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text3224413809",
+    "max": 0,
+    "min": 0,
+    "name": "tcp_send",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(51, new Field({
+    "hidden": false,
+    "id": "select1975511728",
+    "maxSelect": 1,
+    "name": "tcp_send_format",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "text",
+      "hex"
+    ]
+  }))
+
+  // add field
+  collection.fields.addAt(52, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text2903239114",
+    "max": 0,
+    "min": 0,
+    "name": "tcp_expect",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(53, new Field({
+    "hidden": false,
+    "id": "select3586253885",
+    "maxSelect": 1,
+    "name": "tcp_expect_mode",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "contains",
+      "not_contains",
+      "regex"
+    ]
+  }))
+
+  // add field
+  collection.fields.addAt(54, new Field({
+    "hidden": false,
+    "id": "bool3668914329",
+    "name": "tcp_read_banner",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "bool"
+  }))
+
+  // add field
+  collection.fields.addAt(55, new Field({
+    "hidden": false,
+    "id": "number910760737",
+    "max": null,
+    "min": null,
+    "name": "tcp_read_timeout",
+    "onlyInt": true,
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "number"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // remove field
+  collection.fields.removeById("text3224413809")
+
+  // remove field
+  collection.fields.removeById("select1975511728")
+
+  // remove field
+  collection.fields.removeById("text2903239114")
+
+  // remove field
+  collection.fields.removeById("select3586253885")
+
+  // remove field
+  collection.fields.removeById("bool3668914329")
+
+  // remove field
+  collection.fields.removeById("number910760737")
+
+  return app.save(collection)
+})
@@ -0,0 +1,159 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // update field
+  collection.fields.addAt(12, new Field({
+    "hidden": false,
+    "id": "select1117643717",
+    "maxSelect": 1,
+    "name": "service_type",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "http",
+      "tcp",
+      "ping",
+      "dns",
+      "mail_auth",
+      "dnsbl",
+      "udp"
+    ]
+  }))
+
+  // add field
+  collection.fields.addAt(56, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text3376257121",
+    "max": 0,
+    "min": 0,
+    "name": "udp_send",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(57, new Field({
+    "hidden": false,
+    "id": "select2844367768",
+    "maxSelect": 1,
+    "name": "udp_send_format",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "text",
+      "hex"
+    ]
+  }))
+
+  // add field
+  collection.fields.addAt(58, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text648605501",
+    "max": 0,
+    "min": 0,
+    "name": "udp_expect",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(59, new Field({
+    "hidden": false,
+    "id": "select167220501",
+    "maxSelect": 1,
+    "name": "udp_expect_mode",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "contains",
+      "not_contains",
+      "regex"
+    ]
+  }))
+
+  // add field
+  collection.fields.addAt(60, new Field({
+    "hidden": false,
+    "id": "number52463018",
+    "max": null,
+    "min": null,
+    "name": "udp_read_timeout",
+    "onlyInt": true,
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "number"
+  }))
+
+  // add field
+  collection.fields.addAt(61, new Field({
+    "hidden": false,
+    "id": "bool1974081668",
+    "name": "udp_allow_no_response",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "bool"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // update field
+  collection.fields.addAt(12, new Field({
+    "hidden": false,
+    "id": "select1117643717",
+    "maxSelect": 1,
+    "name": "service_type",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "http",
+      "tcp",
+      "ping",
+      "dns",
+      "mail_auth",
+      "dnsbl"
+    ]
+  }))
+
+  // remove field
+  collection.fields.removeById("text3376257121")
+
+  // remove field
+  collection.fields.removeById("select2844367768")
+
+  // remove field
+  collection.fields.removeById("text648605501")
+
+  // remove field
+  collection.fields.removeById("select167220501")
+
+  // remove field
+  collection.fields.removeById("number52463018")
+
+  // remove field
+  collection.fields.removeById("bool1974081668")
+
+  return app.save(collection)
+})
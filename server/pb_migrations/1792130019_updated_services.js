@@ -0,0 +1,92 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // add field
+  collection.fields.addAt(63, new Field({
+    "hidden": false,
+    "id": "number3494908846",
+    "max": null,
+    "min": null,
+    "name": "ping_count",
+    "onlyInt": true,
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "number"
+  }))
+
+  // add field
+  collection.fields.addAt(64, new Field({
+    "hidden": false,
+    "id": "number2075248605",
+    "max": null,
+    "min": null,
+    "name": "ping_size",
+    "onlyInt": true,
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "number"
+  }))
+
+  // add field
+  collection.fields.addAt(65, new Field({
+    "hidden": false,
+    "id": "number3773657261",
+    "max": null,
+    "min": null,
+    "name": "ping_interval",
+    "onlyInt": true,
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "number"
+  }))
+
+  // add field
+  collection.fields.addAt(66, new Field({
+    "hidden": false,
+    "id": "number24235073",
+    "max": null,
+    "min": null,
+    "name": "ping_ttl",
+    "onlyInt": true,
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "number"
+  }))
+
+  // add field
+  collection.fields.addAt(67, new Field({
+    "hidden": false,
+    "id": "bool1739282727",
+    "name": "ping_dont_fragment",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "bool"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // remove field
+  collection.fields.removeById("number3494908846")
+
+  // remove field
+  collection.fields.removeById("number2075248605")
+
+  // remove field
+  collection.fields.removeById("number3773657261")
+
+  // remove field
+  collection.fields.removeById("number24235073")
+
+  // remove field
+  collection.fields.removeById("bool1739282727")
+
+  return app.save(collection)
+})
@@ -0,0 +1,86 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_1168766540")
+
+  // add field
+  collection.fields.addAt(17, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text2776672040",
+    "max": 0,
+    "min": 0,
+    "name": "rtt_mean_dev",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(18, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text1342140288",
+    "max": 0,
+    "min": 0,
+    "name": "rtt_std_dev",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(19, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text2440642918",
+    "max": 0,
+    "min": 0,
+    "name": "duplicates",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(20, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text3545331085",
+    "max": 0,
+    "min": 0,
+    "name": "out_of_order",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_1168766540")
+
+  // remove field
+  collection.fields.removeById("text2776672040")
+
+  // remove field
+  collection.fields.removeById("text1342140288")
+
+  // remove field
+  collection.fields.removeById("text2440642918")
+
+  // remove field
+  collection.fields.removeById("text3545331085")
+
+  return app.save(collection)
+})
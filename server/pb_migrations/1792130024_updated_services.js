@@ -0,0 +1,74 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // update field
+  collection.fields.addAt(12, new Field({
+    "hidden": false,
+    "id": "select1117643717",
+    "maxSelect": 1,
+    "name": "service_type",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "http",
+      "tcp",
+      "ping",
+      "dns",
+      "mail_auth",
+      "dnsbl",
+      "udp",
+      "postgres",
+      "mysql",
+      "redis"
+    ]
+  }))
+
+  // add field
+  collection.fields.addAt(69, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text744373322",
+    "max": 0,
+    "min": 0,
+    "name": "database_name",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // update field
+  collection.fields.addAt(12, new Field({
+    "hidden": false,
+    "id": "select1117643717",
+    "maxSelect": 1,
+    "name": "service_type",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "http",
+      "tcp",
+      "ping",
+      "dns",
+      "mail_auth",
+      "dnsbl",
+      "udp"
+    ]
+  }))
+
+  // remove field
+  collection.fields.removeById("text744373322")
+
+  return app.save(collection)
+})
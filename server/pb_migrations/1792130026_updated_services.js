@@ -0,0 +1,81 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // update field
+  collection.fields.addAt(12, new Field({
+    "hidden": false,
+    "id": "select1117643717",
+    "maxSelect": 1,
+    "name": "service_type",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "http",
+      "tcp",
+      "ping",
+      "dns",
+      "mail_auth",
+      "dnsbl",
+      "udp",
+      "postgres",
+      "mysql",
+      "redis",
+      "smtp",
+      "imap",
+      "pop3"
+    ]
+  }))
+
+  // add field
+  collection.fields.addAt(70, new Field({
+    "hidden": false,
+    "id": "select2967876313",
+    "maxSelect": 1,
+    "name": "mail_tls",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "none",
+      "starttls",
+      "implicit"
+    ]
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // update field
+  collection.fields.addAt(12, new Field({
+    "hidden": false,
+    "id": "select1117643717",
+    "maxSelect": 1,
+    "name": "service_type",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "http",
+      "tcp",
+      "ping",
+      "dns",
+      "mail_auth",
+      "dnsbl",
+      "udp",
+      "postgres",
+      "mysql",
+      "redis"
+    ]
+  }))
+
+  // remove field
+  collection.fields.removeById("select2967876313")
+
+  return app.save(collection)
+})
@@ -0,0 +1,34 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_1836745630")
+
+  // add field
+  collection.fields.addAt(23, new Field({
+    "hidden": false,
+    "id": "select3368074316",
+    "maxSelect": 1,
+    "name": "protocol",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "select",
+    "values": [
+      "tls",
+      "smtp",
+      "imap",
+      "pop3",
+      "ftp",
+      "ldap",
+      "postgres"
+    ]
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_1836745630")
+
+  // remove field
+  collection.fields.removeById("select3368074316")
+
+  return app.save(collection)
+})
@@ -0,0 +1,63 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_1836745630")
+
+  // add field
+  collection.fields.addAt(24, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text1585578986",
+    "max": 0,
+    "min": 0,
+    "name": "ca_bundle",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(25, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text3671439411",
+    "max": 0,
+    "min": 0,
+    "name": "chain_status",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(26, new Field({
+    "hidden": false,
+    "id": "json2969704650",
+    "maxSize": 0,
+    "name": "chain",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "json"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_1836745630")
+
+  // remove field
+  collection.fields.removeById("text1585578986")
+
+  // remove field
+  collection.fields.removeById("text3671439411")
+
+  // remove field
+  collection.fields.removeById("json2969704650")
+
+  return app.save(collection)
+})
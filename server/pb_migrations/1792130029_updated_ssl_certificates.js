@@ -0,0 +1,115 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_1836745630")
+
+  // add field
+  collection.fields.addAt(27, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text4273206072",
+    "max": 0,
+    "min": 0,
+    "name": "revocation_status",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(28, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text3661228375",
+    "max": 0,
+    "min": 0,
+    "name": "revocation_source",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(29, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text3188399656",
+    "max": 0,
+    "min": 0,
+    "name": "revocation_reason",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // add field
+  collection.fields.addAt(30, new Field({
+    "hidden": false,
+    "id": "date3687365789",
+    "max": "",
+    "min": "",
+    "name": "revoked_at",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "date"
+  }))
+
+  // add field
+  collection.fields.addAt(31, new Field({
+    "hidden": false,
+    "id": "date3836699627",
+    "max": "",
+    "min": "",
+    "name": "revocation_this_update",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "date"
+  }))
+
+  // add field
+  collection.fields.addAt(32, new Field({
+    "hidden": false,
+    "id": "date3967167295",
+    "max": "",
+    "min": "",
+    "name": "revocation_next_update",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "date"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_1836745630")
+
+  // remove field
+  collection.fields.removeById("text4273206072")
+
+  // remove field
+  collection.fields.removeById("text3661228375")
+
+  // remove field
+  collection.fields.removeById("text3188399656")
+
+  // remove field
+  collection.fields.removeById("date3687365789")
+
+  // remove field
+  collection.fields.removeById("date3836699627")
+
+  // remove field
+  collection.fields.removeById("date3967167295")
+
+  return app.save(collection)
+})
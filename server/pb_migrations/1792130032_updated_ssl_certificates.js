@@ -0,0 +1,24 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_1836745630")
+
+  // add field
+  collection.fields.addAt(33, new Field({
+    "hidden": false,
+    "id": "bool2012386649",
+    "name": "skip_revocation",
+    "presentable": false,
+    "required": false,
+    "system": false,
+    "type": "bool"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_1836745630")
+
+  // remove field
+  collection.fields.removeById("bool2012386649")
+
+  return app.save(collection)
+})
@@ -0,0 +1,74 @@
+/// <reference path="../pb_data/types.d.ts" />
+migrate((app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // update field
+  collection.fields.addAt(32, new Field({
+    "autogeneratePattern": "",
+    "hidden": true,
+    "id": "text288932179",
+    "max": 0,
+    "min": 0,
+    "name": "auth_password",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // update field
+  collection.fields.addAt(33, new Field({
+    "autogeneratePattern": "",
+    "hidden": true,
+    "id": "text2467688526",
+    "max": 0,
+    "min": 0,
+    "name": "auth_token",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  return app.save(collection)
+}, (app) => {
+  const collection = app.findCollectionByNameOrId("pbc_863811952")
+
+  // update field
+  collection.fields.addAt(32, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text288932179",
+    "max": 0,
+    "min": 0,
+    "name": "auth_password",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  // update field
+  collection.fields.addAt(33, new Field({
+    "autogeneratePattern": "",
+    "hidden": false,
+    "id": "text2467688526",
+    "max": 0,
+    "min": 0,
+    "name": "auth_token",
+    "pattern": "",
+    "presentable": false,
+    "primaryKey": false,
+    "required": false,
+    "system": false,
+    "type": "text"
+  }))
+
+  return app.save(collection)
+})
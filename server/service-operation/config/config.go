@@ -14,9 +14,12 @@ type Config struct {
 	MaxTimeout     time.Duration
 	EnableLogging  bool
 	
-	// PocketBase configuration (no auth required)
+	// PocketBase configuration; superuser credentials are only needed to
+	// read hidden service fields such as passwords and tokens
 	PocketBaseEnabled  bool
 	PocketBaseURL      string
+	PocketBaseSuperuserEmail    string
+	PocketBaseSuperuserPassword string
 }
 
 func Load() *Config {
@@ -28,9 +31,11 @@ func Load() *Config {
 		MaxTimeout:     getEnvDuration("MAX_TIMEOUT", 30*time.Second),
 		EnableLogging:  getEnvBool("ENABLE_LOGGING", true),
 		
-		// PocketBase settings (no credentials needed)
+		// PocketBase settings
 		PocketBaseEnabled:  getEnvBool("POCKETBASE_ENABLED", true),
 		PocketBaseURL:      getEnv("POCKETBASE_URL", ""),
+		PocketBaseSuperuserEmail:    getEnv("POCKETBASE_SUPERUSER_EMAIL", ""),
+		PocketBaseSuperuserPassword: getEnv("POCKETBASE_SUPERUSER_PASSWORD", ""),
 	}
 
 	return cfg
@@ -13,7 +13,7 @@ func (h *OperationHandler) HandleHealth(w http.ResponseWriter, r *http.Request)
 		"service":   "service-operation",
 		"timestamp": time.Now().Unix(),
 		"version":   "1.0.0",
-		"operations": []string{"ping", "dns", "tcp", "http"},
+		"operations": []string{"ping", "dns", "tcp", "udp", "http", "mail_auth", "dnsbl", "traceroute", "postgres", "mysql", "redis", "smtp", "imap", "pop3"},
 	}
 
 	w.Header().Set("Content-Type", "application/json")
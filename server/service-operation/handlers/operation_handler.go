@@ -49,7 +49,13 @@ func (h *OperationHandler) HandleOperation(w http.ResponseWriter, r *http.Reques
 	switch req.Type {
 	case types.OperationPing:
 		pingOp := operations.NewPingOperation(timeout)
-		result, err = pingOp.Execute(req.Host, req.Count)
+		result, err = pingOp.ExecuteWithOptions(req.Host, req.Count, operations.PingOptions{
+			Family:       req.IPFamily,
+			Size:         req.PingSize,
+			Interval:     time.Duration(req.PingInterval) * time.Millisecond,
+			TTL:          req.PingTTL,
+			DontFragment: req.PingDontFragment,
+		})
 		
 	case types.OperationDNS:
 		dnsOp := operations.NewDNSOperation(timeout)
@@ -57,7 +63,33 @@ func (h *OperationHandler) HandleOperation(w http.ResponseWriter, r *http.Reques
 		if query == "" {
 			query = "A"
 		}
-		result, err = dnsOp.Execute(req.Host, query)
+		if !operations.IsSupportedDNSType(query) {
+			http.Error(w, "Unsupported DNS record type", http.StatusBadRequest)
+			return
+		}
+		dnsOpts := operations.DNSQueryOptions{
+			Nameservers: operations.SplitList(req.Nameserver),
+			Transport:   req.DNSTransport,
+		}
+		switch req.DNSMode {
+		case "", operations.DNSModeQuery:
+			result, err = dnsOp.ExecuteWithOptions(req.Host, query, dnsOpts)
+		case operations.DNSModePropagation:
+			result, err = dnsOp.ExecutePropagation(req.Host, query, dnsOpts)
+		case operations.DNSModeDNSSEC:
+			dnsOpts.TrustAnchors = operations.SplitList(req.DNSSECTrustAnchor)
+			dnsOpts.ExpiryWarning = time.Duration(req.DNSSECExpiryWarnDays) * 24 * time.Hour
+			result, err = dnsOp.ExecuteDNSSEC(req.Host, query, dnsOpts)
+		default:
+			http.Error(w, "Invalid DNS mode", http.StatusBadRequest)
+			return
+		}
+		if err == nil && len(req.DNSExpected) > 0 {
+			dnsOp.CheckExpectedAnswers(result, operations.DNSExpectation{
+				Mode:   req.DNSMatchMode,
+				Values: req.DNSExpected,
+			})
+		}
 		
 	case types.OperationTCP:
 		if req.Port <= 0 {
@@ -65,7 +97,27 @@ func (h *OperationHandler) HandleOperation(w http.ResponseWriter, r *http.Reques
 			return
 		}
 		tcpOp := operations.NewTCPOperation(timeout)
-		result, err = tcpOp.Execute(req.Host, req.Port)
+		result, err = tcpOp.ExecuteWithOptions(req.Host, req.Port, operations.TCPProbeOptions{
+			Send:        req.TCPSend,
+			SendFormat:  req.TCPSendFormat,
+			Expect:      operations.KeywordCheck{Keyword: req.TCPExpect, Mode: req.TCPExpectMode},
+			ReadBanner:  req.TCPReadBanner,
+			ReadTimeout: time.Duration(req.TCPReadTimeout) * time.Second,
+		})
+		
+	case types.OperationUDP:
+		if req.Port <= 0 {
+			http.Error(w, "Port is required for UDP operations", http.StatusBadRequest)
+			return
+		}
+		udpOp := operations.NewUDPOperation(timeout)
+		result, err = udpOp.ExecuteWithOptions(req.Host, req.Port, operations.UDPProbeOptions{
+			Send:            req.UDPSend,
+			SendFormat:      req.UDPSendFormat,
+			Expect:          operations.KeywordCheck{Keyword: req.UDPExpect, Mode: req.UDPExpectMode},
+			ReadTimeout:     time.Duration(req.UDPReadTimeout) * time.Second,
+			AllowNoResponse: req.UDPAllowNoResponse,
+		})
 		
 	case types.OperationHTTP:
 		httpOp := operations.NewHTTPOperation(timeout)
@@ -73,15 +125,72 @@ func (h *OperationHandler) HandleOperation(w http.ResponseWriter, r *http.Reques
 		if url == "" {
 			url = req.Host
 		}
-		method := req.Method
-		if method == "" {
-			method = "GET"
+		opts := operations.HTTPRequestOptionsFromRequest(req)
+		if opts.Method == "" {
+			opts.Method = "GET"
+		}
+		result, err = httpOp.ExecuteWithOptions(url, opts)
+		if err == nil && req.ExpectedFinalURL != "" {
+			httpOp.CheckFinalURL(result, req.ExpectedFinalURL)
+		}
+		if err == nil && len(req.JSONAssertions) > 0 {
+			httpOp.CheckJSONAssertions(result, req.JSONAssertions)
 		}
-		result, err = httpOp.Execute(url, method)
 		
 	case types.OperationSSL:
 		sslOp := operations.NewSSLOperation(timeout)
-		result, err = sslOp.Execute(req.Host)
+		result, err = sslOp.ExecuteWithOptions(req.Host, operations.SSLOptions{
+			Protocol: req.SSLProtocol,
+			Port:     req.Port,
+			CABundle: req.CABundle,
+			SkipRevocation: req.SSLSkipRevocation,
+		})
+		
+	case types.OperationDNSBL:
+		dnsblOp := operations.NewDNSBLOperation(timeout)
+		result, err = dnsblOp.ExecuteWithOptions(operations.SplitList(req.Host), operations.DNSBLOptions{
+			Zones:       req.DNSBLZones,
+			Nameservers: operations.SplitList(req.Nameserver),
+			Transport:   req.DNSTransport,
+		})
+		
+	case types.OperationTraceroute:
+		traceOp := operations.NewTracerouteOperation(timeout)
+		result, err = traceOp.ExecuteWithOptions(req.Host, operations.TracerouteOptions{
+			Protocol: req.TraceProtocol,
+			Port:     req.Port,
+			Family:   req.IPFamily,
+			MaxHops:  req.TraceMaxHops,
+			Rounds:   req.TraceRounds,
+		})
+		
+	case types.OperationPostgres, types.OperationMySQL, types.OperationRedis:
+		dbOp := operations.NewDatabaseOperation(timeout)
+		result, err = dbOp.ExecuteWithOptions(string(req.Type), req.Host, req.Port, operations.DatabaseOptions{
+			Username: req.Username,
+			Password: req.Password,
+			Database: req.Database,
+			TLS:                req.DatabaseTLS,
+			InsecureSkipVerify: req.InsecureSkipVerify,
+			AllowInsecureAuth:  req.AllowInsecureAuth,
+		})
+		
+	case types.OperationSMTP, types.OperationIMAP, types.OperationPOP3:
+		mailServerOp := operations.NewMailServerOperation(timeout)
+		result, err = mailServerOp.ExecuteWithOptions(string(req.Type), req.Host, req.Port, operations.MailServerOptions{
+			TLS:                req.MailTLS,
+			Username:           req.Username,
+			Password:           req.Password,
+			InsecureSkipVerify: req.InsecureSkipVerify,
+		})
+		
+	case types.OperationMailAuth:
+		mailOp := operations.NewMailAuthOperation(timeout)
+		result, err = mailOp.ExecuteWithOptions(req.Host, operations.MailAuthOptions{
+			DKIMSelectors: req.DKIMSelectors,
+			Nameservers:   operations.SplitList(req.Nameserver),
+			Transport:     req.DNSTransport,
+		})
 		
 	default:
 		http.Error(w, "Invalid operation type", http.StatusBadRequest)
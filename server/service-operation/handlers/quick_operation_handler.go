@@ -36,10 +36,68 @@ func (h *OperationHandler) HandleQuickOperation(w http.ResponseWriter, r *http.R
 		}
 	}
 
+	if family := r.URL.Query().Get("ip_family"); family != "" {
+		req.IPFamily = family
+	}
+
+	if sizeStr := r.URL.Query().Get("ping_size"); sizeStr != "" {
+		if s, err := strconv.Atoi(sizeStr); err == nil {
+			req.PingSize = s
+		}
+	}
+
+	if intervalStr := r.URL.Query().Get("ping_interval"); intervalStr != "" {
+		if i, err := strconv.Atoi(intervalStr); err == nil {
+			req.PingInterval = i
+		}
+	}
+
+	if ttlStr := r.URL.Query().Get("ping_ttl"); ttlStr != "" {
+		if t, err := strconv.Atoi(ttlStr); err == nil {
+			req.PingTTL = t
+		}
+	}
+
+	if df := r.URL.Query().Get("ping_dont_fragment"); df != "" {
+		req.PingDontFragment = df == "true" || df == "1"
+	}
+
+	if protocol := r.URL.Query().Get("trace_protocol"); protocol != "" {
+		req.TraceProtocol = protocol
+	}
+
+	if hopsStr := r.URL.Query().Get("trace_max_hops"); hopsStr != "" {
+		if n, err := strconv.Atoi(hopsStr); err == nil {
+			req.TraceMaxHops = n
+		}
+	}
+
+	if roundsStr := r.URL.Query().Get("trace_rounds"); roundsStr != "" {
+		if n, err := strconv.Atoi(roundsStr); err == nil {
+			req.TraceRounds = n
+		}
+	}
+
+	if database := r.URL.Query().Get("database"); database != "" {
+		req.Database = database
+	}
+
+	if sslProtocol := r.URL.Query().Get("ssl_protocol"); sslProtocol != "" {
+		req.SSLProtocol = sslProtocol
+	}
+
+	if mailTLS := r.URL.Query().Get("mail_tls"); mailTLS != "" {
+		req.MailTLS = mailTLS
+	}
+
 	if query := r.URL.Query().Get("query"); query != "" {
 		req.Query = query
 	}
 
+	if nameserver := r.URL.Query().Get("nameserver"); nameserver != "" {
+		req.Nameserver = nameserver
+	}
+
 	if url := r.URL.Query().Get("url"); url != "" {
 		req.URL = url
 	}
@@ -29,17 +29,52 @@ func formatResultDetails(result *types.OperationResult) string {
 		details["packets_recv"] = result.PacketsRecv
 		details["packet_loss"] = result.PacketLoss
 		details["avg_rtt"] = result.AvgRTT
+		details["rtt_mean_dev"] = result.RTTMeanDev
+		details["rtt_std_dev"] = result.RTTStdDev
+		details["duplicates"] = result.PingDuplicates
+		details["out_of_order"] = result.PingOutOfOrder
 	case types.OperationHTTP:
 		details["status_code"] = result.HTTPStatusCode
 		details["method"] = result.HTTPMethod
 		details["content_length"] = result.ContentLength
 	case types.OperationTCP:
 		details["tcp_connected"] = result.TCPConnected
+		if result.TCPBanner != "" {
+			details["tcp_banner"] = result.TCPBanner
+		}
+	case types.OperationUDP:
+		details["udp_reachability"] = result.UDPReachability
+		if result.UDPResponse != "" {
+			details["udp_response"] = result.UDPResponse
+		}
 	case types.OperationDNS:
 		details["dns_records"] = result.DNSRecords
 		details["dns_type"] = result.DNSType
+	case types.OperationMailAuth:
+		details["mail_auth_findings"] = result.MailAuthFindings
+		details["spf_lookups"] = result.SPFLookups
+	case types.OperationDNSBL:
+		details["dnsbl_addresses"] = result.DNSBLAddresses
+		details["dnsbl_listings"] = result.DNSBLListings
+	case types.OperationPostgres, types.OperationMySQL, types.OperationRedis:
+		details["db_server_version"] = result.DBServerVersion
+		details["db_handshake_time"] = result.DBHandshakeTime
+		if result.DBErrorKind != "" {
+			details["db_error_kind"] = result.DBErrorKind
+		}
+	case types.OperationSMTP, types.OperationIMAP, types.OperationPOP3:
+		details["mail_greeting"] = result.MailGreeting
+		details["mail_capabilities"] = result.MailCapabilities
+		details["tls_version"] = result.TLSVersion
+		if result.MailErrorKind != "" {
+			details["mail_error_kind"] = result.MailErrorKind
+		}
+	case types.OperationTraceroute:
+		details["trace_address"] = result.TraceAddress
+		details["trace_reached"] = result.TraceReached
+		details["trace_hops"] = len(result.TraceHops)
 	}
 
 	jsonData, _ := json.Marshal(details)
 	return string(jsonData)
-}
\ No newline at end of file
+}
@@ -28,7 +28,7 @@ func main() {
 		//log.Printf("  - PocketBase URL: %s", cfg.PocketBaseURL)
 	}
 	
-	// Initialize PocketBase client (no credentials required)
+	// Initialize PocketBase client
 	var pbClient *pocketbase.PocketBaseClient
 	var monitoringService *monitoring.MonitoringService
 	var sslMonitoringService *monitoring.SSLMonitoringService
@@ -43,6 +43,9 @@ func main() {
 		if err != nil {
 			//log.Printf("⚠️  WARNING: Failed to initialize PocketBase client: %v", err)
 		} else {
+			// Needed to read the hidden credential fields of services
+			pbClient.SetSuperuserCredentials(cfg.PocketBaseSuperuserEmail, cfg.PocketBaseSuperuserPassword)
+			
 			//log.Println("✅ PocketBase client initialized successfully")
 			
 			//log.Println("🔍 Testing PocketBase connection...")
@@ -122,7 +125,7 @@ func main() {
 	if uptimeMonitoringService != nil {
 		log.Printf("✓Uptime monitoring enabled with notification support")
 	}
-	log.Printf("✓Supported operations: ping, dns, tcp, http, ssl")
+	log.Printf("✓Supported operations: ping, dns, tcp, udp, http, ssl, mail_auth, dnsbl, traceroute, postgres, mysql, redis, smtp, imap, pop3")
 	
 
 	// Setup graceful shutdown
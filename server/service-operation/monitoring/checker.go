@@ -40,7 +40,18 @@ func (ms *MonitoringService) performCheck(service pocketbase.Service) {
 		if host == "" {
 			host = latestService.URL
 		}
-		result, err = pingOp.Execute(host, 1) // Single ping for monitoring
+		// Single ping for monitoring unless more packets are needed for jitter
+		count := latestService.PingCount
+		if count <= 0 {
+			count = 1
+		}
+		result, err = pingOp.ExecuteWithOptions(host, count, operations.PingOptions{
+			Family:       latestService.IPFamily,
+			Size:         latestService.PingSize,
+			Interval:     time.Duration(latestService.PingInterval) * time.Millisecond,
+			TTL:          latestService.PingTTL,
+			DontFragment: latestService.PingDontFragment,
+		})
 		
 	case "dns":
 		dnsOp := operations.NewDNSOperation(timeout)
@@ -48,9 +59,31 @@ func (ms *MonitoringService) performCheck(service pocketbase.Service) {
 		if host == "" {
 			host = latestService.Domain
 		}
-		// Default to A record, but could be made configurable
-		queryType := "A"
-		result, err = dnsOp.Execute(host, queryType)
+		// Default to A record
+		queryType := latestService.DNSQueryType
+		if queryType == "" {
+			queryType = "A"
+		}
+		dnsOpts := operations.DNSQueryOptions{
+			Nameservers: operations.SplitList(latestService.Nameserver),
+			Transport:   latestService.DNSTransport,
+		}
+		switch latestService.DNSMode {
+		case operations.DNSModePropagation:
+			result, err = dnsOp.ExecutePropagation(host, queryType, dnsOpts)
+		case operations.DNSModeDNSSEC:
+			dnsOpts.TrustAnchors = operations.SplitList(latestService.DNSSECTrustAnchor)
+			dnsOpts.ExpiryWarning = time.Duration(latestService.DNSSECExpiryWarnDays) * 24 * time.Hour
+			result, err = dnsOp.ExecuteDNSSEC(host, queryType, dnsOpts)
+		default:
+			result, err = dnsOp.ExecuteWithOptions(host, queryType, dnsOpts)
+		}
+		if err == nil && latestService.DNSExpected != "" {
+			dnsOp.CheckExpectedAnswers(result, operations.DNSExpectation{
+				Mode:   latestService.DNSMatchMode,
+				Values: operations.SplitList(latestService.DNSExpected),
+			})
+		}
 		
 	case "tcp":
 		tcpOp := operations.NewTCPOperation(timeout)
@@ -62,7 +95,27 @@ func (ms *MonitoringService) performCheck(service pocketbase.Service) {
 		if port <= 0 {
 			port = 80 // Default port
 		}
-		result, err = tcpOp.Execute(host, port)
+		result, err = tcpOp.ExecuteWithOptions(host, port, operations.TCPProbeOptions{
+			Send:        latestService.TCPSend,
+			SendFormat:  latestService.TCPSendFormat,
+			Expect:      operations.KeywordCheck{Keyword: latestService.TCPExpect, Mode: latestService.TCPExpectMode},
+			ReadBanner:  latestService.TCPReadBanner,
+			ReadTimeout: time.Duration(latestService.TCPReadTimeout) * time.Second,
+		})
+		
+	case "udp":
+		udpOp := operations.NewUDPOperation(timeout)
+		host := latestService.Host
+		if host == "" {
+			host = latestService.URL
+		}
+		result, err = udpOp.ExecuteWithOptions(host, latestService.Port, operations.UDPProbeOptions{
+			Send:            latestService.UDPSend,
+			SendFormat:      latestService.UDPSendFormat,
+			Expect:          operations.KeywordCheck{Keyword: latestService.UDPExpect, Mode: latestService.UDPExpectMode},
+			ReadTimeout:     time.Duration(latestService.UDPReadTimeout) * time.Second,
+			AllowNoResponse: latestService.UDPAllowNoResponse,
+		})
 		
 	case "http", "https":
 		httpOp := operations.NewHTTPOperation(timeout)
@@ -70,7 +123,82 @@ func (ms *MonitoringService) performCheck(service pocketbase.Service) {
 		if url == "" {
 			url = latestService.Host
 		}
-		result, err = httpOp.Execute(url, "GET")
+		result, err = httpOp.ExecuteWithOptions(url, operations.HTTPRequestOptions{
+			Method:             latestService.HTTPMethod,
+			Headers:            latestService.RequestHeaders,
+			Body:               latestService.RequestBody,
+			ContentType:        latestService.RequestContentType,
+			AuthType:           latestService.AuthType,
+			Username:           latestService.AuthUsername,
+			Password:           latestService.AuthPassword,
+			BearerToken:        latestService.AuthToken,
+			QueryParams:        latestService.QueryParams,
+			InsecureSkipVerify: latestService.IgnoreTLSErrors,
+			RedirectPolicy:     latestService.RedirectPolicy,
+			MaxRedirects:       latestService.MaxRedirects,
+		})
+		if err == nil && latestService.StatusCodes != "" {
+			httpOp.CheckStatusCodes(result, latestService.StatusCodes)
+		}
+		if err == nil && latestService.ExpectedFinalURL != "" {
+			httpOp.CheckFinalURL(result, latestService.ExpectedFinalURL)
+		}
+		if err == nil && latestService.Keyword != "" {
+			httpOp.CheckKeyword(result, operations.KeywordCheck{
+				Keyword:    latestService.Keyword,
+				Mode:       latestService.KeywordMode,
+				IgnoreCase: latestService.KeywordIgnoreCase,
+			})
+		}
+		if err == nil && len(latestService.JSONAssertions) > 0 {
+			httpOp.CheckJSONAssertions(result, latestService.JSONAssertions)
+		}
+		
+	case "dnsbl":
+		dnsblOp := operations.NewDNSBLOperation(timeout)
+		targets := operations.SplitList(latestService.Host)
+		if len(targets) == 0 {
+			targets = operations.SplitList(latestService.Domain)
+		}
+		result, err = dnsblOp.ExecuteWithOptions(targets, operations.DNSBLOptions{
+			Zones:       operations.SplitList(latestService.DNSBLZones),
+			Nameservers: operations.SplitList(latestService.Nameserver),
+			Transport:   latestService.DNSTransport,
+		})
+		
+	case "postgres", "mysql", "redis":
+		// Logins reuse the service's auth_username and auth_password
+		dbOp := operations.NewDatabaseOperation(timeout)
+		result, err = dbOp.ExecuteWithOptions(serviceType, latestService.Host, latestService.Port, operations.DatabaseOptions{
+			Username: latestService.AuthUsername,
+			Password: latestService.AuthPassword,
+			Database: latestService.DatabaseName,
+			TLS:                latestService.DatabaseTLS,
+			InsecureSkipVerify: latestService.IgnoreTLSErrors,
+			AllowInsecureAuth:  latestService.AllowInsecureAuth,
+		})
+		
+	case "smtp", "imap", "pop3":
+		// Logins reuse the service's auth_username and auth_password
+		mailServerOp := operations.NewMailServerOperation(timeout)
+		result, err = mailServerOp.ExecuteWithOptions(serviceType, latestService.Host, latestService.Port, operations.MailServerOptions{
+			TLS:                latestService.MailTLS,
+			Username:           latestService.AuthUsername,
+			Password:           latestService.AuthPassword,
+			InsecureSkipVerify: latestService.IgnoreTLSErrors,
+		})
+		
+	case "mail_auth":
+		mailOp := operations.NewMailAuthOperation(timeout)
+		domain := latestService.Domain
+		if domain == "" {
+			domain = latestService.Host
+		}
+		result, err = mailOp.ExecuteWithOptions(domain, operations.MailAuthOptions{
+			DKIMSelectors: operations.SplitList(latestService.DKIMSelectors),
+			Nameservers:   operations.SplitList(latestService.Nameserver),
+			Transport:     latestService.DNSTransport,
+		})
 		
 	default:
 		log.Printf("Unknown service type: %s for service %s", latestService.ServiceType, latestService.Name)
@@ -120,4 +248,40 @@ func (ms *MonitoringService) performCheck(service pocketbase.Service) {
 		metricsSaver := savers.NewMetricsSaverWithRegion(ms.pbClient, regionName, agentID)
 		metricsSaver.SaveMetricsForService(*latestService, result)
 	}
+
+	// Record the network path once when the service goes down
+	if status == "down" && currentService.Status != "down" && latestService.TracerouteOnDown {
+		go ms.traceDownService(*latestService)
+	}
+}
+
+// traceDownService runs a traceroute to a service that just went down and saves
+// it as diagnostic context. Ping services are traced with ICMP probes and TCP
+// services with SYNs to their port, so firewalls treat the probes like the check.
+func (ms *MonitoringService) traceDownService(service pocketbase.Service) {
+	opts := operations.TracerouteOptions{Family: service.IPFamily}
+	// Ping and TCP checks both fall back to the URL field
+	host := service.Host
+	if host == "" {
+		host = service.URL
+	}
+	switch strings.ToLower(service.ServiceType) {
+	case "ping", "icmp":
+		opts.Protocol = "icmp"
+	case "tcp":
+		opts.Protocol = "tcp"
+		opts.Port = service.Port
+	default:
+		return
+	}
+
+	result, err := operations.NewTracerouteOperation(2 * time.Second).ExecuteWithOptions(host, opts)
+	if err != nil {
+		log.Printf("Traceroute for %s failed: %v", service.Name, err)
+		return
+	}
+
+	regionName, agentID := ms.GetRegionalInfo()
+	metricsSaver := savers.NewMetricsSaverWithRegion(ms.pbClient, regionName, agentID)
+	metricsSaver.SaveTracerouteDataToPocketBase(result, service.ID, "service_down")
 }
\ No newline at end of file
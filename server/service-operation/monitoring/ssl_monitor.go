@@ -185,7 +185,7 @@ func (s *SSLMonitoringService) checkSingleCertificateWithRetry(cert types.SSLCer
 	// log.Printf("🔍 Checking SSL certificate for domain: %s (attempt %d/%d)", 
 	//	cert.Domain, retryCount+1, s.maxRetries+1)
 	
-	result, err := s.performSSLCheck(cert.Domain)
+	result, err := s.performSSLCheck(cert)
 	
 	if err != nil && retryCount < s.maxRetries {
 		// Increment retry count and schedule retry
@@ -219,10 +219,15 @@ func (s *SSLMonitoringService) checkSingleCertificateWithRetry(cert types.SSLCer
 	s.updateCertificateWithResults(cert, result)
 }
 
-func (s *SSLMonitoringService) performSSLCheck(domain string) (*types.OperationResult, error) {
+func (s *SSLMonitoringService) performSSLCheck(cert types.SSLCertificate) (*types.OperationResult, error) {
+	domain := cert.Domain
 	// log.Printf("Performing SSL check for domain: %s", domain)
 	sslOp := operations.NewSSLOperation(30 * time.Second)
-	result, err := sslOp.Execute(domain)
+	result, err := sslOp.ExecuteWithOptions(domain, operations.SSLOptions{
+		Protocol: cert.Protocol,
+		CABundle: cert.CABundle,
+		SkipRevocation: cert.SkipRevocation,
+	})
 	
 	if err != nil {
 		// log.Printf("SSL operation failed for %s: %v", domain, err)
@@ -298,8 +303,16 @@ func (s *SSLMonitoringService) updateCertificateWithResults(cert types.SSLCertif
 		"serial_number":         result.SSLSerialNumber,
 		"cert_alg":              result.SSLAlgorithm,
 		"cert_sans":             result.SSLSANs,
+		"chain_status":          result.SSLChainStatus,
+		"chain":                 result.SSLChain,       // Per-certificate expiry, leaf first
+		"revocation_status":     result.SSLRevocationStatus,
+		"revocation_source":     result.SSLRevocationSource,
+		"revocation_reason":     result.SSLRevocationReason,
+		"revoked_at":            formatOptionalTime(result.SSLRevokedAt),
+		"revocation_this_update": formatOptionalTime(result.SSLRevocationThisUpdate),
+		"revocation_next_update": formatOptionalTime(result.SSLRevocationNextUpdate),
 		"updated":               time.Now().Format(time.RFC3339),
-		"error_message":         "", // Clear any previous error
+		"error_message":         result.Error, // Clears any previous error, or explains the error status
 	}
 
 	// Calculate next check time based on check_interval (in days) and certificate status
@@ -328,6 +341,10 @@ func (s *SSLMonitoringService) updateCertificateWithResults(cert types.SSLCertif
 }
 
 func getSSLStatus(result *types.OperationResult) string {
+	// A revoked certificate fails the check, but is not an error to retry
+	if result.SSLRevocationStatus == operations.RevocationRevoked {
+		return "revoked"
+	}
 	if !result.Success {
 		return "error"
 	}
@@ -341,4 +358,12 @@ func getSSLStatus(result *types.OperationResult) string {
 	}
 	
 	return "valid"
-}
\ No newline at end of file
+}
+
+// formatOptionalTime formats t for PocketBase, leaving unset times empty
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -176,6 +176,7 @@ func (ds *DiscordService) determineColorFromMessage(message string) int {
 	   strings.Contains(messageLower, "failed") || 
 	   strings.Contains(messageLower, "error") || 
 	   strings.Contains(messageLower, "critical") ||
+	   strings.Contains(messageLower, "revoked") ||
 	   strings.Contains(messageLower, "🚨") ||
 	   strings.Contains(messageLower, "🔴") {
 		return 15158332 // Red (#E74C3C)
@@ -17,6 +17,7 @@ type SSLNotificationTemplate struct {
 	Expired      string `json:"expired"`
 	ExpiringSoon string `json:"exiring_soon"`
 	Warning      string `json:"warning"`
+	Revoked      string `json:"revoked"`
 	Placeholder  string `json:"placeholder"`
 }
 
@@ -171,6 +172,9 @@ func (snm *SSLNotificationManager) generateSSLMessage(payload *NotificationPaylo
 		//	template.Expired, template.ExpiringSoon, template.Warning)
 		
 		switch strings.ToLower(payload.Status) {
+		case "revoked":
+			// Templates created before revocation checks have no revoked message
+			baseMessage = template.Revoked
 		case "expired":
 			baseMessage = template.Expired
 			// log.Printf("🔧 [SSL-EXPIRED] Selected expired template: '%s'", baseMessage)
@@ -216,6 +220,8 @@ func (snm *SSLNotificationManager) replaceSSLPlaceholders(message string, payloa
 	message = strings.ReplaceAll(message, "${days_left}", snm.safeString(payload.DaysLeft))
 	message = strings.ReplaceAll(message, "${issuer_cn}", snm.safeString(payload.IssuerCN))
 	message = strings.ReplaceAll(message, "${serial_number}", snm.safeString(payload.SerialNumber))
+	message = strings.ReplaceAll(message, "${revocation_reason}", snm.safeString(payload.RevocationReason))
+	message = strings.ReplaceAll(message, "${revoked_at}", snm.safeString(payload.RevokedAt))
 	
 	// Basic placeholders
 	message = strings.ReplaceAll(message, "${status}", strings.ToUpper(payload.Status))
@@ -250,7 +256,9 @@ func (snm *SSLNotificationManager) safeString(value string) string {
 // getDefaultSSLMessage provides a default notification message for SSL certificates
 func (snm *SSLNotificationManager) getDefaultSSLMessage(payload *NotificationPayload) string {
 	statusEmoji := "🔒"
-	if payload.Status == "expired" {
+	if payload.Status == "revoked" {
+		statusEmoji = "🚫"
+	} else if payload.Status == "expired" {
 		statusEmoji = "🚨"
 	} else if payload.Status == "expiring_soon" {
 		statusEmoji = "⚠️"
@@ -259,13 +267,25 @@ func (snm *SSLNotificationManager) getDefaultSSLMessage(payload *NotificationPay
 	}
 
 	// Create the default message with all SSL details
-	message := fmt.Sprintf("%s SSL certificate for %s has %s", statusEmoji, payload.Domain, strings.ToUpper(payload.Status))
+	verb := "has"
+	if payload.Status == "revoked" {
+		verb = "was"
+	}
+	message := fmt.Sprintf("%s SSL certificate for %s %s %s", statusEmoji, payload.Domain, verb, strings.ToUpper(payload.Status))
 	
 	// Add certificate details if available
 	if payload.CertificateName != "" && payload.CertificateName != payload.Domain {
 		message += fmt.Sprintf("\n • Certs Name: %s", payload.CertificateName)
 	}
 	
+	if payload.RevokedAt != "" {
+		message += fmt.Sprintf("\n • Revoked At: %s", payload.RevokedAt)
+	}
+	
+	if payload.RevocationReason != "" {
+		message += fmt.Sprintf("\n • Revocation Reason: %s", payload.RevocationReason)
+	}
+	
 	if payload.ExpiryDate != "" {
 		message += fmt.Sprintf("\n • Expiry Date: %s", payload.ExpiryDate)
 	}
@@ -38,6 +38,8 @@ type NotificationPayload struct {
 	DaysLeft        string    `json:"days_left,omitempty"`
 	IssuerCN        string    `json:"issuer_cn,omitempty"`
 	SerialNumber    string    `json:"serial_number,omitempty"`
+	RevocationReason string   `json:"revocation_reason,omitempty"`
+	RevokedAt        string   `json:"revoked_at,omitempty"`
 }
 
 // AlertConfiguration represents an alert configuration from PocketBase
@@ -0,0 +1,234 @@
+package operations
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"service-operation/types"
+)
+
+// Database engines probed by DatabaseOperation, matching the operation and service types
+const (
+	DatabasePostgres = "postgres"
+	DatabaseMySQL    = "mysql"
+	DatabaseRedis    = "redis"
+)
+
+// Stages at which a database probe fails, reported as db_error_kind
+const (
+	DBErrorConnection = "connection"
+	DBErrorHandshake  = "handshake"
+	DBErrorAuth       = "auth"
+	DBErrorQuery      = "query"
+)
+
+// databaseApplicationName identifies the probe's sessions on the server
+const databaseApplicationName = "ServiceOperation"
+
+// databaseMaxMessage caps the size of a single protocol message read from the server
+const databaseMaxMessage = 1 << 20
+
+var defaultDatabasePorts = map[string]int{
+	DatabasePostgres: 5432,
+	DatabaseMySQL:    3306,
+	DatabaseRedis:    6379,
+}
+
+type DatabaseOperation struct {
+	timeout time.Duration
+}
+
+// DatabaseOptions holds the login used by a probe. Without a username (a
+// password for Redis) the probe only checks that the server answers its
+// protocol handshake, and a server asking for credentials counts as up.
+type DatabaseOptions struct {
+	Username string
+	Password string
+	Database string // PostgreSQL database, MySQL schema or Redis database number
+
+	// PostgreSQL and MySQL probes ask for TLS and verify the server
+	// certificate; Redis has no STARTTLS and only uses TLS when TLS is set
+	TLS                bool
+	InsecureSkipVerify bool
+	// Allows PostgreSQL cleartext and MD5 passwords, MySQL passwords
+	// encrypted with an unauthenticated RSA key and Redis AUTH without TLS
+	AllowInsecureAuth bool
+}
+
+// databaseSession is an open probe connection and what was learned over it
+type databaseSession struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	host     string
+	start    time.Time
+	tlsState *tls.ConnectionState
+
+	version       string
+	authMethod    string
+	authenticated bool
+	loginSkipped  bool // Stopped before logging in, as no credentials are configured
+	handshakeTime time.Duration
+	queryTime     time.Duration
+
+	mysqlSeq byte // Sequence number of the next MySQL packet
+}
+
+// startTLS upgrades the session's connection to TLS, verifying the server
+// certificate unless InsecureSkipVerify is set
+func (s *databaseSession) startTLS(opts DatabaseOptions) error {
+	tlsConn := tls.Client(s.conn, &tls.Config{
+		ServerName:         s.host,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		return stageError(DBErrorHandshake, "TLS handshake failed: %v", err)
+	}
+	state := tlsConn.ConnectionState()
+	s.tlsState = &state
+	s.conn = tlsConn
+	s.reader = bufio.NewReader(tlsConn)
+	return nil
+}
+
+// handshakeDone records the time from dialing until the server accepted the session
+func (s *databaseSession) handshakeDone() {
+	s.handshakeTime = time.Since(s.start)
+}
+
+func NewDatabaseOperation(timeout time.Duration) *DatabaseOperation {
+	return &DatabaseOperation{timeout: timeout}
+}
+
+func (d *DatabaseOperation) Execute(engine, host string, port int) (*types.OperationResult, error) {
+	return d.ExecuteWithOptions(engine, host, port, DatabaseOptions{})
+}
+
+// ExecuteWithOptions connects to a database server, performs its protocol
+// handshake and login, and runs a trivial query: SELECT 1 on PostgreSQL, a
+// ping on MySQL and Redis. A zero port uses the engine's default port.
+func (d *DatabaseOperation) ExecuteWithOptions(engine, host string, port int, opts DatabaseOptions) (*types.OperationResult, error) {
+	if host == "" {
+		return nil, fmt.Errorf("host cannot be empty")
+	}
+
+	var opType types.OperationType
+	var probe func(*databaseSession, DatabaseOptions) error
+	switch strings.ToLower(engine) {
+	case DatabasePostgres:
+		opType, probe = types.OperationPostgres, probePostgres
+	case DatabaseMySQL:
+		opType, probe = types.OperationMySQL, probeMySQL
+	case DatabaseRedis:
+		if opts.Database != "" {
+			if n, err := strconv.Atoi(opts.Database); err != nil || n < 0 {
+				return nil, fmt.Errorf("redis database must be a non-negative number, got %q", opts.Database)
+			}
+		}
+		opType, probe = types.OperationRedis, probeRedis
+	default:
+		return nil, fmt.Errorf("unsupported database type %q", engine)
+	}
+	engine = string(opType)
+	if port <= 0 {
+		port = defaultDatabasePorts[engine]
+	}
+
+	result := &types.OperationResult{
+		Type:      opType,
+		Host:      host,
+		Port:      port,
+		StartTime: time.Now(),
+	}
+
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	conn, err := net.DialTimeout("tcp", address, d.timeout)
+	result.TCPConnectTime = time.Since(result.StartTime)
+	if err != nil {
+		err = stageError(DBErrorConnection, "%v", err)
+	} else {
+		defer conn.Close()
+		if d.timeout > 0 {
+			conn.SetDeadline(result.StartTime.Add(d.timeout))
+		}
+
+		session := &databaseSession{conn: conn, reader: bufio.NewReader(conn), host: host, start: result.StartTime}
+		err = probe(session, opts)
+
+		result.DBServerVersion = session.version
+		result.DBAuthMethod = session.authMethod
+		result.DBAuthenticated = session.authenticated
+		result.DBHandshakeTime = session.handshakeTime
+		result.DBQueryTime = session.queryTime
+		result.DBLoginSkipped = session.loginSkipped
+		if session.tlsState != nil {
+			result.TLSVersion = tls.VersionName(session.tlsState.Version)
+			result.TLSCipherSuite = tls.CipherSuiteName(session.tlsState.CipherSuite)
+		}
+	}
+
+	result.EndTime = time.Now()
+	result.ResponseTime = result.EndTime.Sub(result.StartTime)
+
+	if err != nil {
+		var probeErr *probeError
+		if !errors.As(err, &probeErr) {
+			probeErr = &probeError{kind: DBErrorHandshake, message: err.Error()}
+		}
+		result.Success = false
+		result.Error = probeErr.message
+		result.DBErrorKind = probeErr.kind
+	} else {
+		result.Success = true
+	}
+	result.Details = d.createDetailedMessage(result, address, opts.Username)
+
+	return result, nil
+}
+
+func (d *DatabaseOperation) createDetailedMessage(result *types.OperationResult, address, username string) string {
+	var details strings.Builder
+	name := strings.ToUpper(string(result.Type))
+
+	switch {
+	case result.Success:
+		details.WriteString(fmt.Sprintf("✅ %s OK", name))
+		if result.DBServerVersion != "" {
+			details.WriteString(fmt.Sprintf(" - version %s", result.DBServerVersion))
+		}
+	case result.DBErrorKind == DBErrorAuth:
+		details.WriteString(fmt.Sprintf("🔐 %s AUTH FAILED - %s", name, result.Error))
+	case result.DBErrorKind == DBErrorQuery:
+		details.WriteString(fmt.Sprintf("⚠️ %s QUERY FAILED - %s", name, result.Error))
+	default:
+		details.WriteString(fmt.Sprintf("❌ %s %s FAILED - %s", name, strings.ToUpper(result.DBErrorKind), result.Error))
+	}
+
+	details.WriteString(fmt.Sprintf(" | Server: %s", address))
+	if result.DBHandshakeTime > 0 {
+		details.WriteString(fmt.Sprintf(" | Handshake: %.2fms", float64(result.DBHandshakeTime.Nanoseconds())/1e6))
+	}
+	if result.DBQueryTime > 0 {
+		details.WriteString(fmt.Sprintf(" | Query: %.2fms", float64(result.DBQueryTime.Nanoseconds())/1e6))
+	}
+	if result.TLSVersion != "" {
+		details.WriteString(fmt.Sprintf(" | TLS: %s", result.TLSVersion))
+	}
+
+	switch {
+	case result.DBAuthenticated && username != "":
+		details.WriteString(fmt.Sprintf(" | User: %s", username))
+	case result.DBLoginSkipped:
+		details.WriteString(" | Login not checked, no credentials configured")
+	}
+	if result.DBAuthenticated && result.DBAuthMethod != "" {
+		details.WriteString(fmt.Sprintf(" | Auth: %s", result.DBAuthMethod))
+	}
+
+	return details.String()
+}
@@ -0,0 +1,367 @@
+package operations
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"time"
+)
+
+// MySQL capability flags sent by the probe
+const (
+	mysqlClientLongPassword     = 0x00000001
+	mysqlClientConnectWithDB    = 0x00000008
+	mysqlClientProtocol41       = 0x00000200
+	mysqlClientSSL              = 0x00000800
+	mysqlClientTransactions     = 0x00002000
+	mysqlClientSecureConnection = 0x00008000
+	mysqlClientPluginAuth       = 0x00080000
+)
+
+// MySQL authentication plugins the probe can answer
+const (
+	mysqlNativePassword      = "mysql_native_password"
+	mysqlCachingSHA2Password = "caching_sha2_password"
+	mysqlSHA256Password      = "sha256_password"
+)
+
+// Server error codes that mean the login itself was refused
+var mysqlAuthErrors = map[uint16]bool{
+	1044: true, // Access denied to database
+	1045: true, // Access denied for user
+	1130: true, // Host not allowed to connect
+	1251: true, // Authentication protocol not supported
+	1698: true, // Access denied, e.g. auth_socket users
+}
+
+// mysqlCharsetUTF8MB4 is utf8mb4_general_ci
+const mysqlCharsetUTF8MB4 = 45
+
+// mysqlMaxPacket is announced as the largest packet the client accepts
+const mysqlMaxPacket = 1 << 24
+
+// probeMySQL reads the server greeting and, with a username configured, logs
+// in and pings the server
+func probeMySQL(s *databaseSession, opts DatabaseOptions) error {
+	greeting, err := s.readMySQL()
+	if err != nil {
+		return stageError(DBErrorHandshake, "%v", err)
+	}
+	if len(greeting) > 0 && greeting[0] == 0xff {
+		return mysqlError(greeting, DBErrorHandshake)
+	}
+	if len(greeting) == 0 || greeting[0] != 10 {
+		return stageError(DBErrorHandshake, "not a MySQL server (unsupported protocol version)")
+	}
+
+	version, scramble, plugin, capabilities, ok := parseMySQLGreeting(greeting)
+	if !ok {
+		return stageError(DBErrorHandshake, "malformed server greeting")
+	}
+	s.version = version
+
+	if opts.Username == "" {
+		s.loginSkipped = true
+		s.handshakeDone()
+		return nil
+	}
+
+	if plugin != mysqlCachingSHA2Password {
+		// Unknown plugins get a native password answer and the server
+		// switches to the plugin the account uses
+		plugin = mysqlNativePassword
+	}
+	s.authMethod = plugin
+
+	flags := uint32(mysqlClientLongPassword | mysqlClientProtocol41 | mysqlClientTransactions |
+		mysqlClientSecureConnection | mysqlClientPluginAuth)
+	if opts.Database != "" {
+		flags |= mysqlClientConnectWithDB
+	}
+	if capabilities&mysqlClientSSL != 0 {
+		// An SSL request is the start of the login packet; the login
+		// follows over TLS
+		flags |= mysqlClientSSL
+		if err := s.writeMySQL(mysqlLoginHeader(flags)); err != nil {
+			return stageError(DBErrorHandshake, "sending SSL request: %v", err)
+		}
+		if err := s.startTLS(opts); err != nil {
+			return err
+		}
+	}
+	authData := mysqlScramble(plugin, opts.Password, scramble)
+
+	response := append(mysqlLoginHeader(flags), opts.Username...)
+	response = append(response, 0)
+	response = append(append(response, byte(len(authData))), authData...)
+	if opts.Database != "" {
+		response = append(append(response, opts.Database...), 0)
+	}
+	response = append(append(response, plugin...), 0)
+	if err := s.writeMySQL(response); err != nil {
+		return stageError(DBErrorHandshake, "sending login: %v", err)
+	}
+
+	if err := s.authenticateMySQL(plugin, opts, scramble); err != nil {
+		return err
+	}
+	s.authenticated = true
+	s.handshakeDone()
+
+	return s.pingMySQL()
+}
+
+// mysqlLoginHeader starts a login packet, which alone is an SSL request
+func mysqlLoginHeader(flags uint32) []byte {
+	header := binary.LittleEndian.AppendUint32(nil, flags)
+	header = binary.LittleEndian.AppendUint32(header, mysqlMaxPacket)
+	header = append(header, mysqlCharsetUTF8MB4)
+	return append(header, make([]byte, 23)...)
+}
+
+// authenticateMySQL follows the server's answers to the login until it is
+// accepted or refused, switching plugins as requested. Full authentication
+// sends the password over TLS; without TLS it is encrypted with the server's
+// RSA public key, which a man in the middle could replace, so that needs
+// AllowInsecureAuth.
+func (s *databaseSession) authenticateMySQL(plugin string, opts DatabaseOptions, scramble []byte) error {
+	password := opts.Password
+	fullAuth := func() ([]byte, error) {
+		if s.tlsState != nil {
+			return append([]byte(password), 0), nil
+		}
+		if !opts.AllowInsecureAuth {
+			return nil, stageError(DBErrorAuth, "refusing to send the password without TLS, the server does not accept SSL connections")
+		}
+		if plugin == mysqlSHA256Password {
+			return []byte{1}, nil // Request the server's public key
+		}
+		return []byte{2}, nil
+	}
+
+	for {
+		packet, err := s.readMySQL()
+		if err != nil {
+			return stageError(DBErrorHandshake, "%v", err)
+		}
+		if len(packet) == 0 {
+			return stageError(DBErrorHandshake, "empty authentication response")
+		}
+
+		var reply []byte
+		switch packet[0] {
+		case 0x00:
+			return nil
+
+		case 0xff:
+			return mysqlError(packet, DBErrorHandshake)
+
+		case 0xfe: // Auth switch request
+			fields := bytes.SplitN(packet[1:], []byte{0}, 2)
+			if len(fields) < 2 {
+				return stageError(DBErrorAuth, "server requested the pre-4.1 password protocol, which is not supported")
+			}
+			plugin = string(fields[0])
+			scramble = bytes.TrimSuffix(fields[1], []byte{0})
+			s.authMethod = plugin
+			switch plugin {
+			case mysqlNativePassword, mysqlCachingSHA2Password:
+				reply = mysqlScramble(plugin, password, scramble)
+			case mysqlSHA256Password:
+				if reply, err = fullAuth(); err != nil {
+					return err
+				}
+			default:
+				return stageError(DBErrorAuth, "unsupported authentication plugin %q", plugin)
+			}
+
+		case 0x01: // More authentication data
+			data := packet[1:]
+			switch {
+			case plugin == mysqlCachingSHA2Password && len(data) == 1 && data[0] == 3:
+				continue // Fast authentication succeeded, OK follows
+			case plugin == mysqlCachingSHA2Password && len(data) == 1 && data[0] == 4:
+				if reply, err = fullAuth(); err != nil {
+					return err
+				}
+			case bytes.HasPrefix(data, []byte("-----BEGIN")) && s.tlsState == nil && opts.AllowInsecureAuth:
+				if reply, err = mysqlEncryptPassword(password, scramble, data); err != nil {
+					return stageError(DBErrorAuth, "%v", err)
+				}
+			default:
+				return stageError(DBErrorHandshake, "unexpected authentication data from server")
+			}
+
+		default:
+			return stageError(DBErrorHandshake, "unexpected authentication response 0x%02x", packet[0])
+		}
+
+		if err := s.writeMySQL(reply); err != nil {
+			return stageError(DBErrorHandshake, "sending authentication data: %v", err)
+		}
+	}
+}
+
+// pingMySQL sends COM_PING on a logged-in session and quits
+func (s *databaseSession) pingMySQL() error {
+	queryStart := time.Now()
+	s.mysqlSeq = 0
+	if err := s.writeMySQL([]byte{0x0e}); err != nil {
+		return stageError(DBErrorQuery, "sending ping: %v", err)
+	}
+	packet, err := s.readMySQL()
+	if err != nil {
+		return stageError(DBErrorQuery, "%v", err)
+	}
+	s.queryTime = time.Since(queryStart)
+	if len(packet) > 0 && packet[0] == 0xff {
+		return mysqlError(packet, DBErrorQuery)
+	}
+
+	s.mysqlSeq = 0
+	s.writeMySQL([]byte{0x01}) // COM_QUIT
+	return nil
+}
+
+// parseMySQLGreeting reads the server version, the 20-byte scramble, the
+// default authentication plugin and the lower capability flags from a
+// protocol 10 handshake
+func parseMySQLGreeting(packet []byte) (string, []byte, string, uint32, bool) {
+	end := bytes.IndexByte(packet[1:], 0)
+	if end < 0 {
+		return "", nil, "", 0, false
+	}
+	version := string(packet[1 : end+1])
+	rest := packet[end+2:]
+
+	// Connection id, first 8 scramble bytes and a filler
+	if len(rest) < 13 {
+		return "", nil, "", 0, false
+	}
+	scramble := append([]byte(nil), rest[4:12]...)
+	rest = rest[13:]
+
+	var capabilities uint32
+	if len(rest) >= 2 {
+		capabilities = uint32(binary.LittleEndian.Uint16(rest))
+	}
+
+	// Capabilities, charset, status, upper capabilities, scramble length and
+	// 10 reserved bytes precede the rest of the scramble
+	if len(rest) < 18 {
+		return version, scramble, "", capabilities, true
+	}
+	scrambleLen := int(rest[7])
+	rest = rest[18:]
+	n := scrambleLen - 8
+	if n < 13 {
+		n = 13
+	}
+	if len(rest) < n {
+		return version, scramble, "", capabilities, true
+	}
+	scramble = append(scramble, bytes.TrimSuffix(rest[:n], []byte{0})...)
+	plugin := rest[n:]
+	if i := bytes.IndexByte(plugin, 0); i >= 0 {
+		plugin = plugin[:i]
+	}
+	return version, scramble, string(plugin), capabilities, true
+}
+
+// mysqlScramble answers the scramble challenge of the native and caching SHA-2 plugins
+func mysqlScramble(plugin, password string, scramble []byte) []byte {
+	if password == "" {
+		return nil
+	}
+	if plugin == mysqlCachingSHA2Password {
+		// SHA256(password) XOR SHA256(SHA256(SHA256(password)) + scramble)
+		hash := sha256.Sum256([]byte(password))
+		hashHash := sha256.Sum256(hash[:])
+		mix := sha256.Sum256(append(hashHash[:], scramble...))
+		for i := range hash {
+			hash[i] ^= mix[i]
+		}
+		return hash[:]
+	}
+	// SHA1(password) XOR SHA1(scramble + SHA1(SHA1(password)))
+	hash := sha1.Sum([]byte(password))
+	hashHash := sha1.Sum(hash[:])
+	mix := sha1.Sum(append(append([]byte(nil), scramble...), hashHash[:]...))
+	for i := range hash {
+		hash[i] ^= mix[i]
+	}
+	return hash[:]
+}
+
+// mysqlEncryptPassword encrypts the password for full authentication over an
+// unencrypted connection with the server's RSA public key
+func mysqlEncryptPassword(password string, scramble, pemKey []byte) ([]byte, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, fmt.Errorf("invalid server public key")
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server public key: %v", err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("server public key is not an RSA key")
+	}
+
+	plain := append([]byte(password), 0)
+	for i := range plain {
+		if len(scramble) > 0 {
+			plain[i] ^= scramble[i%len(scramble)]
+		}
+	}
+	return rsa.EncryptOAEP(sha1.New(), rand.Reader, key, plain, nil)
+}
+
+// mysqlError converts an ERR packet, classifying refused logins as auth errors
+func mysqlError(packet []byte, kind string) error {
+	if len(packet) < 3 {
+		return stageError(kind, "server error")
+	}
+	code := binary.LittleEndian.Uint16(packet[1:3])
+	message := packet[3:]
+	if len(message) >= 6 && message[0] == '#' {
+		message = message[6:] // SQL state marker and state
+	}
+	if mysqlAuthErrors[code] {
+		kind = DBErrorAuth
+	}
+	return stageError(kind, "%s (%d)", message, code)
+}
+
+// readMySQL reads one packet and remembers its sequence number
+func (s *databaseSession) readMySQL() ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(s.reader, header[:]); err != nil {
+		return nil, fmt.Errorf("reading server packet: %w", err)
+	}
+	length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+	if length > databaseMaxMessage {
+		return nil, fmt.Errorf("not a MySQL server (packet of %d bytes)", length)
+	}
+	packet := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, packet); err != nil {
+		return nil, fmt.Errorf("reading server packet: %w", err)
+	}
+	s.mysqlSeq = header[3] + 1
+	return packet, nil
+}
+
+func (s *databaseSession) writeMySQL(payload []byte) error {
+	header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), s.mysqlSeq}
+	s.mysqlSeq++
+	_, err := s.conn.Write(append(header, payload...))
+	return err
+}
@@ -0,0 +1,407 @@
+package operations
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// postgresProtocolVersion is protocol 3.0 as sent in the startup message
+const postgresProtocolVersion = 196608
+
+// postgresSSLRequestCode asks a PostgreSQL server to switch to TLS
+const postgresSSLRequestCode = 80877103
+
+// Bounds on the SCRAM iteration count a server may ask for, so a hostile
+// server cannot pin a CPU with PBKDF2 on every check
+const (
+	scramMinIterations = 4096
+	scramMaxIterations = 1 << 20
+)
+
+// PostgreSQL authentication request codes
+const (
+	postgresAuthOK           = 0
+	postgresAuthCleartext    = 3
+	postgresAuthMD5          = 5
+	postgresAuthSASL         = 10
+	postgresAuthSASLContinue = 11
+	postgresAuthSASLFinal    = 12
+)
+
+// probePostgres asks for TLS, sends a startup message, answers the server's
+// authentication request and runs SELECT 1
+func probePostgres(s *databaseSession, opts DatabaseOptions) error {
+	if err := s.startPostgresTLS(opts); err != nil {
+		return err
+	}
+
+	user := opts.Username
+	if user == "" {
+		user = "postgres"
+	}
+	params := []string{"user", user, "application_name", databaseApplicationName}
+	if opts.Database != "" {
+		params = append(params, "database", opts.Database)
+	}
+
+	startup := binary.BigEndian.AppendUint32(make([]byte, 4), postgresProtocolVersion)
+	for _, param := range params {
+		startup = append(append(startup, param...), 0)
+	}
+	startup = append(startup, 0)
+	binary.BigEndian.PutUint32(startup, uint32(len(startup)))
+	if _, err := s.conn.Write(startup); err != nil {
+		return stageError(DBErrorHandshake, "sending startup message: %v", err)
+	}
+
+	var scram *scramClient
+	for {
+		msgType, body, err := s.readPostgres()
+		if err != nil {
+			return stageError(DBErrorHandshake, "%v", err)
+		}
+
+		switch msgType {
+		case 'R':
+			if len(body) < 4 {
+				return stageError(DBErrorHandshake, "malformed authentication request")
+			}
+			code, data := binary.BigEndian.Uint32(body), body[4:]
+			if code == postgresAuthOK {
+				s.authenticated = true
+				continue
+			}
+			if code != postgresAuthSASLContinue && code != postgresAuthSASLFinal && opts.Password == "" {
+				if opts.Username == "" {
+					// The server answered and wants a login, which is all a
+					// probe without credentials can check
+					s.loginSkipped = true
+					s.handshakeDone()
+					s.terminatePostgres()
+					return nil
+				}
+				return stageError(DBErrorAuth, "server requested a password for user %q but none is configured", user)
+			}
+
+			if (code == postgresAuthCleartext || code == postgresAuthMD5) && s.tlsState == nil && !opts.AllowInsecureAuth {
+				return stageError(DBErrorAuth, "refusing password authentication without TLS, the server does not accept SSL connections")
+			}
+
+			switch code {
+			case postgresAuthCleartext:
+				s.authMethod = "password"
+				err = s.writePostgres('p', append([]byte(opts.Password), 0))
+			case postgresAuthMD5:
+				if len(data) < 4 {
+					return stageError(DBErrorHandshake, "malformed MD5 authentication request")
+				}
+				s.authMethod = "md5"
+				err = s.writePostgres('p', append([]byte(postgresMD5Password(user, opts.Password, data[:4])), 0))
+			case postgresAuthSASL:
+				if !containsString(splitCStrings(data), "SCRAM-SHA-256") {
+					return stageError(DBErrorAuth, "server offers no supported SASL mechanism: %s", strings.Join(splitCStrings(data), ", "))
+				}
+				s.authMethod = "scram-sha-256"
+				if scram, err = newScramClient(opts.Password); err != nil {
+					return stageError(DBErrorHandshake, "%v", err)
+				}
+				first := scram.firstMessage()
+				msg := append([]byte("SCRAM-SHA-256"), 0)
+				msg = binary.BigEndian.AppendUint32(msg, uint32(len(first)))
+				err = s.writePostgres('p', append(msg, first...))
+			case postgresAuthSASLContinue:
+				if scram == nil {
+					return stageError(DBErrorHandshake, "unexpected SASL continuation")
+				}
+				var final string
+				if final, err = scram.finalMessage(string(data)); err != nil {
+					return stageError(DBErrorAuth, "SCRAM: %v", err)
+				}
+				err = s.writePostgres('p', []byte(final))
+			case postgresAuthSASLFinal:
+				if scram == nil {
+					return stageError(DBErrorHandshake, "unexpected SASL completion")
+				}
+				if err := scram.verifyServer(string(data)); err != nil {
+					return stageError(DBErrorAuth, "SCRAM: %v", err)
+				}
+			default:
+				return stageError(DBErrorAuth, "unsupported authentication method (request code %d)", code)
+			}
+			if err != nil {
+				return stageError(DBErrorHandshake, "sending authentication response: %v", err)
+			}
+
+		case 'E':
+			code, message := parsePostgresError(body)
+			kind := DBErrorHandshake
+			if strings.HasPrefix(code, "28") { // Class 28: invalid authorization specification
+				kind = DBErrorAuth
+			}
+			return stageError(kind, "%s (%s)", message, code)
+
+		case 'S':
+			if fields := splitCStrings(body); len(fields) >= 2 && fields[0] == "server_version" {
+				s.version = fields[1]
+			}
+
+		case 'Z':
+			s.handshakeDone()
+			return s.queryPostgres()
+		}
+		// BackendKeyData, notices and protocol negotiation need no answer
+	}
+}
+
+// startPostgresTLS sends an SSLRequest and upgrades the connection when the
+// server accepts it. Servers without SSL support are probed in plain text.
+func (s *databaseSession) startPostgresTLS(opts DatabaseOptions) error {
+	accepted, err := postgresRequestSSL(s.conn)
+	if err != nil {
+		return stageError(DBErrorHandshake, "%v", err)
+	}
+	if !accepted {
+		return nil
+	}
+	return s.startTLS(opts)
+}
+
+// postgresRequestSSL sends an SSLRequest and reports whether the server
+// accepted it. The answer is read unbuffered, as the TLS handshake follows.
+func postgresRequestSSL(conn net.Conn) (bool, error) {
+	request := binary.BigEndian.AppendUint32([]byte{0, 0, 0, 8}, postgresSSLRequestCode)
+	if _, err := conn.Write(request); err != nil {
+		return false, err
+	}
+	var answer [1]byte
+	if _, err := io.ReadFull(conn, answer[:]); err != nil {
+		return false, fmt.Errorf("reading SSL response: %v", err)
+	}
+	switch answer[0] {
+	case 'S':
+		return true, nil
+	case 'N':
+		return false, nil
+	default:
+		return false, fmt.Errorf("not a PostgreSQL server (SSL response %q)", answer[0])
+	}
+}
+
+// queryPostgres runs SELECT 1 on a ready session and terminates it
+func (s *databaseSession) queryPostgres() error {
+	defer s.terminatePostgres()
+
+	queryStart := time.Now()
+	if err := s.writePostgres('Q', []byte("SELECT 1\x00")); err != nil {
+		return stageError(DBErrorQuery, "sending query: %v", err)
+	}
+
+	var queryErr error
+	gotRow := false
+	for {
+		msgType, body, err := s.readPostgres()
+		if err != nil {
+			return stageError(DBErrorQuery, "%v", err)
+		}
+		switch msgType {
+		case 'E':
+			code, message := parsePostgresError(body)
+			queryErr = stageError(DBErrorQuery, "%s (%s)", message, code)
+		case 'D':
+			gotRow = true
+		case 'Z':
+			s.queryTime = time.Since(queryStart)
+			if queryErr == nil && !gotRow {
+				queryErr = stageError(DBErrorQuery, "SELECT 1 returned no rows")
+			}
+			return queryErr
+		}
+	}
+}
+
+func (s *databaseSession) terminatePostgres() {
+	s.writePostgres('X', nil)
+}
+
+// readPostgres reads one backend message
+func (s *databaseSession) readPostgres() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(s.reader, header[:]); err != nil {
+		return 0, nil, fmt.Errorf("reading server message: %w", err)
+	}
+	length := int(binary.BigEndian.Uint32(header[1:]))
+	if length < 4 || length > databaseMaxMessage {
+		return 0, nil, fmt.Errorf("not a PostgreSQL server (message %q of %d bytes)", header[0], length)
+	}
+	body := make([]byte, length-4)
+	if _, err := io.ReadFull(s.reader, body); err != nil {
+		return 0, nil, fmt.Errorf("reading server message: %w", err)
+	}
+	return header[0], body, nil
+}
+
+func (s *databaseSession) writePostgres(msgType byte, body []byte) error {
+	msg := binary.BigEndian.AppendUint32([]byte{msgType}, uint32(len(body)+4))
+	_, err := s.conn.Write(append(msg, body...))
+	return err
+}
+
+// parsePostgresError returns the SQLSTATE code and message of an ErrorResponse
+func parsePostgresError(body []byte) (string, string) {
+	var code, message string
+	for len(body) > 1 {
+		field := body[0]
+		end := bytes.IndexByte(body[1:], 0)
+		if end < 0 {
+			break
+		}
+		value := string(body[1 : end+1])
+		body = body[end+2:]
+		switch field {
+		case 'C':
+			code = value
+		case 'M':
+			message = value
+		}
+	}
+	if message == "" {
+		message = "server error"
+	}
+	return code, message
+}
+
+// postgresMD5Password hashes a password the way the md5 authentication method expects
+func postgresMD5Password(user, password string, salt []byte) string {
+	inner := md5.Sum([]byte(password + user))
+	outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), salt...))
+	return "md5" + hex.EncodeToString(outer[:])
+}
+
+// splitCStrings splits a sequence of NUL-terminated strings
+func splitCStrings(data []byte) []string {
+	var values []string
+	for _, value := range bytes.Split(data, []byte{0}) {
+		if len(value) > 0 {
+			values = append(values, string(value))
+		}
+	}
+	return values
+}
+
+func containsString(values []string, want string) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
+
+// scramClient performs the client side of SCRAM-SHA-256 (RFC 7677) without
+// channel binding. PostgreSQL takes the user name from the startup message, so
+// the SCRAM user name is left empty.
+type scramClient struct {
+	password        string
+	clientNonce     string
+	clientFirstBare string
+	serverSignature []byte
+}
+
+func newScramClient(password string) (*scramClient, error) {
+	nonce := make([]byte, 18)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	c := &scramClient{password: password, clientNonce: base64.StdEncoding.EncodeToString(nonce)}
+	c.clientFirstBare = "n=,r=" + c.clientNonce
+	return c, nil
+}
+
+func (c *scramClient) firstMessage() string {
+	return "n,," + c.clientFirstBare
+}
+
+// finalMessage answers the server-first message with the client proof
+func (c *scramClient) finalMessage(serverFirst string) (string, error) {
+	attrs := scramAttributes(serverFirst)
+	nonce := attrs["r"]
+	if !strings.HasPrefix(nonce, c.clientNonce) || len(nonce) == len(c.clientNonce) {
+		return "", fmt.Errorf("server nonce does not extend the client nonce")
+	}
+	salt, err := base64.StdEncoding.DecodeString(attrs["s"])
+	if err != nil {
+		return "", fmt.Errorf("invalid salt: %v", err)
+	}
+	iterations, err := strconv.Atoi(attrs["i"])
+	if err != nil || iterations < scramMinIterations || iterations > scramMaxIterations {
+		return "", fmt.Errorf("invalid iteration count %q, expected %d to %d", attrs["i"], scramMinIterations, scramMaxIterations)
+	}
+
+	saltedPassword := pbkdf2SHA256([]byte(c.password), salt, iterations)
+	clientKey := hmacSHA256(saltedPassword, []byte("Client Key"))
+	storedKey := sha256.Sum256(clientKey)
+
+	withoutProof := "c=biws,r=" + nonce
+	authMessage := []byte(c.clientFirstBare + "," + serverFirst + "," + withoutProof)
+	proof := hmacSHA256(storedKey[:], authMessage)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+	c.serverSignature = hmacSHA256(hmacSHA256(saltedPassword, []byte("Server Key")), authMessage)
+
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+// verifyServer checks the server signature, which proves the server knows the password too
+func (c *scramClient) verifyServer(serverFinal string) error {
+	attrs := scramAttributes(serverFinal)
+	if e, ok := attrs["e"]; ok {
+		return fmt.Errorf("server error %s", e)
+	}
+	signature, err := base64.StdEncoding.DecodeString(attrs["v"])
+	if err != nil || !hmac.Equal(signature, c.serverSignature) {
+		return fmt.Errorf("server signature mismatch")
+	}
+	return nil
+}
+
+func scramAttributes(message string) map[string]string {
+	attrs := make(map[string]string)
+	for _, part := range strings.Split(message, ",") {
+		if len(part) >= 2 && part[1] == '=' {
+			attrs[part[:1]] = part[2:]
+		}
+	}
+	return attrs
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// pbkdf2SHA256 derives a single SHA-256 sized block, which is all SCRAM needs
+func pbkdf2SHA256(password, salt []byte, iterations int) []byte {
+	u := hmacSHA256(password, binary.BigEndian.AppendUint32(append([]byte(nil), salt...), 1))
+	key := append([]byte(nil), u...)
+	for i := 1; i < iterations; i++ {
+		u = hmacSHA256(password, u)
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
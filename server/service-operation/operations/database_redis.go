@@ -0,0 +1,142 @@
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// probeRedis connects over TLS when configured, authenticates when a password
+// is configured, selects the database and sends PING, then reads the version
+// from INFO server
+func probeRedis(s *databaseSession, opts DatabaseOptions) error {
+	if opts.TLS {
+		if err := s.startTLS(opts); err != nil {
+			return err
+		}
+	}
+	if opts.Password != "" {
+		if s.tlsState == nil && !opts.AllowInsecureAuth {
+			return stageError(DBErrorAuth, "refusing to send AUTH without TLS, enable TLS or allow insecure authentication")
+		}
+		args := []string{"AUTH", opts.Password}
+		s.authMethod = "password"
+		if opts.Username != "" {
+			args = []string{"AUTH", opts.Username, opts.Password}
+			s.authMethod = "acl"
+		}
+		if _, err := s.redisCommand(DBErrorAuth, args...); err != nil {
+			return err
+		}
+		s.authenticated = true
+	}
+	if opts.Database != "" {
+		if _, err := s.redisCommand(DBErrorHandshake, "SELECT", opts.Database); err != nil {
+			return err
+		}
+	}
+	s.handshakeDone()
+
+	queryStart := time.Now()
+	reply, err := s.redisCommand(DBErrorQuery, "PING")
+	s.queryTime = time.Since(queryStart)
+	if err != nil {
+		var probeErr *probeError
+		if errors.As(err, &probeErr) && probeErr.kind == DBErrorAuth && opts.Password == "" {
+			// The server answered and wants a login, which is all a probe
+			// without credentials can check
+			s.queryTime = 0
+			s.loginSkipped = true
+			return nil
+		}
+		return err
+	}
+	if reply != "PONG" {
+		return stageError(DBErrorQuery, "unexpected PING reply %q", reply)
+	}
+
+	// INFO may be renamed or forbidden by ACLs; the version is optional
+	if info, err := s.redisCommand(DBErrorQuery, "INFO", "server"); err == nil {
+		for _, line := range strings.Split(info, "\n") {
+			if version, ok := strings.CutPrefix(strings.TrimSpace(line), "redis_version:"); ok {
+				s.version = version
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// redisCommand sends a command and returns its simple, integer or bulk string
+// reply. Error replies fail with the given kind, or as auth errors when they
+// are about missing or wrong credentials.
+func (s *databaseSession) redisCommand(kind string, args ...string) (string, error) {
+	var cmd strings.Builder
+	cmd.WriteString(fmt.Sprintf("*%d\r\n", len(args)))
+	for _, arg := range args {
+		cmd.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg))
+	}
+	if _, err := s.conn.Write([]byte(cmd.String())); err != nil {
+		return "", stageError(kind, "sending %s: %v", args[0], err)
+	}
+
+	replyType, reply, err := s.readRedis()
+	if err != nil {
+		return "", stageError(DBErrorHandshake, "%v", err)
+	}
+	if replyType == '-' {
+		code, _, _ := strings.Cut(reply, " ")
+		switch code {
+		case "NOAUTH", "WRONGPASS", "NOPERM", "DENIED":
+			kind = DBErrorAuth
+		}
+		return "", stageError(kind, "%s", reply)
+	}
+	return reply, nil
+}
+
+// readRedis reads one RESP reply. Arrays are read and discarded, as the probe
+// sends no command that returns one.
+func (s *databaseSession) readRedis() (byte, string, error) {
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		return 0, "", fmt.Errorf("reading server reply: %w", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return 0, "", fmt.Errorf("not a Redis server (empty reply)")
+	}
+
+	switch line[0] {
+	case '+', '-', ':':
+		return line[0], line[1:], nil
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n > databaseMaxMessage {
+			return 0, "", fmt.Errorf("not a Redis server (invalid bulk length %q)", line[1:])
+		}
+		if n < 0 {
+			return '$', "", nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(s.reader, data); err != nil {
+			return 0, "", fmt.Errorf("reading server reply: %w", err)
+		}
+		return '$', string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n > databaseMaxMessage {
+			return 0, "", fmt.Errorf("not a Redis server (invalid array length %q)", line[1:])
+		}
+		for i := 0; i < n; i++ {
+			if _, _, err := s.readRedis(); err != nil {
+				return 0, "", err
+			}
+		}
+		return '*', "", nil
+	}
+	return 0, "", fmt.Errorf("not a Redis server (unexpected reply \"%s\")", sanitizeBanner([]byte(line)))
+}
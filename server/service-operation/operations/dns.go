@@ -2,10 +2,11 @@ package operations
 
 import (
 	"fmt"
-	"net"
 	"strings"
 	"time"
 
+	"golang.org/x/net/dns/dnsmessage"
+
 	"service-operation/types"
 )
 
@@ -13,11 +14,26 @@ type DNSOperation struct {
 	timeout time.Duration
 }
 
+// DNSQueryOptions selects the resolver a DNS operation queries
+type DNSQueryOptions struct {
+	Nameservers []string // Empty uses the system resolvers
+	Transport   string   // auto, udp or tcp
+
+	// DNSSEC mode only
+	TrustAnchors  []string      // DS records trusted in addition to the root KSKs
+	ExpiryWarning time.Duration // Warn when signatures expire sooner than this
+}
+
 func NewDNSOperation(timeout time.Duration) *DNSOperation {
 	return &DNSOperation{timeout: timeout}
 }
 
 func (d *DNSOperation) Execute(host, query string) (*types.OperationResult, error) {
+	return d.ExecuteWithOptions(host, query, DNSQueryOptions{})
+}
+
+// ExecuteWithOptions performs the DNS query against the configured nameservers
+func (d *DNSOperation) ExecuteWithOptions(host, query string, opts DNSQueryOptions) (*types.OperationResult, error) {
 	// Validate inputs
 	if host == "" {
 		return nil, fmt.Errorf("host cannot be empty")
@@ -30,53 +46,43 @@ func (d *DNSOperation) Execute(host, query string) (*types.OperationResult, erro
 	result := &types.OperationResult{
 		Type:      types.OperationDNS,
 		Host:      host,
-		DNSType:   query,
+		DNSType:   strings.ToUpper(query),
 		StartTime: time.Now(),
 	}
 
-	start := time.Now()
-
-	// Resolve the host first to get detailed info
-	var resolvedIPs []string
-	var err error
+	qtype, ok := dnsQueryTypes[strings.ToUpper(query)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported DNS record type %q", query)
+	}
 
-	switch strings.ToUpper(query) {
-	case "A":
-		resolvedIPs, err = d.performARecordLookup(host)
-		
-	case "AAAA":
-		resolvedIPs, err = d.performAAAARecordLookup(host)
-		
-	case "MX":
-		resolvedIPs, err = d.performMXRecordLookup(host)
-		
-	case "TXT":
-		resolvedIPs, err = d.performTXTRecordLookup(host)
-		
-	case "CNAME":
-		resolvedIPs, err = d.performCNAMERecordLookup(host)
-		
-	case "NS":
-		resolvedIPs, err = d.performNSRecordLookup(host)
-		
-	default:
-		// Default to A record lookup for unknown types
-		resolvedIPs, err = d.performARecordLookup(host)
+	// PTR lookups accept a plain IP address
+	name := host
+	if qtype == dnsmessage.TypePTR {
+		name = reverseDNSName(host)
 	}
 
+	start := time.Now()
+
+	client := NewDNSClient(d.timeout, opts.Nameservers, opts.Transport)
+	resp, err := client.Query(name, qtype)
+
 	result.ResponseTime = time.Since(start)
 	result.EndTime = time.Now()
 
+	if err == nil {
+		d.applyResponse(result, resp, qtype)
+		err = dnsRcodeError(resp.Message.Header.RCode)
+	}
+
 	if err != nil {
 		result.Error = err.Error()
 		result.Success = false
 		result.Details = d.createDetailedErrorMessage(err.Error(), host, query)
 	} else {
-		result.DNSRecords = resolvedIPs
-		result.Success = len(resolvedIPs) > 0
+		result.Success = len(result.DNSRecords) > 0
 		
 		if result.Success {
-			result.Details = d.createDetailedSuccessMessage(result, host, query, resolvedIPs)
+			result.Details = d.createDetailedSuccessMessage(result, host, query, result.DNSRecords)
 		} else {
 			result.Error = "No DNS records found"
 			result.Details = d.createDetailedErrorMessage("no records found", host, query)
@@ -86,82 +92,24 @@ func (d *DNSOperation) Execute(host, query string) (*types.OperationResult, erro
 	return result, nil
 }
 
-func (d *DNSOperation) performARecordLookup(host string) ([]string, error) {
-	ips, err := net.LookupIP(host)
-	if err != nil {
-		return nil, err
-	}
-	
-	var ipv4Records []string
-	for _, ip := range ips {
-		if ipv4 := ip.To4(); ipv4 != nil {
-			ipv4Records = append(ipv4Records, ipv4.String())
-		}
-	}
-	
-	return ipv4Records, nil
-}
+// applyResponse copies the sections and transport details of a reply into the result
+func (d *DNSOperation) applyResponse(result *types.OperationResult, resp *DNSResponse, qtype dnsmessage.Type) {
+	msg := resp.Message
 
-func (d *DNSOperation) performAAAARecordLookup(host string) ([]string, error) {
-	ips, err := net.LookupIP(host)
-	if err != nil {
-		return nil, err
-	}
-	
-	var ipv6Records []string
-	for _, ip := range ips {
-		if ipv6 := ip.To16(); ipv6 != nil && ip.To4() == nil {
-			ipv6Records = append(ipv6Records, ipv6.String())
-		}
-	}
-	
-	return ipv6Records, nil
-}
-
-func (d *DNSOperation) performMXRecordLookup(host string) ([]string, error) {
-	mxRecords, err := net.LookupMX(host)
-	if err != nil {
-		return nil, err
-	}
-	
-	var records []string
-	for _, mx := range mxRecords {
-		records = append(records, fmt.Sprintf("%s (priority: %d)", mx.Host, mx.Pref))
-	}
-	
-	return records, nil
-}
+	result.DNSServer = resp.Server
+	result.DNSTransport = resp.Transport
+	result.DNSMsgSize = resp.Size
+	result.DNSRcode = dnsRcodeName(msg.Header.RCode)
+	result.DNSAuthoritative = msg.Header.Authoritative
+	result.DNSAnswers = formatDNSResources(msg.Answers)
+	result.DNSAuthority = formatDNSResources(msg.Authorities)
+	result.DNSAdditional = formatDNSResources(msg.Additionals)
+	result.DNSRecords = answerValues(msg.Answers, qtype)
 
-func (d *DNSOperation) performTXTRecordLookup(host string) ([]string, error) {
-	txtRecords, err := net.LookupTXT(host)
-	if err != nil {
-		return nil, err
+	if len(msg.Questions) > 0 {
+		q := msg.Questions[0]
+		result.DNSQuestion = fmt.Sprintf("%s IN %s", q.Name.String(), dnsTypeName(q.Type))
 	}
-	
-	return txtRecords, nil
-}
-
-func (d *DNSOperation) performCNAMERecordLookup(host string) ([]string, error) {
-	cname, err := net.LookupCNAME(host)
-	if err != nil {
-		return nil, err
-	}
-	
-	return []string{cname}, nil
-}
-
-func (d *DNSOperation) performNSRecordLookup(host string) ([]string, error) {
-	nsRecords, err := net.LookupNS(host)
-	if err != nil {
-		return nil, err
-	}
-	
-	var records []string
-	for _, ns := range nsRecords {
-		records = append(records, ns.Host)
-	}
-	
-	return records, nil
 }
 
 func (d *DNSOperation) createDetailedSuccessMessage(result *types.OperationResult, host, queryType string, records []string) string {
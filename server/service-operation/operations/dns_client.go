@@ -0,0 +1,321 @@
+package operations
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNS transports supported by DNSClient
+const (
+	DNSTransportAuto = "auto" // UDP with TCP retry on truncation
+	DNSTransportUDP  = "udp"
+	DNSTransportTCP  = "tcp"
+)
+
+const (
+	dnsDefaultPort   = "53"
+	dnsUDPBufferSize = 1232 // EDNS0 payload size recommended by DNS flag day 2020
+	dnsMaxTCPSize    = 65535
+)
+
+// DNSClient is a minimal DNS wire protocol client that queries a specific
+// nameserver over UDP or TCP, falling back to the system resolvers
+type DNSClient struct {
+	timeout     time.Duration
+	nameservers []string
+	transport   string
+	dnssec      bool
+}
+
+// DNSResponse is a parsed DNS reply along with transport level details
+type DNSResponse struct {
+	Message   *dnsmessage.Message
+	Server    string
+	Transport string
+	Size      int
+	RTT       time.Duration
+}
+
+// NewDNSClient creates a client for the given nameservers ("8.8.8.8", "1.1.1.1:53",
+// "[2606:4700::1111]:53"). When none are given the system resolvers are used.
+func NewDNSClient(timeout time.Duration, nameservers []string, transport string) *DNSClient {
+	var servers []string
+	for _, ns := range nameservers {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			servers = append(servers, normalizeNameserver(ns))
+		}
+	}
+	if len(servers) == 0 {
+		servers = systemNameservers()
+	}
+
+	transport = strings.ToLower(transport)
+	if transport != DNSTransportUDP && transport != DNSTransportTCP {
+		transport = DNSTransportAuto
+	}
+
+	return &DNSClient{
+		timeout:     timeout,
+		nameservers: servers,
+		transport:   transport,
+	}
+}
+
+// SetDNSSEC requests DNSSEC records (DO bit) and disables validation by the
+// resolver (CD bit) so signatures can be checked locally
+func (c *DNSClient) SetDNSSEC(enabled bool) {
+	c.dnssec = enabled
+}
+
+// Nameservers returns the nameservers the client queries, in order
+func (c *DNSClient) Nameservers() []string {
+	return c.nameservers
+}
+
+// Query sends a recursive query for name/qtype, trying each nameserver until one answers
+func (c *DNSClient) Query(name string, qtype dnsmessage.Type) (*DNSResponse, error) {
+	var lastErr error
+	for _, server := range c.nameservers {
+		resp, err := c.QueryServer(server, name, qtype)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no nameservers configured")
+	}
+	return nil, lastErr
+}
+
+// QueryServer sends a query to a single nameserver
+func (c *DNSClient) QueryServer(server, name string, qtype dnsmessage.Type) (*DNSResponse, error) {
+	server = normalizeNameserver(server)
+
+	query, id, err := c.buildQuery(name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := c.transport
+	if transport == DNSTransportAuto {
+		transport = DNSTransportUDP
+	}
+
+	resp, err := c.exchange(server, transport, query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retry over TCP when the UDP answer did not fit
+	if resp.Message.Header.Truncated && c.transport == DNSTransportAuto {
+		if tcpResp, tcpErr := c.exchange(server, DNSTransportTCP, query, id); tcpErr == nil {
+			tcpResp.RTT += resp.RTT
+			return tcpResp, nil
+		}
+	}
+
+	return resp, nil
+}
+
+func (c *DNSClient) buildQuery(name string, qtype dnsmessage.Type) ([]byte, uint16, error) {
+	qname, err := dnsmessage.NewName(fqdn(name))
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid query name %q: %v", name, err)
+	}
+
+	// Unpredictable IDs keep off-path attackers from spoofing UDP answers
+	var idBytes [2]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return nil, 0, fmt.Errorf("generating query ID: %v", err)
+	}
+	id := binary.BigEndian.Uint16(idBytes[:])
+	builder := dnsmessage.NewBuilder(make([]byte, 2, 514), dnsmessage.Header{
+		ID:               id,
+		RecursionDesired: true,
+		CheckingDisabled: c.dnssec,
+	})
+	builder.EnableCompression()
+
+	if err := builder.StartQuestions(); err != nil {
+		return nil, 0, err
+	}
+	if err := builder.Question(dnsmessage.Question{
+		Name:  qname,
+		Type:  qtype,
+		Class: dnsmessage.ClassINET,
+	}); err != nil {
+		return nil, 0, err
+	}
+
+	// Advertise EDNS0 so larger answers are not truncated
+	if err := builder.StartAdditionals(); err != nil {
+		return nil, 0, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(dnsUDPBufferSize, dnsmessage.RCodeSuccess, c.dnssec); err != nil {
+		return nil, 0, err
+	}
+	if err := builder.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, 0, err
+	}
+
+	// The first two bytes are reserved for the TCP length prefix
+	msg, err := builder.Finish()
+	if err != nil {
+		return nil, 0, err
+	}
+	binary.BigEndian.PutUint16(msg, uint16(len(msg)-2))
+
+	return msg, id, nil
+}
+
+func (c *DNSClient) exchange(server, transport string, query []byte, id uint16) (*DNSResponse, error) {
+	start := time.Now()
+	conn, err := net.DialTimeout(transport, server, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+
+	var raw []byte
+	if transport == DNSTransportTCP {
+		raw, err = exchangeTCP(conn, query)
+	} else {
+		raw, err = exchangeUDP(conn, query[2:], id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query to %s over %s failed: %v", server, transport, err)
+	}
+	rtt := time.Since(start)
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(raw); err != nil {
+		return nil, fmt.Errorf("malformed response from %s: %v", server, err)
+	}
+	if msg.Header.ID != id {
+		return nil, fmt.Errorf("response ID mismatch from %s", server)
+	}
+	if !sameQuestion(query[2:], msg.Questions) {
+		return nil, fmt.Errorf("response question mismatch from %s", server)
+	}
+
+	return &DNSResponse{
+		Message:   &msg,
+		Server:    server,
+		Transport: transport,
+		Size:      len(raw),
+		RTT:       rtt,
+	}, nil
+}
+
+// sameQuestion reports whether a response repeats the question of the query,
+// comparing names case-insensitively
+func sameQuestion(query []byte, questions []dnsmessage.Question) bool {
+	var parser dnsmessage.Parser
+	if _, err := parser.Start(query); err != nil {
+		return false
+	}
+	asked, err := parser.Question()
+	if err != nil || len(questions) != 1 {
+		return false
+	}
+	answered := questions[0]
+	return answered.Type == asked.Type && answered.Class == asked.Class &&
+		strings.EqualFold(answered.Name.String(), asked.Name.String())
+}
+
+func exchangeUDP(conn net.Conn, query []byte, id uint16) ([]byte, error) {
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, dnsUDPBufferSize*4)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		// Ignore stray datagrams that don't belong to this query
+		if n >= 2 && binary.BigEndian.Uint16(buf[:2]) == id {
+			return buf[:n], nil
+		}
+	}
+}
+
+func exchangeTCP(conn net.Conn, query []byte) ([]byte, error) {
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+
+	size := int(binary.BigEndian.Uint16(length[:]))
+	if size == 0 || size > dnsMaxTCPSize {
+		return nil, fmt.Errorf("invalid TCP response length %d", size)
+	}
+
+	raw := make([]byte, size)
+	if _, err := io.ReadFull(conn, raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// normalizeNameserver adds the default DNS port when missing
+func normalizeNameserver(server string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), dnsDefaultPort)
+}
+
+// systemNameservers reads the resolvers configured in /etc/resolv.conf
+func systemNameservers() []string {
+	var servers []string
+
+	file, err := os.Open("/etc/resolv.conf")
+	if err == nil {
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) >= 2 && fields[0] == "nameserver" {
+				// Drop IPv6 zones such as fe80::1%eth0 which the dialer cannot use without an interface
+				if ip := net.ParseIP(strings.SplitN(fields[1], "%", 2)[0]); ip != nil {
+					servers = append(servers, net.JoinHostPort(ip.String(), dnsDefaultPort))
+				}
+			}
+		}
+	}
+
+	if len(servers) == 0 {
+		servers = []string{"127.0.0.1:53", "[::1]:53"}
+	}
+	return servers
+}
+
+// fqdn makes sure the name is fully qualified
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
@@ -0,0 +1,268 @@
+package operations
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsHandler builds the reply to a query; tcp reports the transport it came over
+type dnsHandler func(query dnsmessage.Message, tcp bool) dnsmessage.Message
+
+// startDNSServer serves handler on a UDP and a TCP listener sharing one
+// 127.0.0.1 port and returns that address
+func startDNSServer(t *testing.T, handler dnsHandler) string {
+	t.Helper()
+
+	var udp net.PacketConn
+	var tcp net.Listener
+	for attempt := 0; tcp == nil; attempt++ {
+		var err error
+		if udp, err = net.ListenPacket("udp", "127.0.0.1:0"); err != nil {
+			t.Fatalf("listen udp: %v", err)
+		}
+		if tcp, err = net.Listen("tcp", udp.LocalAddr().String()); err != nil {
+			udp.Close()
+			if attempt == 10 {
+				t.Fatalf("listen tcp: %v", err)
+			}
+		}
+	}
+	t.Cleanup(func() {
+		udp.Close()
+		tcp.Close()
+	})
+
+	reply := func(raw []byte, isTCP bool) []byte {
+		var query dnsmessage.Message
+		if err := query.Unpack(raw); err != nil {
+			return nil
+		}
+		msg := handler(query, isTCP)
+		packed, err := msg.Pack()
+		if err != nil {
+			t.Errorf("pack reply: %v", err)
+			return nil
+		}
+		return packed
+	}
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, addr, err := udp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if packed := reply(buf[:n], false); packed != nil {
+				udp.WriteTo(packed, addr)
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var length [2]byte
+				if _, err := io.ReadFull(conn, length[:]); err != nil {
+					return
+				}
+				raw := make([]byte, binary.BigEndian.Uint16(length[:]))
+				if _, err := io.ReadFull(conn, raw); err != nil {
+					return
+				}
+				if packed := reply(raw, true); packed != nil {
+					conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(packed))), packed...))
+				}
+			}()
+		}
+	}()
+
+	return udp.LocalAddr().String()
+}
+
+// dnsReply starts a response to query with the given rcode and answers
+func dnsReply(query dnsmessage.Message, rcode dnsmessage.RCode, answers ...dnsmessage.Resource) dnsmessage.Message {
+	return dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 query.Header.ID,
+			Response:           true,
+			RecursionDesired:   query.Header.RecursionDesired,
+			RecursionAvailable: true,
+			RCode:              rcode,
+		},
+		Questions: query.Questions,
+		Answers:   answers,
+	}
+}
+
+func aRecord(name string, ip [4]byte) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{
+			Name:  dnsmessage.MustNewName(name),
+			Type:  dnsmessage.TypeA,
+			Class: dnsmessage.ClassINET,
+			TTL:   300,
+		},
+		Body: &dnsmessage.AResource{A: ip},
+	}
+}
+
+func TestDNSOperationRcodes(t *testing.T) {
+	tests := []struct {
+		name      string
+		rcode     dnsmessage.RCode
+		answers   bool
+		success   bool
+		wantRcode string
+		wantError string
+	}{
+		{name: "answer", rcode: dnsmessage.RCodeSuccess, answers: true, success: true, wantRcode: "NOERROR"},
+		{name: "no data", rcode: dnsmessage.RCodeSuccess, wantRcode: "NOERROR", wantError: "No DNS records found"},
+		{name: "nxdomain", rcode: dnsmessage.RCodeNameError, wantRcode: "NXDOMAIN", wantError: "NXDOMAIN"},
+		{name: "servfail", rcode: dnsmessage.RCodeServerFailure, wantRcode: "SERVFAIL", wantError: "SERVFAIL"},
+		{name: "refused", rcode: dnsmessage.RCodeRefused, wantRcode: "REFUSED", wantError: "REFUSED"},
+		{name: "other", rcode: dnsmessage.RCodeNotImplemented, wantRcode: "NOTIMP", wantError: "NOTIMP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startDNSServer(t, func(query dnsmessage.Message, tcp bool) dnsmessage.Message {
+				if !tt.answers {
+					return dnsReply(query, tt.rcode)
+				}
+				return dnsReply(query, tt.rcode, aRecord("example.test.", [4]byte{192, 0, 2, 1}))
+			})
+
+			result, err := NewDNSOperation(2*time.Second).ExecuteWithOptions("example.test", "A", DNSQueryOptions{
+				Nameservers: []string{server},
+			})
+			if err != nil {
+				t.Fatalf("ExecuteWithOptions: %v", err)
+			}
+			if result.Success != tt.success {
+				t.Errorf("Success = %v, want %v (error %q)", result.Success, tt.success, result.Error)
+			}
+			if result.DNSRcode != tt.wantRcode {
+				t.Errorf("DNSRcode = %q, want %q", result.DNSRcode, tt.wantRcode)
+			}
+			if !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("Error = %q, want it to contain %q", result.Error, tt.wantError)
+			}
+			if tt.success && (len(result.DNSRecords) != 1 || result.DNSRecords[0] != "192.0.2.1") {
+				t.Errorf("DNSRecords = %v, want [192.0.2.1]", result.DNSRecords)
+			}
+			if result.DNSTransport != DNSTransportUDP {
+				t.Errorf("DNSTransport = %q, want udp", result.DNSTransport)
+			}
+		})
+	}
+}
+
+func TestDNSOperationTruncatedRetriesOverTCP(t *testing.T) {
+	server := startDNSServer(t, func(query dnsmessage.Message, tcp bool) dnsmessage.Message {
+		if !tcp {
+			reply := dnsReply(query, dnsmessage.RCodeSuccess)
+			reply.Header.Truncated = true
+			return reply
+		}
+		return dnsReply(query, dnsmessage.RCodeSuccess, aRecord("example.test.", [4]byte{192, 0, 2, 2}))
+	})
+
+	result, err := NewDNSOperation(2*time.Second).ExecuteWithOptions("example.test", "A", DNSQueryOptions{
+		Nameservers: []string{server},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteWithOptions: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("Success = false, error %q", result.Error)
+	}
+	if result.DNSTransport != DNSTransportTCP {
+		t.Errorf("DNSTransport = %q, want tcp", result.DNSTransport)
+	}
+	if len(result.DNSRecords) != 1 || result.DNSRecords[0] != "192.0.2.2" {
+		t.Errorf("DNSRecords = %v, want [192.0.2.2]", result.DNSRecords)
+	}
+
+	// A client pinned to UDP keeps the truncated answer
+	result, err = NewDNSOperation(2*time.Second).ExecuteWithOptions("example.test", "A", DNSQueryOptions{
+		Nameservers: []string{server},
+		Transport:   DNSTransportUDP,
+	})
+	if err != nil {
+		t.Fatalf("ExecuteWithOptions: %v", err)
+	}
+	if result.Success || result.DNSTransport != DNSTransportUDP {
+		t.Errorf("Success = %v over %q, want a failed udp query", result.Success, result.DNSTransport)
+	}
+}
+
+func TestDNSClientRejectsMismatchedResponses(t *testing.T) {
+	wrongID := func(query dnsmessage.Message, tcp bool) dnsmessage.Message {
+		reply := dnsReply(query, dnsmessage.RCodeSuccess, aRecord("example.test.", [4]byte{192, 0, 2, 3}))
+		reply.Header.ID++
+		return reply
+	}
+	wrongQuestion := func(query dnsmessage.Message, tcp bool) dnsmessage.Message {
+		reply := dnsReply(query, dnsmessage.RCodeSuccess, aRecord("other.test.", [4]byte{192, 0, 2, 3}))
+		reply.Questions = []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName("other.test."),
+			Type:  dnsmessage.TypeA,
+			Class: dnsmessage.ClassINET,
+		}}
+		return reply
+	}
+	wrongType := func(query dnsmessage.Message, tcp bool) dnsmessage.Message {
+		reply := dnsReply(query, dnsmessage.RCodeSuccess)
+		reply.Questions[0].Type = dnsmessage.TypeAAAA
+		return reply
+	}
+
+	tests := []struct {
+		name      string
+		handler   dnsHandler
+		transport string
+		wantError string
+	}{
+		// Stray UDP datagrams are skipped until the query times out
+		{name: "udp id", handler: wrongID, transport: DNSTransportUDP, wantError: "timeout"},
+		{name: "tcp id", handler: wrongID, transport: DNSTransportTCP, wantError: "ID mismatch"},
+		{name: "udp question", handler: wrongQuestion, transport: DNSTransportUDP, wantError: "question mismatch"},
+		{name: "tcp question", handler: wrongQuestion, transport: DNSTransportTCP, wantError: "question mismatch"},
+		{name: "question type", handler: wrongType, transport: DNSTransportUDP, wantError: "question mismatch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startDNSServer(t, tt.handler)
+
+			result, err := NewDNSOperation(500*time.Millisecond).ExecuteWithOptions("example.test", "A", DNSQueryOptions{
+				Nameservers: []string{server},
+				Transport:   tt.transport,
+			})
+			if err != nil {
+				t.Fatalf("ExecuteWithOptions: %v", err)
+			}
+			if result.Success {
+				t.Fatalf("Success = true, want the response rejected")
+			}
+			if !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("Error = %q, want it to contain %q", result.Error, tt.wantError)
+			}
+			if len(result.DNSRecords) != 0 {
+				t.Errorf("DNSRecords = %v, want none from a rejected response", result.DNSRecords)
+			}
+		})
+	}
+}
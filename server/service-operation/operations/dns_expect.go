@@ -0,0 +1,187 @@
+package operations
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"service-operation/types"
+)
+
+// DNS answer match modes supported by services.dns_match_mode
+const (
+	DNSMatchExact  = "exact"  // Answers must equal the expected set
+	DNSMatchSubset = "subset" // Every answer must be one of the expected values
+	DNSMatchCIDR   = "cidr"   // Every answer must fall within one of the expected ranges
+	DNSMatchRegex  = "regex"  // Every answer must match one of the expected patterns
+)
+
+// DNSExpectation describes the answers a DNS check expects to receive
+type DNSExpectation struct {
+	Mode   string
+	Values []string
+}
+
+// CheckExpectedAnswers compares the answers of the queried type against the
+// expectation, marking the check failed with a diff when they don't match
+func (d *DNSOperation) CheckExpectedAnswers(result *types.OperationResult, expectation DNSExpectation) {
+	if result == nil || len(expectation.Values) == 0 || !result.Success {
+		return
+	}
+
+	var actual []string
+	for _, record := range result.DNSAnswers {
+		if record.Type == result.DNSType {
+			actual = append(actual, normalizeDNSValue(result.DNSType, record.Value))
+		}
+	}
+
+	missing, unexpected, err := diffDNSAnswers(result.DNSType, expectation, actual)
+	if err != nil {
+		result.Success = false
+		result.Error = fmt.Sprintf("DNS expectation error: %v", err)
+		return
+	}
+	if len(missing) == 0 && len(unexpected) == 0 {
+		return
+	}
+
+	mode := strings.ToLower(expectation.Mode)
+	if mode == "" {
+		mode = DNSMatchExact
+	}
+
+	var diff []string
+	for _, value := range missing {
+		diff = append(diff, "- "+value)
+	}
+	for _, value := range unexpected {
+		diff = append(diff, "+ "+value)
+	}
+	result.DNSAnswerDiff = strings.Join(diff, "\n")
+
+	result.Success = false
+	result.Error = fmt.Sprintf("DNS answer mismatch (%s): expected %s, got %s",
+		mode, strings.Join(expectation.Values, ", "), strings.Join(actual, ", "))
+	result.Details = fmt.Sprintf("🚨 DNS ANSWER MISMATCH - %s %s | Mode: %s | Missing: %s | Unexpected: %s",
+		result.DNSType, result.Host, mode, listOrNone(missing), listOrNone(unexpected))
+}
+
+// diffDNSAnswers returns the expected values that are missing and the actual
+// values that are not allowed by the expectation
+func diffDNSAnswers(recordType string, expectation DNSExpectation, actual []string) ([]string, []string, error) {
+	var missing, unexpected []string
+
+	switch strings.ToLower(expectation.Mode) {
+	case "", DNSMatchExact, DNSMatchSubset:
+		expected := make(map[string]bool)
+		for _, value := range expectation.Values {
+			expected[normalizeDNSValue(recordType, value)] = true
+		}
+		seen := make(map[string]bool)
+		for _, value := range actual {
+			seen[value] = true
+			if !expected[value] {
+				unexpected = append(unexpected, value)
+			}
+		}
+		if strings.ToLower(expectation.Mode) != DNSMatchSubset {
+			for value := range expected {
+				if !seen[value] {
+					missing = append(missing, value)
+				}
+			}
+		}
+
+	case DNSMatchCIDR:
+		var networks []*net.IPNet
+		for _, value := range expectation.Values {
+			cidr := strings.TrimSpace(value)
+			if !strings.Contains(cidr, "/") {
+				if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+					cidr += "/32"
+				} else {
+					cidr += "/128"
+				}
+			}
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid CIDR %q", value)
+			}
+			networks = append(networks, network)
+		}
+		for _, value := range actual {
+			ip := net.ParseIP(value)
+			if ip == nil || !ipInNetworks(ip, networks) {
+				unexpected = append(unexpected, value)
+			}
+		}
+
+	case DNSMatchRegex:
+		var patterns []*regexp.Regexp
+		for _, value := range expectation.Values {
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid pattern %q: %v", value, err)
+			}
+			patterns = append(patterns, re)
+		}
+		for _, value := range actual {
+			matched := false
+			for _, re := range patterns {
+				if re.MatchString(value) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				unexpected = append(unexpected, value)
+			}
+		}
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported match mode %q", expectation.Mode)
+	}
+
+	sort.Strings(missing)
+	sort.Strings(unexpected)
+	return missing, unexpected, nil
+}
+
+// normalizeDNSValue canonicalizes IP addresses and lowercases names without the
+// trailing dot; free-form TXT and CAA data is compared as-is
+func normalizeDNSValue(recordType, value string) string {
+	value = strings.TrimSpace(value)
+	if ip := net.ParseIP(value); ip != nil {
+		return ip.String()
+	}
+	if recordType == "TXT" || recordType == "CAA" {
+		return value
+	}
+
+	fields := strings.Fields(value)
+	for i, field := range fields {
+		if !strings.HasPrefix(field, "\"") {
+			fields[i] = strings.TrimSuffix(strings.ToLower(field), ".")
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+func ipInNetworks(ip net.IP, networks []*net.IPNet) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func listOrNone(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ", ")
+}
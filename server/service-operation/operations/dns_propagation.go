@@ -0,0 +1,362 @@
+package operations
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"service-operation/types"
+)
+
+// DNS operation modes supported by services.dns_mode
+const (
+	DNSModeQuery       = "query"       // Single query against the configured resolvers
+	DNSModePropagation = "propagation" // Same query against every authoritative and configured nameserver
+	DNSModeDNSSEC      = "dnssec"      // Query with DNSSEC records and validate the chain of trust
+)
+
+// Sources of the nameservers in a propagation check
+const (
+	DNSSourceAuthoritative = "authoritative"
+	DNSSourceResolver      = "resolver"
+)
+
+// DNS propagation verdicts
+const (
+	DNSConsistent   = "consistent"
+	DNSInconsistent = "inconsistent"
+)
+
+type propagationTarget struct {
+	server  string
+	address string
+	source  string
+}
+
+// ExecutePropagation sends the query to the zone's authoritative nameservers and
+// the configured resolvers in parallel and reports whether they all agree on the
+// answers and the SOA serial
+func (d *DNSOperation) ExecutePropagation(host, query string, opts DNSQueryOptions) (*types.OperationResult, error) {
+	if host == "" {
+		return nil, fmt.Errorf("host cannot be empty")
+	}
+
+	if query == "" {
+		query = "A"
+	}
+
+	qtype, ok := dnsQueryTypes[strings.ToUpper(query)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported DNS record type %q", query)
+	}
+
+	result := &types.OperationResult{
+		Type:      types.OperationDNS,
+		Host:      host,
+		DNSType:   strings.ToUpper(query),
+		StartTime: time.Now(),
+	}
+
+	name := host
+	if qtype == dnsmessage.TypePTR {
+		name = reverseDNSName(host)
+	}
+
+	// The configured resolvers (or the system ones) are used to discover the zone
+	client := NewDNSClient(d.timeout, opts.Nameservers, opts.Transport)
+	zone, targets, discoverErr := d.discoverAuthoritative(client, name)
+
+	for _, ns := range opts.Nameservers {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			targets = append(targets, propagationTarget{
+				server:  ns,
+				address: normalizeNameserver(ns),
+				source:  DNSSourceResolver,
+			})
+		}
+	}
+
+	if len(targets) == 0 {
+		result.EndTime = time.Now()
+		result.ResponseTime = result.EndTime.Sub(result.StartTime)
+		result.Success = false
+		result.Error = fmt.Sprintf("no nameservers to check: %v", discoverErr)
+		result.Details = d.createDetailedErrorMessage(result.Error, host, query)
+		return result, nil
+	}
+
+	if zone == "" {
+		zone = fqdn(name)
+	}
+
+	answers := make([]types.DNSServerAnswer, len(targets))
+	responses := make([]*DNSResponse, len(targets))
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target propagationTarget) {
+			defer wg.Done()
+			answers[i], responses[i] = queryPropagationTarget(client, target, name, zone, qtype)
+		}(i, target)
+	}
+	wg.Wait()
+
+	result.EndTime = time.Now()
+	result.ResponseTime = result.EndTime.Sub(result.StartTime)
+	result.DNSPropagation = answers
+
+	// Report the records of the first server that answered so expectations still apply
+	for i, resp := range responses {
+		if resp != nil && answers[i].Error == "" {
+			d.applyResponse(result, resp, qtype)
+			break
+		}
+	}
+
+	summary := summarizePropagation(result.DNSType, answers)
+	if summary.consistent() {
+		result.DNSConsistency = DNSConsistent
+		result.Success = true
+	} else {
+		result.DNSConsistency = DNSInconsistent
+		result.Success = false
+		result.Error = summary.errorMessage()
+	}
+	result.Details = d.createPropagationMessage(result, host, zone, summary, discoverErr)
+
+	return result, nil
+}
+
+// discoverAuthoritative finds the zone apex by walking up the name until an NS
+// lookup returns the zone's nameservers, then resolves each of them
+func (d *DNSOperation) discoverAuthoritative(client *DNSClient, name string) (string, []propagationTarget, error) {
+	labels := strings.Split(strings.TrimSuffix(fqdn(name), "."), ".")
+
+	for i := 0; i < len(labels)-1; i++ {
+		candidate := fqdn(strings.Join(labels[i:], "."))
+
+		resp, err := client.Query(candidate, dnsmessage.TypeNS)
+		if err != nil {
+			return "", nil, fmt.Errorf("NS lookup for %s failed: %v", candidate, err)
+		}
+
+		var hosts []string
+		for _, answer := range resp.Message.Answers {
+			ns, ok := answer.Body.(*dnsmessage.NSResource)
+			if ok && strings.EqualFold(answer.Header.Name.String(), candidate) {
+				hosts = append(hosts, ns.NS.String())
+			}
+		}
+		if len(hosts) == 0 {
+			continue
+		}
+		sort.Strings(hosts)
+
+		var targets []propagationTarget
+		for _, nsHost := range hosts {
+			ip := glueAddress(resp.Message.Additionals, nsHost)
+			if ip == "" {
+				ip = resolveNameserver(client, nsHost)
+			}
+
+			target := propagationTarget{server: nsHost, source: DNSSourceAuthoritative}
+			if ip != "" {
+				target.address = net.JoinHostPort(ip, dnsDefaultPort)
+			}
+			targets = append(targets, target)
+		}
+		return candidate, targets, nil
+	}
+
+	return "", nil, fmt.Errorf("no NS records found for %s", name)
+}
+
+// glueAddress returns the address of a nameserver from the additional section
+func glueAddress(additionals []dnsmessage.Resource, host string) string {
+	for _, resource := range additionals {
+		if !strings.EqualFold(resource.Header.Name.String(), host) {
+			continue
+		}
+		switch rb := resource.Body.(type) {
+		case *dnsmessage.AResource:
+			return net.IP(rb.A[:]).String()
+		case *dnsmessage.AAAAResource:
+			return net.IP(rb.AAAA[:]).String()
+		}
+	}
+	return ""
+}
+
+// resolveNameserver looks up the address of a nameserver without glue, preferring IPv4
+func resolveNameserver(client *DNSClient, host string) string {
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		resp, err := client.Query(host, qtype)
+		if err != nil {
+			continue
+		}
+		if ip := glueAddress(resp.Message.Answers, host); ip != "" {
+			return ip
+		}
+	}
+	return ""
+}
+
+// queryPropagationTarget sends the query and a SOA query for the zone to a single nameserver
+func queryPropagationTarget(client *DNSClient, target propagationTarget, name, zone string, qtype dnsmessage.Type) (types.DNSServerAnswer, *DNSResponse) {
+	answer := types.DNSServerAnswer{
+		Server:  target.server,
+		Address: target.address,
+		Source:  target.source,
+	}
+	if target.address == "" {
+		answer.Error = "could not resolve nameserver address"
+		return answer, nil
+	}
+
+	resp, err := client.QueryServer(target.address, name, qtype)
+	if err != nil {
+		answer.Error = err.Error()
+		return answer, nil
+	}
+	answer.ResponseTime = resp.RTT
+	answer.Rcode = dnsRcodeName(resp.Message.Header.RCode)
+	answer.Answers = answerValues(resp.Message.Answers, qtype)
+	sort.Strings(answer.Answers)
+
+	// Negative answers carry the SOA in the authority section
+	answer.SOASerial = soaSerial(resp.Message)
+	if answer.SOASerial == 0 {
+		if soaResp, err := client.QueryServer(target.address, zone, dnsmessage.TypeSOA); err == nil {
+			answer.SOASerial = soaSerial(soaResp.Message)
+		}
+	}
+
+	return answer, resp
+}
+
+func soaSerial(msg *dnsmessage.Message) uint32 {
+	for _, section := range [][]dnsmessage.Resource{msg.Answers, msg.Authorities} {
+		for _, resource := range section {
+			if soa, ok := resource.Body.(*dnsmessage.SOAResource); ok {
+				return soa.Serial
+			}
+		}
+	}
+	return 0
+}
+
+// propagationSummary groups the servers of a propagation check by the answer they gave
+type propagationSummary struct {
+	total   int
+	answers map[string][]string // answer set -> servers
+	serials map[uint32][]string // SOA serial -> servers
+	failed  []string
+}
+
+func summarizePropagation(recordType string, answers []types.DNSServerAnswer) propagationSummary {
+	summary := propagationSummary{
+		total:   len(answers),
+		answers: make(map[string][]string),
+		serials: make(map[uint32][]string),
+	}
+
+	for _, answer := range answers {
+		if answer.Error != "" {
+			summary.failed = append(summary.failed, answer.Server)
+			continue
+		}
+
+		values := make([]string, len(answer.Answers))
+		for i, value := range answer.Answers {
+			values[i] = normalizeDNSValue(recordType, value)
+		}
+		sort.Strings(values)
+
+		key := answer.Rcode
+		if len(values) > 0 {
+			key = strings.Join(values, ", ")
+		}
+		summary.answers[key] = append(summary.answers[key], answer.Server)
+
+		if answer.SOASerial != 0 {
+			summary.serials[answer.SOASerial] = append(summary.serials[answer.SOASerial], answer.Server)
+		}
+	}
+
+	return summary
+}
+
+// consistent reports whether every server answered and all agree on answers and serial
+func (s propagationSummary) consistent() bool {
+	return s.total > 0 && len(s.failed) == 0 && len(s.answers) == 1 && len(s.serials) <= 1
+}
+
+func (s propagationSummary) serialList() []string {
+	var values []uint32
+	for serial := range s.serials {
+		values = append(values, serial)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	serials := make([]string, len(values))
+	for i, serial := range values {
+		serials[i] = fmt.Sprintf("%d", serial)
+	}
+	return serials
+}
+
+// agreeing returns the size of the largest group of servers with the same answers
+func (s propagationSummary) agreeing() int {
+	largest := 0
+	for _, servers := range s.answers {
+		if len(servers) > largest {
+			largest = len(servers)
+		}
+	}
+	return largest
+}
+
+func (s propagationSummary) errorMessage() string {
+	var problems []string
+	if len(s.answers) > 1 {
+		problems = append(problems, fmt.Sprintf("%d different answers", len(s.answers)))
+	}
+	if len(s.serials) > 1 {
+		problems = append(problems, fmt.Sprintf("SOA serials %s", strings.Join(s.serialList(), ", ")))
+	}
+	if len(s.failed) > 0 {
+		problems = append(problems, fmt.Sprintf("no answer from %s", strings.Join(s.failed, ", ")))
+	}
+	return fmt.Sprintf("DNS propagation inconsistent: %s", strings.Join(problems, "; "))
+}
+
+func (d *DNSOperation) createPropagationMessage(result *types.OperationResult, host, zone string, summary propagationSummary, discoverErr error) string {
+	var details strings.Builder
+
+	if result.DNSConsistency == DNSConsistent {
+		details.WriteString(fmt.Sprintf("🟢 DNS CONSISTENT - %s %s on %d nameservers",
+			result.DNSType, host, summary.total))
+	} else {
+		details.WriteString(fmt.Sprintf("🔀 DNS INCONSISTENT - %s %s | %d/%d nameservers agree",
+			result.DNSType, host, summary.agreeing(), summary.total))
+	}
+
+	details.WriteString(fmt.Sprintf(" | Zone: %s", strings.TrimSuffix(zone, ".")))
+	if serials := summary.serialList(); len(serials) > 0 {
+		details.WriteString(fmt.Sprintf(" | Serial: %s", strings.Join(serials, ", ")))
+	}
+	if len(summary.failed) > 0 {
+		details.WriteString(fmt.Sprintf(" | Failed: %s", strings.Join(summary.failed, ", ")))
+	}
+	if discoverErr != nil {
+		details.WriteString(fmt.Sprintf(" | Authoritative lookup: %s", d.getShortErrorMessage(discoverErr.Error())))
+	}
+
+	return details.String()
+}
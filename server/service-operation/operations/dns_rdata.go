@@ -0,0 +1,206 @@
+package operations
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Record types the dnsmessage package has no structured support for
+const (
+	dnsTypeDS     dnsmessage.Type = 43
+	dnsTypeRRSIG  dnsmessage.Type = 46
+	dnsTypeNSEC   dnsmessage.Type = 47
+	dnsTypeDNSKEY dnsmessage.Type = 48
+	dnsTypeNSEC3  dnsmessage.Type = 50
+	dnsTypeCAA    dnsmessage.Type = 257
+)
+
+// parseDNSRecordFields returns the structured fields and presentation value of
+// a record, or nil fields for types without structured parsing
+func parseDNSRecordFields(body dnsmessage.ResourceBody) (map[string]string, string) {
+	switch rb := body.(type) {
+	case *dnsmessage.SOAResource:
+		fields := map[string]string{
+			"mname":   rb.NS.String(),
+			"rname":   rb.MBox.String(),
+			"serial":  strconv.FormatUint(uint64(rb.Serial), 10),
+			"refresh": strconv.FormatUint(uint64(rb.Refresh), 10),
+			"retry":   strconv.FormatUint(uint64(rb.Retry), 10),
+			"expire":  strconv.FormatUint(uint64(rb.Expire), 10),
+			"minimum": strconv.FormatUint(uint64(rb.MinTTL), 10),
+		}
+		return fields, fmt.Sprintf("%s %s %d %d %d %d %d",
+			rb.NS.String(), rb.MBox.String(), rb.Serial, rb.Refresh, rb.Retry, rb.Expire, rb.MinTTL)
+
+	case *dnsmessage.SRVResource:
+		fields := map[string]string{
+			"priority": strconv.Itoa(int(rb.Priority)),
+			"weight":   strconv.Itoa(int(rb.Weight)),
+			"port":     strconv.Itoa(int(rb.Port)),
+			"target":   rb.Target.String(),
+		}
+		return fields, fmt.Sprintf("%d %d %d %s", rb.Priority, rb.Weight, rb.Port, rb.Target.String())
+
+	case *dnsmessage.PTRResource:
+		return map[string]string{"target": rb.PTR.String()}, rb.PTR.String()
+
+	case *dnsmessage.UnknownResource:
+		switch rb.Type {
+		case dnsTypeCAA:
+			return parseCAA(rb.Data)
+		case dnsTypeDS:
+			return parseDS(rb.Data)
+		case dnsTypeDNSKEY:
+			return parseDNSKEY(rb.Data)
+		case dnsTypeRRSIG:
+			return parseRRSIGFields(rb.Data)
+		}
+	}
+
+	return nil, ""
+}
+
+// parseCAA parses RFC 8659 certification authority authorization data
+func parseCAA(data []byte) (map[string]string, string) {
+	if len(data) < 2 || len(data) < 2+int(data[1]) {
+		return malformedRecord(data)
+	}
+
+	flags := data[0]
+	tagLen := int(data[1])
+	tag := string(data[2 : 2+tagLen])
+	value := string(data[2+tagLen:])
+
+	fields := map[string]string{
+		"flags": strconv.Itoa(int(flags)),
+		"tag":   tag,
+		"value": value,
+	}
+	if flags&0x80 != 0 {
+		fields["critical"] = "true"
+	}
+	return fields, fmt.Sprintf("%d %s %q", flags, tag, value)
+}
+
+// parseDS parses RFC 4034 delegation signer data
+func parseDS(data []byte) (map[string]string, string) {
+	if len(data) < 5 {
+		return malformedRecord(data)
+	}
+
+	keyTag := binary.BigEndian.Uint16(data[0:2])
+	algorithm := data[2]
+	digestType := data[3]
+	digest := strings.ToUpper(hex.EncodeToString(data[4:]))
+
+	fields := map[string]string{
+		"key_tag":     strconv.Itoa(int(keyTag)),
+		"algorithm":   strconv.Itoa(int(algorithm)),
+		"digest_type": strconv.Itoa(int(digestType)),
+		"digest":      digest,
+	}
+	return fields, fmt.Sprintf("%d %d %d %s", keyTag, algorithm, digestType, digest)
+}
+
+// parseDNSKEY parses RFC 4034 DNS public key data
+func parseDNSKEY(data []byte) (map[string]string, string) {
+	if len(data) < 4 {
+		return malformedRecord(data)
+	}
+
+	flags := binary.BigEndian.Uint16(data[0:2])
+	protocol := data[2]
+	algorithm := data[3]
+	publicKey := base64.StdEncoding.EncodeToString(data[4:])
+
+	role := "ZSK"
+	if flags&0x0001 != 0 {
+		role = "KSK"
+	}
+
+	fields := map[string]string{
+		"flags":      strconv.Itoa(int(flags)),
+		"protocol":   strconv.Itoa(int(protocol)),
+		"algorithm":  strconv.Itoa(int(algorithm)),
+		"key_tag":    strconv.Itoa(int(dnskeyTag(data))),
+		"role":       role,
+		"public_key": publicKey,
+	}
+	return fields, fmt.Sprintf("%d %d %d %s", flags, protocol, algorithm, publicKey)
+}
+
+// parseRRSIGFields parses RFC 4034 signature data
+func parseRRSIGFields(data []byte) (map[string]string, string) {
+	sig, err := parseRRSIG(".", data)
+	if err != nil {
+		return malformedRecord(data)
+	}
+
+	const timeFormat = "20060102150405"
+	expiration := time.Unix(int64(sig.expiration), 0).UTC().Format(timeFormat)
+	inception := time.Unix(int64(sig.inception), 0).UTC().Format(timeFormat)
+
+	fields := map[string]string{
+		"type_covered": dnsTypeName(sig.typeCovered),
+		"algorithm":    strconv.Itoa(int(sig.algorithm)),
+		"labels":       strconv.Itoa(int(sig.labels)),
+		"original_ttl": strconv.FormatUint(uint64(sig.originalTTL), 10),
+		"expiration":   expiration,
+		"inception":    inception,
+		"key_tag":      strconv.Itoa(int(sig.keyTag)),
+		"signer":       sig.signerName,
+	}
+	return fields, fmt.Sprintf("%s %d %d %d %s %s %d %s %s",
+		fields["type_covered"], sig.algorithm, sig.labels, sig.originalTTL, expiration, inception,
+		sig.keyTag, sig.signerName, base64.StdEncoding.EncodeToString(sig.signature))
+}
+
+// dnskeyTag computes the key tag of DNSKEY rdata (RFC 4034 appendix B)
+func dnskeyTag(rdata []byte) uint16 {
+	var ac uint32
+	for i, b := range rdata {
+		if i&1 == 1 {
+			ac += uint32(b)
+		} else {
+			ac += uint32(b) << 8
+		}
+	}
+	ac += ac >> 16 & 0xFFFF
+	return uint16(ac & 0xFFFF)
+}
+
+func malformedRecord(data []byte) (map[string]string, string) {
+	return map[string]string{"malformed": "true"}, fmt.Sprintf("\\# %d %x", len(data), data)
+}
+
+// reverseDNSName converts an IP address to its in-addr.arpa or ip6.arpa name,
+// returning the input unchanged when it is not an IP address
+func reverseDNSName(host string) string {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", v4[3], v4[2], v4[1], v4[0])
+	}
+
+	const hexDigits = "0123456789abcdef"
+	var name strings.Builder
+	for i := len(ip) - 1; i >= 0; i-- {
+		name.WriteByte(hexDigits[ip[i]&0x0F])
+		name.WriteByte('.')
+		name.WriteByte(hexDigits[ip[i]>>4])
+		name.WriteByte('.')
+	}
+	name.WriteString("ip6.arpa.")
+	return name.String()
+}
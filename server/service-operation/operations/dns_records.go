@@ -0,0 +1,160 @@
+package operations
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"service-operation/types"
+)
+
+// dnsQueryTypes maps supported query type names to their wire types
+var dnsQueryTypes = map[string]dnsmessage.Type{
+	"A":      dnsmessage.TypeA,
+	"AAAA":   dnsmessage.TypeAAAA,
+	"MX":     dnsmessage.TypeMX,
+	"TXT":    dnsmessage.TypeTXT,
+	"CNAME":  dnsmessage.TypeCNAME,
+	"NS":     dnsmessage.TypeNS,
+	"SOA":    dnsmessage.TypeSOA,
+	"SRV":    dnsmessage.TypeSRV,
+	"CAA":    dnsTypeCAA,
+	"PTR":    dnsmessage.TypePTR,
+	"DS":     dnsTypeDS,
+	"DNSKEY": dnsTypeDNSKEY,
+}
+
+// dnsExtraTypeNames names record types that may appear in responses but can't be queried directly
+var dnsExtraTypeNames = map[dnsmessage.Type]string{
+	dnsmessage.TypeOPT: "OPT",
+	dnsTypeRRSIG:       "RRSIG",
+	dnsTypeNSEC:        "NSEC",
+	dnsTypeNSEC3:       "NSEC3",
+}
+
+// IsSupportedDNSType reports whether the DNS operation can query the record type
+func IsSupportedDNSType(query string) bool {
+	_, ok := dnsQueryTypes[strings.ToUpper(query)]
+	return ok
+}
+
+// dnsTypeName returns the mnemonic of a record type, e.g. "A" or "TYPE65"
+func dnsTypeName(t dnsmessage.Type) string {
+	for name, qtype := range dnsQueryTypes {
+		if qtype == t {
+			return name
+		}
+	}
+	if name, ok := dnsExtraTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("TYPE%d", uint16(t))
+}
+
+// dnsRcodeName returns the conventional name of a response code
+func dnsRcodeName(rcode dnsmessage.RCode) string {
+	switch rcode {
+	case dnsmessage.RCodeSuccess:
+		return "NOERROR"
+	case dnsmessage.RCodeFormatError:
+		return "FORMERR"
+	case dnsmessage.RCodeServerFailure:
+		return "SERVFAIL"
+	case dnsmessage.RCodeNameError:
+		return "NXDOMAIN"
+	case dnsmessage.RCodeNotImplemented:
+		return "NOTIMP"
+	case dnsmessage.RCodeRefused:
+		return "REFUSED"
+	default:
+		return fmt.Sprintf("RCODE%d", uint16(rcode))
+	}
+}
+
+// dnsRcodeError converts an unsuccessful response code to an error whose text
+// matches the wording the DNS error classification expects
+func dnsRcodeError(rcode dnsmessage.RCode) error {
+	switch rcode {
+	case dnsmessage.RCodeSuccess:
+		return nil
+	case dnsmessage.RCodeNameError:
+		return fmt.Errorf("no such host (NXDOMAIN)")
+	case dnsmessage.RCodeServerFailure:
+		return fmt.Errorf("server failure (SERVFAIL)")
+	case dnsmessage.RCodeRefused:
+		return fmt.Errorf("query refused (REFUSED)")
+	default:
+		return fmt.Errorf("DNS query failed (%s)", dnsRcodeName(rcode))
+	}
+}
+
+// formatDNSResources converts wire resources to result records, skipping EDNS0 OPT pseudo records
+func formatDNSResources(resources []dnsmessage.Resource) []types.DNSRecord {
+	var records []types.DNSRecord
+	for _, resource := range resources {
+		if resource.Header.Type == dnsmessage.TypeOPT {
+			continue
+		}
+		fields, _ := parseDNSRecordFields(resource.Body)
+		records = append(records, types.DNSRecord{
+			Name:   resource.Header.Name.String(),
+			Type:   dnsTypeName(resource.Header.Type),
+			TTL:    resource.Header.TTL,
+			Value:  formatDNSResourceBody(resource.Body),
+			Fields: fields,
+		})
+	}
+	return records
+}
+
+// formatDNSResourceBody renders record data in zone file presentation format
+func formatDNSResourceBody(body dnsmessage.ResourceBody) string {
+	if fields, value := parseDNSRecordFields(body); fields != nil {
+		return value
+	}
+
+	switch rb := body.(type) {
+	case *dnsmessage.AResource:
+		return net.IP(rb.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		return net.IP(rb.AAAA[:]).String()
+	case *dnsmessage.CNAMEResource:
+		return rb.CNAME.String()
+	case *dnsmessage.NSResource:
+		return rb.NS.String()
+	case *dnsmessage.MXResource:
+		return fmt.Sprintf("%d %s", rb.Pref, rb.MX.String())
+	case *dnsmessage.TXTResource:
+		return strings.Join(rb.TXT, "")
+	case *dnsmessage.UnknownResource:
+		return fmt.Sprintf("\\# %d %x", len(rb.Data), rb.Data)
+	default:
+		return fmt.Sprintf("%v", body)
+	}
+}
+
+// answerValues extracts the values of the requested type from the answer
+// section, in the formats historically returned by the DNS operation
+func answerValues(resources []dnsmessage.Resource, qtype dnsmessage.Type) []string {
+	var values []string
+	for _, resource := range resources {
+		if resource.Header.Type != qtype && !isUnknownOfType(resource.Body, qtype) {
+			continue
+		}
+		switch rb := resource.Body.(type) {
+		case *dnsmessage.MXResource:
+			values = append(values, fmt.Sprintf("%s (priority: %d)", rb.MX.String(), rb.Pref))
+		default:
+			values = append(values, formatDNSResourceBody(resource.Body))
+		}
+	}
+	return values
+}
+
+// isUnknownOfType matches record types dnsmessage only exposes as unknown resources
+func isUnknownOfType(body dnsmessage.ResourceBody, qtype dnsmessage.Type) bool {
+	unknown, ok := body.(*dnsmessage.UnknownResource)
+	return ok && unknown.Type == qtype
+}
@@ -0,0 +1,282 @@
+package operations
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"service-operation/types"
+)
+
+// DefaultDNSBLZones are queried when no blocklists are configured
+var DefaultDNSBLZones = []string{
+	"zen.spamhaus.org",
+	"bl.spamcop.net",
+	"b.barracudacentral.org",
+	"psbl.surriel.com",
+	"dnsbl-1.uceprotect.net",
+}
+
+// dnsblConcurrency bounds the number of blocklist queries in flight
+const dnsblConcurrency = 8
+
+type DNSBLOperation struct {
+	timeout time.Duration
+}
+
+// DNSBLOptions configures a blocklist check
+type DNSBLOptions struct {
+	Zones       []string // Empty uses DefaultDNSBLZones
+	Nameservers []string // Empty uses the system resolvers
+	Transport   string   // auto, udp or tcp
+}
+
+func NewDNSBLOperation(timeout time.Duration) *DNSBLOperation {
+	return &DNSBLOperation{timeout: timeout}
+}
+
+func (b *DNSBLOperation) Execute(targets []string) (*types.OperationResult, error) {
+	return b.ExecuteWithOptions(targets, DNSBLOptions{})
+}
+
+// ExecuteWithOptions checks IP addresses against DNS blocklists. Host names are
+// resolved to the addresses of their MX hosts, or their own A records without MX.
+func (b *DNSBLOperation) ExecuteWithOptions(targets []string, opts DNSBLOptions) (*types.OperationResult, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("host cannot be empty")
+	}
+
+	configured := opts.Zones
+	if len(configured) == 0 {
+		configured = DefaultDNSBLZones
+	}
+	var zones []string
+	for _, zone := range configured {
+		if zone = strings.Trim(strings.TrimSpace(zone), "."); zone != "" {
+			zones = append(zones, zone)
+		}
+	}
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("no valid DNSBL zones configured")
+	}
+
+	result := &types.OperationResult{
+		Type:       types.OperationDNSBL,
+		Host:       strings.Join(targets, ","),
+		StartTime:  time.Now(),
+		DNSBLZones: zones,
+	}
+
+	dnsOpts := DNSQueryOptions{Nameservers: opts.Nameservers, Transport: opts.Transport}
+	addresses, resolveErrors := b.resolveTargets(targets, dnsOpts)
+	result.DNSBLAddresses = addresses
+	result.DNSBLErrors = resolveErrors
+
+	if len(addresses) == 0 {
+		result.EndTime = time.Now()
+		result.ResponseTime = result.EndTime.Sub(result.StartTime)
+		result.Success = false
+		result.Error = fmt.Sprintf("no addresses to check: %s", strings.Join(resolveErrors, "; "))
+		result.Details = fmt.Sprintf("❌ DNSBL FAILED - %s", result.Error)
+		return result, nil
+	}
+
+	client := NewDNSClient(b.timeout, opts.Nameservers, opts.Transport)
+	listings, queryErrors := b.queryBlocklists(client, addresses, zones)
+
+	result.EndTime = time.Now()
+	result.ResponseTime = result.EndTime.Sub(result.StartTime)
+	result.DNSBLListings = listings
+	result.DNSBLErrors = append(result.DNSBLErrors, queryErrors...)
+
+	switch {
+	case len(listings) > 0:
+		var listed []string
+		for _, listing := range listings {
+			listed = append(listed, fmt.Sprintf("%s on %s", listing.IP, listing.Zone))
+		}
+		result.Success = false
+		result.Error = fmt.Sprintf("Blocklisted: %s", strings.Join(listed, ", "))
+	case len(queryErrors) > 0 && len(queryErrors) == len(addresses)*len(zones):
+		result.Success = false
+		result.Error = fmt.Sprintf("no blocklist could be queried: %s", queryErrors[0])
+	default:
+		result.Success = true
+	}
+	result.Details = b.createDetailedMessage(result)
+
+	return result, nil
+}
+
+// resolveTargets turns the targets into IP addresses through DNSOperation
+func (b *DNSBLOperation) resolveTargets(targets []string, opts DNSQueryOptions) ([]string, []string) {
+	var addresses, errors []string
+	seen := make(map[string]bool)
+	add := func(ip string) {
+		if !seen[ip] {
+			seen[ip] = true
+			addresses = append(addresses, ip)
+		}
+	}
+
+	dnsOp := NewDNSOperation(b.timeout)
+	for _, target := range targets {
+		if ip := net.ParseIP(target); ip != nil {
+			add(ip.String())
+			continue
+		}
+
+		hosts := []string{target}
+		if mx, err := dnsOp.ExecuteWithOptions(target, "MX", opts); err == nil && mx.Success {
+			hosts = nil
+			for _, record := range mx.DNSAnswers {
+				if fields := strings.Fields(record.Value); record.Type == "MX" && len(fields) == 2 {
+					hosts = append(hosts, fields[1])
+				}
+			}
+		}
+
+		resolved := false
+		for _, host := range hosts {
+			a, err := dnsOp.ExecuteWithOptions(host, "A", opts)
+			if err != nil || !a.Success {
+				continue
+			}
+			for _, record := range a.DNSAnswers {
+				if record.Type == "A" {
+					add(record.Value)
+					resolved = true
+				}
+			}
+		}
+		if !resolved {
+			errors = append(errors, fmt.Sprintf("could not resolve %s", target))
+		}
+	}
+	return addresses, errors
+}
+
+type dnsblQuery struct {
+	ip   string
+	zone string
+}
+
+// queryBlocklists looks up every address on every zone in parallel
+func (b *DNSBLOperation) queryBlocklists(client *DNSClient, addresses, zones []string) ([]types.DNSBLListing, []string) {
+	queries := make(chan dnsblQuery)
+	var mu sync.Mutex
+	var listings []types.DNSBLListing
+	var errors []string
+
+	var wg sync.WaitGroup
+	for i := 0; i < dnsblConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for query := range queries {
+				listing, err := lookupDNSBL(client, query.ip, query.zone)
+
+				mu.Lock()
+				if err != nil {
+					errors = append(errors, fmt.Sprintf("%s: %v", query.zone, err))
+				} else if listing != nil {
+					listings = append(listings, *listing)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, ip := range addresses {
+		for _, zone := range zones {
+			queries <- dnsblQuery{ip: ip, zone: zone}
+		}
+	}
+	close(queries)
+	wg.Wait()
+
+	return listings, errors
+}
+
+// lookupDNSBL queries one blocklist for an address, returning nil when it is not listed
+func lookupDNSBL(client *DNSClient, ip, zone string) (*types.DNSBLListing, error) {
+	name, err := dnsblQueryName(ip, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Query(name, dnsmessage.TypeA)
+	if err != nil {
+		return nil, err
+	}
+	rcode := resp.Message.Header.RCode
+	if rcode == dnsmessage.RCodeNameError {
+		return nil, nil
+	}
+	if err := dnsRcodeError(rcode); err != nil {
+		return nil, err
+	}
+
+	codes := answerValues(resp.Message.Answers, dnsmessage.TypeA)
+	if len(codes) == 0 {
+		return nil, nil
+	}
+	for _, code := range codes {
+		// Lists answer outside 127.0.0.0/8 when wildcarded, and with 127.255.255.x
+		// when they refuse to serve the resolver (e.g. public resolvers on Spamhaus)
+		if !strings.HasPrefix(code, "127.") {
+			return nil, fmt.Errorf("unexpected response %s", code)
+		}
+		if strings.HasPrefix(code, "127.255.255.") {
+			return nil, fmt.Errorf("query refused by list (%s)", code)
+		}
+	}
+
+	listing := &types.DNSBLListing{IP: ip, Zone: zone, Codes: codes}
+	if txt, err := client.Query(name, dnsmessage.TypeTXT); err == nil {
+		listing.Reason = strings.Join(answerValues(txt.Message.Answers, dnsmessage.TypeTXT), " ")
+	}
+	return listing, nil
+}
+
+// dnsblQueryName builds the blocklist name of an address, e.g. 2.0.0.127.zen.spamhaus.org
+func dnsblQueryName(ip, zone string) (string, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", fmt.Errorf("invalid IP address %q", ip)
+	}
+
+	reversed := reverseDNSName(parsed.String())
+	reversed = strings.TrimSuffix(reversed, "in-addr.arpa.")
+	reversed = strings.TrimSuffix(reversed, "ip6.arpa.")
+	return reversed + fqdn(zone), nil
+}
+
+func (b *DNSBLOperation) createDetailedMessage(result *types.OperationResult) string {
+	var details strings.Builder
+
+	switch {
+	case len(result.DNSBLListings) > 0:
+		var listed []string
+		for _, listing := range result.DNSBLListings {
+			listed = append(listed, fmt.Sprintf("%s on %s (%s)", listing.IP, listing.Zone, strings.Join(listing.Codes, ", ")))
+		}
+		details.WriteString(fmt.Sprintf("🚫 BLOCKLISTED - %s", strings.Join(listed, "; ")))
+	case result.Success:
+		details.WriteString(fmt.Sprintf("🛡️ NOT LISTED - %d addresses clean on %d blocklists",
+			len(result.DNSBLAddresses), len(result.DNSBLZones)))
+	default:
+		details.WriteString(fmt.Sprintf("❌ DNSBL FAILED - %s", result.Error))
+	}
+
+	details.WriteString(fmt.Sprintf(" | Addresses: %s", strings.Join(result.DNSBLAddresses, ", ")))
+	if len(result.DNSBLErrors) > 0 {
+		details.WriteString(fmt.Sprintf(" | %d lookups failed", len(result.DNSBLErrors)))
+	}
+
+	return details.String()
+}
@@ -0,0 +1,649 @@
+package operations
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"service-operation/types"
+)
+
+// DNSSEC validation results
+const (
+	DNSSECSecure   = "secure"   // Chain of trust validated up to a trust anchor
+	DNSSECInsecure = "insecure" // Provably unsigned, e.g. no DS at the parent
+	DNSSECBogus    = "bogus"    // Signatures missing, expired or invalid
+)
+
+// DefaultDNSSECExpiryWarning is how long before expiry signatures are reported
+const DefaultDNSSECExpiryWarning = 7 * 24 * time.Hour
+
+// rootTrustAnchors are the DS records of the IANA root zone KSKs
+var rootTrustAnchors = []string{
+	". IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D",
+	". IN DS 38696 8 2 683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16",
+}
+
+// parseTrustAnchors parses DS records in presentation format such as
+// "example.com. IN DS 12345 13 2 <digest>", keyed by zone
+func parseTrustAnchors(lines []string) (map[string][]*dsRecord, error) {
+	anchors := make(map[string][]*dsRecord)
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		zone := canonicalName(fields[0])
+		rest := fields[1:]
+		for len(rest) > 1 && (isDSKeyword(rest[0]) || isTTL(rest[0]) && isDSKeyword(rest[1])) {
+			rest = rest[1:]
+		}
+		if len(rest) < 4 {
+			return nil, fmt.Errorf("invalid trust anchor %q", line)
+		}
+
+		keyTag, errTag := strconv.ParseUint(rest[0], 10, 16)
+		algorithm, errAlg := strconv.ParseUint(rest[1], 10, 8)
+		digestType, errType := strconv.ParseUint(rest[2], 10, 8)
+		digest, errDigest := hex.DecodeString(strings.Join(rest[3:], ""))
+		if errTag != nil || errAlg != nil || errType != nil || errDigest != nil {
+			return nil, fmt.Errorf("invalid trust anchor %q", line)
+		}
+
+		anchors[zone] = append(anchors[zone], &dsRecord{
+			owner:      zone,
+			keyTag:     uint16(keyTag),
+			algorithm:  uint8(algorithm),
+			digestType: uint8(digestType),
+			digest:     digest,
+		})
+	}
+	return anchors, nil
+}
+
+// isTTL reports whether a presentation field is a numeric TTL
+func isTTL(field string) bool {
+	_, err := strconv.ParseUint(field, 10, 32)
+	return err == nil
+}
+
+func isDSKeyword(field string) bool {
+	return strings.EqualFold(field, "IN") || strings.EqualFold(field, "DS")
+}
+
+// zoneKeys is the validation state of a zone's DNSKEY RRset
+type zoneKeys struct {
+	status string
+	reason string
+	keys   []*dnskeyRecord
+}
+
+// dnssecValidator walks the chain of trust from an answer up to a trust anchor
+type dnssecValidator struct {
+	client        *DNSClient
+	anchors       map[string][]*dsRecord
+	now           time.Time
+	expiryWarning time.Duration
+
+	zones      map[string]*zoneKeys
+	chain      []string
+	warnings   []string
+	expiration time.Time
+}
+
+func newDNSSECValidator(client *DNSClient, anchors map[string][]*dsRecord, expiryWarning time.Duration) *dnssecValidator {
+	return &dnssecValidator{
+		client:        client,
+		anchors:       anchors,
+		now:           time.Now(),
+		expiryWarning: expiryWarning,
+		zones:         make(map[string]*zoneKeys),
+	}
+}
+
+// ExecuteDNSSEC performs the query with DNSSEC records and validates the answer
+// from the signing zone up to the configured trust anchors (the root KSKs by default)
+func (d *DNSOperation) ExecuteDNSSEC(host, query string, opts DNSQueryOptions) (*types.OperationResult, error) {
+	if host == "" {
+		return nil, fmt.Errorf("host cannot be empty")
+	}
+
+	if query == "" {
+		query = "A"
+	}
+
+	qtype, ok := dnsQueryTypes[strings.ToUpper(query)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported DNS record type %q", query)
+	}
+
+	anchors, err := parseTrustAnchors(append(append([]string{}, rootTrustAnchors...), opts.TrustAnchors...))
+	if err != nil {
+		return nil, err
+	}
+
+	expiryWarning := opts.ExpiryWarning
+	if expiryWarning <= 0 {
+		expiryWarning = DefaultDNSSECExpiryWarning
+	}
+
+	result := &types.OperationResult{
+		Type:      types.OperationDNS,
+		Host:      host,
+		DNSType:   strings.ToUpper(query),
+		StartTime: time.Now(),
+	}
+
+	name := host
+	if qtype == dnsmessage.TypePTR {
+		name = reverseDNSName(host)
+	}
+
+	client := NewDNSClient(d.timeout, opts.Nameservers, opts.Transport)
+	client.SetDNSSEC(true)
+
+	resp, err := client.Query(name, qtype)
+	if err == nil {
+		d.applyResponse(result, resp, qtype)
+
+		validator := newDNSSECValidator(client, anchors, expiryWarning)
+		status, reason := validator.validateResponse(resp.Message, name, qtype)
+
+		result.DNSSECStatus = status
+		result.DNSSECChain = validator.chain
+		result.DNSSECWarnings = validator.warnings
+		result.DNSSECExpiration = validator.expiration
+
+		switch status {
+		case DNSSECSecure:
+			err = dnsRcodeError(resp.Message.Header.RCode)
+		case DNSSECInsecure:
+			err = fmt.Errorf("DNSSEC insecure: %s", reason)
+		default:
+			err = fmt.Errorf("DNSSEC validation failed (bogus): %s", reason)
+		}
+	}
+
+	result.ResponseTime = time.Since(result.StartTime)
+	result.EndTime = time.Now()
+
+	if err != nil {
+		result.Success = false
+		result.Error = err.Error()
+	} else if len(result.DNSRecords) == 0 {
+		result.Success = false
+		result.Error = "No DNS records found"
+	} else {
+		result.Success = true
+	}
+
+	if result.DNSSECStatus == "" {
+		result.Details = d.createDetailedErrorMessage(result.Error, host, query)
+	} else {
+		result.Details = d.createDNSSECMessage(result, host)
+	}
+
+	return result, nil
+}
+
+// validateResponse validates every RRset of the answer, or of the authority
+// section for negative answers
+func (v *dnssecValidator) validateResponse(msg *dnsmessage.Message, name string, qtype dnsmessage.Type) (string, string) {
+	section := msg.Answers
+	if len(section) == 0 {
+		section = msg.Authorities
+	}
+
+	rrsets, sigs := groupRRsets(section)
+	if len(rrsets) == 0 {
+		// Nothing signed to look at; the zone decides whether that is expected
+		zone, err := v.enclosingZone(name)
+		if err != nil {
+			return DNSSECBogus, err.Error()
+		}
+		keys := v.zoneKeys(zone)
+		if keys.status != DNSSECSecure {
+			return keys.status, keys.reason
+		}
+		return DNSSECBogus, fmt.Sprintf("no signed records in %s response for %s", dnsTypeName(qtype), name)
+	}
+
+	status, reason := DNSSECSecure, ""
+	for _, key := range rrsetOrder(section) {
+		setStatus, setReason := v.validateRRset(rrsets[key], sigs[key])
+		if setStatus == DNSSECBogus {
+			return setStatus, setReason
+		}
+		if setStatus == DNSSECInsecure {
+			status, reason = setStatus, setReason
+		}
+	}
+	return status, reason
+}
+
+// validateRRset validates an RRset against the keys of the zone that signed it
+func (v *dnssecValidator) validateRRset(rrset []dnsmessage.Resource, sigs []*rrsigRecord) (string, string) {
+	owner := canonicalName(rrset[0].Header.Name.String())
+	typeName := dnsTypeName(rrset[0].Header.Type)
+
+	if len(sigs) == 0 {
+		zone, err := v.enclosingZone(owner)
+		if err != nil {
+			return DNSSECBogus, err.Error()
+		}
+		keys := v.zoneKeys(zone)
+		if keys.status != DNSSECSecure {
+			return keys.status, keys.reason
+		}
+		return DNSSECBogus, fmt.Sprintf("%s %s is not signed although %s is", owner, typeName, zone)
+	}
+
+	signer := sigs[0].signerName
+	if !isSubdomain(owner, signer) {
+		return DNSSECBogus, fmt.Sprintf("%s %s is signed by unrelated zone %s", owner, typeName, signer)
+	}
+
+	keys := v.zoneKeys(signer)
+	if keys.status != DNSSECSecure {
+		return keys.status, keys.reason
+	}
+
+	if err := v.verifyRRset(rrset, sigs, keys.keys); err != nil {
+		return DNSSECBogus, fmt.Sprintf("%s %s: %v", owner, typeName, err)
+	}
+	return DNSSECSecure, ""
+}
+
+// zoneKeys returns the validated DNSKEYs of a zone, authenticating them with a
+// trust anchor or with the DS RRset of the parent zone
+func (v *dnssecValidator) zoneKeys(zone string) *zoneKeys {
+	zone = canonicalName(zone)
+	if cached, ok := v.zones[zone]; ok {
+		return cached
+	}
+
+	// Guard against loops while the chain is walked
+	v.zones[zone] = &zoneKeys{status: DNSSECBogus, reason: fmt.Sprintf("trust chain loop at %s", zone)}
+	keys := v.authenticateZone(zone)
+	v.zones[zone] = keys
+	return keys
+}
+
+func (v *dnssecValidator) authenticateZone(zone string) *zoneKeys {
+	if anchors, ok := v.anchors[zone]; ok {
+		keys := v.validateDNSKEYs(zone, anchors)
+		if keys.status == DNSSECSecure {
+			v.chain = append(v.chain, fmt.Sprintf("%s DNSKEY trusted by anchor %s", zone, dsTags(anchors)))
+		}
+		return keys
+	}
+	if zone == "." {
+		return &zoneKeys{status: DNSSECBogus, reason: "no trust anchor for the root zone"}
+	}
+
+	resp, err := v.query(zone, dnsTypeDS)
+	if err != nil {
+		return &zoneKeys{status: DNSSECBogus, reason: fmt.Sprintf("DS lookup for %s failed: %v", zone, err)}
+	}
+
+	rrsets, sigs := groupRRsets(resp.Answers)
+	key := rrsetKey(zone, dnsTypeDS)
+	dsSet := rrsets[key]
+
+	if len(dsSet) == 0 {
+		return v.provenInsecure(zone, resp)
+	}
+
+	// The DS RRset is authenticated by the parent zone
+	if len(sigs[key]) == 0 {
+		return &zoneKeys{status: DNSSECBogus, reason: fmt.Sprintf("DS for %s is not signed", zone)}
+	}
+	parent := sigs[key][0].signerName
+	if parent == zone || !isSubdomain(zone, parent) {
+		return &zoneKeys{status: DNSSECBogus, reason: fmt.Sprintf("DS for %s is signed by %s", zone, parent)}
+	}
+
+	parentKeys := v.zoneKeys(parent)
+	if parentKeys.status != DNSSECSecure {
+		return parentKeys
+	}
+	if err := v.verifyRRset(dsSet, sigs[key], parentKeys.keys); err != nil {
+		return &zoneKeys{status: DNSSECBogus, reason: fmt.Sprintf("DS for %s: %v", zone, err)}
+	}
+
+	var dsRecords []*dsRecord
+	for _, rr := range dsSet {
+		if unknown, ok := rr.Body.(*dnsmessage.UnknownResource); ok {
+			if ds, err := parseDSRecord(zone, unknown.Data); err == nil {
+				dsRecords = append(dsRecords, ds)
+			}
+		}
+	}
+
+	keys := v.validateDNSKEYs(zone, dsRecords)
+	if keys.status == DNSSECSecure {
+		v.chain = append(v.chain, fmt.Sprintf("%s DNSKEY matches DS %s from %s", zone, dsTags(dsRecords), parent))
+	}
+	return keys
+}
+
+// provenInsecure checks a negative DS answer: a signed denial from a secure
+// parent proves the delegation is unsigned
+func (v *dnssecValidator) provenInsecure(zone string, resp *dnsmessage.Message) *zoneKeys {
+	rrsets, sigs := groupRRsets(resp.Authorities)
+
+	parent := ""
+	for key, rrset := range rrsets {
+		if rrset[0].Header.Type == dnsmessage.TypeSOA {
+			parent = canonicalName(rrset[0].Header.Name.String())
+		}
+		if parent == "" && len(sigs[key]) > 0 {
+			parent = sigs[key][0].signerName
+		}
+	}
+	if parent == "" {
+		parent = parentName(zone)
+	}
+	if parent == zone {
+		// The name is not a zone cut, so it belongs to the zone above
+		return v.zoneKeys(parentName(zone))
+	}
+
+	parentKeys := v.zoneKeys(parent)
+	if parentKeys.status != DNSSECSecure {
+		return parentKeys
+	}
+
+	denied := false
+	for _, key := range rrsetOrder(resp.Authorities) {
+		rrset := rrsets[key]
+		rrType := rrset[0].Header.Type
+		if rrType != dnsTypeNSEC && rrType != dnsTypeNSEC3 {
+			continue
+		}
+		if err := v.verifyRRset(rrset, sigs[key], parentKeys.keys); err != nil {
+			return &zoneKeys{status: DNSSECBogus, reason: fmt.Sprintf("DS denial for %s: %v", zone, err)}
+		}
+		proves, err := deniesDS(zone, parent, rrset[0])
+		if err != nil {
+			return &zoneKeys{status: DNSSECBogus, reason: fmt.Sprintf("DS denial for %s: %v", zone, err)}
+		}
+		denied = denied || proves
+	}
+
+	if !denied {
+		return &zoneKeys{status: DNSSECBogus, reason: fmt.Sprintf("no DS for %s and no signed denial from %s", zone, parent)}
+	}
+
+	v.chain = append(v.chain, fmt.Sprintf("%s has no DS in %s", zone, parent))
+	return &zoneKeys{status: DNSSECInsecure, reason: fmt.Sprintf("%s is not signed (no DS in %s)", zone, parent)}
+}
+
+// deniesDS reports whether an NSEC or NSEC3 record proves the zone name has
+// no DS record: its owner matches the name and its type bitmap lacks DS, or it
+// is an opt-out NSEC3 covering the name's hash. Records about other names
+// prove nothing; a matching record listing DS is an error.
+func deniesDS(zone, parent string, rr dnsmessage.Resource) (bool, error) {
+	unknown, ok := rr.Body.(*dnsmessage.UnknownResource)
+	if !ok {
+		return false, nil
+	}
+	owner := canonicalName(rr.Header.Name.String())
+
+	switch unknown.Type {
+	case dnsTypeNSEC:
+		if owner != zone {
+			return false, nil
+		}
+		bitmap, err := nsecTypeBitmap(unknown.Data)
+		if err != nil {
+			return false, nil
+		}
+		if typeBitmapHas(bitmap, dnsTypeDS) {
+			return false, fmt.Errorf("NSEC lists a DS record")
+		}
+		return true, nil
+	case dnsTypeNSEC3:
+		labels := nameLabels(owner)
+		if len(labels) == 0 || canonicalName(parentName(owner)) != parent {
+			return false, nil
+		}
+		ownerHash := strings.ToLower(labels[0])
+		record, err := parseNSEC3(unknown.Data)
+		if err != nil {
+			return false, nil
+		}
+		hash, err := nsec3Hash(zone, record)
+		if err != nil {
+			return false, nil
+		}
+		switch {
+		case ownerHash == hash:
+			if typeBitmapHas(record.bitmap, dnsTypeDS) {
+				return false, fmt.Errorf("NSEC3 lists a DS record")
+			}
+			return true, nil
+		case record.flags&nsec3FlagOptOut != 0 && nsec3Covers(ownerHash, record.nextHash, hash):
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// validateDNSKEYs fetches the zone's DNSKEY RRset and checks it is signed by a
+// key that one of the DS records refers to
+func (v *dnssecValidator) validateDNSKEYs(zone string, dsRecords []*dsRecord) *zoneKeys {
+	resp, err := v.query(zone, dnsTypeDNSKEY)
+	if err != nil {
+		return &zoneKeys{status: DNSSECBogus, reason: fmt.Sprintf("DNSKEY lookup for %s failed: %v", zone, err)}
+	}
+
+	rrsets, sigs := groupRRsets(resp.Answers)
+	key := rrsetKey(zone, dnsTypeDNSKEY)
+	if len(rrsets[key]) == 0 {
+		return &zoneKeys{status: DNSSECBogus, reason: fmt.Sprintf("%s has DS records but no DNSKEY", zone)}
+	}
+
+	var keys, trusted []*dnskeyRecord
+	for _, rr := range rrsets[key] {
+		unknown, ok := rr.Body.(*dnsmessage.UnknownResource)
+		if !ok {
+			continue
+		}
+		dnskey, err := parseDNSKEYRecord(zone, unknown.Data)
+		if err != nil || dnskey.flags&dnskeyFlagZone == 0 || dnskey.protocol != 3 {
+			continue
+		}
+		keys = append(keys, dnskey)
+		for _, ds := range dsRecords {
+			if dnskey.matchesDS(ds) {
+				trusted = append(trusted, dnskey)
+				break
+			}
+		}
+	}
+
+	if len(trusted) == 0 {
+		return &zoneKeys{status: DNSSECBogus, reason: fmt.Sprintf("no DNSKEY of %s matches DS %s", zone, dsTags(dsRecords))}
+	}
+	if err := v.verifyRRset(rrsets[key], sigs[key], trusted); err != nil {
+		return &zoneKeys{status: DNSSECBogus, reason: fmt.Sprintf("%s DNSKEY: %v", zone, err)}
+	}
+
+	return &zoneKeys{status: DNSSECSecure, keys: keys}
+}
+
+// verifyRRset succeeds when any current signature verifies with one of the keys,
+// recording when that signature expires
+func (v *dnssecValidator) verifyRRset(rrset []dnsmessage.Resource, sigs []*rrsigRecord, keys []*dnskeyRecord) error {
+	if len(sigs) == 0 {
+		return fmt.Errorf("no RRSIG")
+	}
+
+	lastErr := fmt.Errorf("no DNSKEY matches RRSIG key tag %d", sigs[0].keyTag)
+	for _, sig := range sigs {
+		inception := signatureTime(sig.inception, v.now)
+		expiration := signatureTime(sig.expiration, v.now)
+		if v.now.Before(inception) {
+			lastErr = fmt.Errorf("signature %d not valid until %s", sig.keyTag, inception.UTC().Format(time.RFC3339))
+			continue
+		}
+		if v.now.After(expiration) {
+			lastErr = fmt.Errorf("signature %d expired at %s", sig.keyTag, expiration.UTC().Format(time.RFC3339))
+			continue
+		}
+
+		data, err := rrsetSignedData(sig, rrset)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		for _, key := range keys {
+			if key.keyTag != sig.keyTag || key.algorithm != sig.algorithm || key.owner != sig.signerName {
+				continue
+			}
+			if err := verifySignature(sig, key, data); err != nil {
+				lastErr = fmt.Errorf("signature %d invalid: %v", sig.keyTag, err)
+				continue
+			}
+			v.noteExpiration(rrset[0], expiration)
+			return nil
+		}
+	}
+	return lastErr
+}
+
+func (v *dnssecValidator) noteExpiration(rr dnsmessage.Resource, expiration time.Time) {
+	if v.expiration.IsZero() || expiration.Before(v.expiration) {
+		v.expiration = expiration
+	}
+	if remaining := expiration.Sub(v.now); remaining < v.expiryWarning {
+		v.warnings = append(v.warnings, fmt.Sprintf("RRSIG %s %s expires in %s",
+			rr.Header.Name.String(), dnsTypeName(rr.Header.Type), formatRemaining(remaining)))
+	}
+}
+
+// formatRemaining renders a duration as days and hours, e.g. "2d 3h"
+func formatRemaining(d time.Duration) string {
+	hours := int(d.Hours())
+	if hours < 24 {
+		return d.Round(time.Minute).String()
+	}
+	return fmt.Sprintf("%dd %dh", hours/24, hours%24)
+}
+
+// enclosingZone finds the apex of the zone a name belongs to from the SOA record
+func (v *dnssecValidator) enclosingZone(name string) (string, error) {
+	resp, err := v.query(name, dnsmessage.TypeSOA)
+	if err != nil {
+		return "", fmt.Errorf("SOA lookup for %s failed: %v", name, err)
+	}
+	for _, section := range [][]dnsmessage.Resource{resp.Answers, resp.Authorities} {
+		for _, rr := range section {
+			if rr.Header.Type == dnsmessage.TypeSOA {
+				return canonicalName(rr.Header.Name.String()), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no SOA found for %s", name)
+}
+
+func (v *dnssecValidator) query(name string, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	resp, err := v.client.Query(name, qtype)
+	if err != nil {
+		return nil, err
+	}
+	rcode := resp.Message.Header.RCode
+	if rcode != dnsmessage.RCodeSuccess && rcode != dnsmessage.RCodeNameError {
+		return nil, dnsRcodeError(rcode)
+	}
+	return resp.Message, nil
+}
+
+// groupRRsets groups records by owner and type along with the RRSIGs covering them
+func groupRRsets(resources []dnsmessage.Resource) (map[string][]dnsmessage.Resource, map[string][]*rrsigRecord) {
+	rrsets := make(map[string][]dnsmessage.Resource)
+	sigs := make(map[string][]*rrsigRecord)
+
+	for _, rr := range resources {
+		owner := rr.Header.Name.String()
+		switch {
+		case rr.Header.Type == dnsmessage.TypeOPT:
+		case rr.Header.Type == dnsTypeRRSIG:
+			unknown, ok := rr.Body.(*dnsmessage.UnknownResource)
+			if !ok {
+				continue
+			}
+			if sig, err := parseRRSIG(owner, unknown.Data); err == nil {
+				key := rrsetKey(owner, sig.typeCovered)
+				sigs[key] = append(sigs[key], sig)
+			}
+		default:
+			key := rrsetKey(owner, rr.Header.Type)
+			rrsets[key] = append(rrsets[key], rr)
+		}
+	}
+	return rrsets, sigs
+}
+
+// rrsetOrder returns the RRset keys in the order they first appear
+func rrsetOrder(resources []dnsmessage.Resource) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, rr := range resources {
+		if rr.Header.Type == dnsmessage.TypeOPT || rr.Header.Type == dnsTypeRRSIG {
+			continue
+		}
+		key := rrsetKey(rr.Header.Name.String(), rr.Header.Type)
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func rrsetKey(owner string, t dnsmessage.Type) string {
+	return canonicalName(owner) + " " + strconv.Itoa(int(t))
+}
+
+func dsTags(records []*dsRecord) string {
+	tags := make([]string, len(records))
+	for i, ds := range records {
+		tags[i] = strconv.Itoa(int(ds.keyTag))
+	}
+	return strings.Join(tags, "/")
+}
+
+func (d *DNSOperation) createDNSSECMessage(result *types.OperationResult, host string) string {
+	var details strings.Builder
+
+	switch {
+	case result.DNSSECStatus == DNSSECSecure && len(result.DNSSECWarnings) > 0:
+		details.WriteString(fmt.Sprintf("⚠️ DNSSEC SECURE - %s %s | Signatures expiring soon", result.DNSType, host))
+	case result.DNSSECStatus == DNSSECSecure:
+		details.WriteString(fmt.Sprintf("🔐 DNSSEC SECURE - %s %s", result.DNSType, host))
+	case result.DNSSECStatus == DNSSECInsecure:
+		details.WriteString(fmt.Sprintf("🔓 DNSSEC INSECURE - %s %s", result.DNSType, host))
+	default:
+		details.WriteString(fmt.Sprintf("🚨 DNSSEC BOGUS - %s %s", result.DNSType, host))
+	}
+
+	if result.DNSSECStatus == DNSSECSecure && !result.DNSSECExpiration.IsZero() {
+		details.WriteString(fmt.Sprintf(" | Earliest expiry: %s", result.DNSSECExpiration.UTC().Format("2006-01-02 15:04 MST")))
+	}
+	if len(result.DNSSECWarnings) > 0 {
+		details.WriteString(" | " + strings.Join(result.DNSSECWarnings, "; "))
+	}
+	if result.DNSSECStatus != DNSSECSecure || !result.Success {
+		details.WriteString(" | Error: " + result.Error)
+	}
+
+	return details.String()
+}
@@ -0,0 +1,478 @@
+package operations
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNSSEC signing algorithms (RFC 8624)
+const (
+	dnssecAlgRSASHA1         uint8 = 5
+	dnssecAlgRSASHA1NSEC3    uint8 = 7
+	dnssecAlgRSASHA256       uint8 = 8
+	dnssecAlgRSASHA512       uint8 = 10
+	dnssecAlgECDSAP256SHA256 uint8 = 13
+	dnssecAlgECDSAP384SHA384 uint8 = 14
+	dnssecAlgED25519         uint8 = 15
+)
+
+// DS digest types
+const (
+	dsDigestSHA1   uint8 = 1
+	dsDigestSHA256 uint8 = 2
+	dsDigestSHA384 uint8 = 4
+)
+
+const (
+	dnskeyFlagZone = 0x0100
+	dnskeyFlagSEP  = 0x0001
+)
+
+// rrsigRecord is a parsed RRSIG record (RFC 4034 section 3)
+type rrsigRecord struct {
+	owner       string
+	typeCovered dnsmessage.Type
+	algorithm   uint8
+	labels      uint8
+	originalTTL uint32
+	expiration  uint32
+	inception   uint32
+	keyTag      uint16
+	signerName  string
+	signature   []byte
+	signedData  []byte // RDATA without the signature, with a canonical signer name
+}
+
+// dnskeyRecord is a parsed DNSKEY record
+type dnskeyRecord struct {
+	owner     string
+	flags     uint16
+	protocol  uint8
+	algorithm uint8
+	publicKey []byte
+	rdata     []byte
+	keyTag    uint16
+}
+
+// dsRecord is a parsed DS record or trust anchor
+type dsRecord struct {
+	owner      string
+	keyTag     uint16
+	algorithm  uint8
+	digestType uint8
+	digest     []byte
+}
+
+func parseRRSIG(owner string, data []byte) (*rrsigRecord, error) {
+	if len(data) < 19 {
+		return nil, fmt.Errorf("RRSIG too short")
+	}
+
+	signer, n, err := readWireName(data[18:])
+	if err != nil {
+		return nil, fmt.Errorf("RRSIG signer name: %v", err)
+	}
+
+	sig := &rrsigRecord{
+		owner:       canonicalName(owner),
+		typeCovered: dnsmessage.Type(binary.BigEndian.Uint16(data[0:2])),
+		algorithm:   data[2],
+		labels:      data[3],
+		originalTTL: binary.BigEndian.Uint32(data[4:8]),
+		expiration:  binary.BigEndian.Uint32(data[8:12]),
+		inception:   binary.BigEndian.Uint32(data[12:16]),
+		keyTag:      binary.BigEndian.Uint16(data[16:18]),
+		signerName:  canonicalName(signer),
+		signature:   data[18+n:],
+	}
+	sig.signedData = append(append([]byte{}, data[:18]...), canonicalNameWire(sig.signerName)...)
+	return sig, nil
+}
+
+func parseDNSKEYRecord(owner string, data []byte) (*dnskeyRecord, error) {
+	if len(data) < 5 {
+		return nil, fmt.Errorf("DNSKEY too short")
+	}
+	return &dnskeyRecord{
+		owner:     canonicalName(owner),
+		flags:     binary.BigEndian.Uint16(data[0:2]),
+		protocol:  data[2],
+		algorithm: data[3],
+		publicKey: data[4:],
+		rdata:     data,
+		keyTag:    dnskeyTag(data),
+	}, nil
+}
+
+func parseDSRecord(owner string, data []byte) (*dsRecord, error) {
+	if len(data) < 5 {
+		return nil, fmt.Errorf("DS too short")
+	}
+	return &dsRecord{
+		owner:      canonicalName(owner),
+		keyTag:     binary.BigEndian.Uint16(data[0:2]),
+		algorithm:  data[2],
+		digestType: data[3],
+		digest:     data[4:],
+	}, nil
+}
+
+// readWireName reads an uncompressed wire format name, returning it in
+// presentation format along with the number of bytes consumed
+func readWireName(data []byte) (string, int, error) {
+	var labels []string
+	offset := 0
+	for {
+		if offset >= len(data) {
+			return "", 0, fmt.Errorf("truncated name")
+		}
+		length := int(data[offset])
+		offset++
+		if length == 0 {
+			break
+		}
+		if length > 63 {
+			return "", 0, fmt.Errorf("compressed or invalid label")
+		}
+		if offset+length > len(data) {
+			return "", 0, fmt.Errorf("truncated label")
+		}
+		labels = append(labels, string(data[offset:offset+length]))
+		offset += length
+	}
+	return strings.Join(labels, ".") + ".", offset, nil
+}
+
+// canonicalName lowercases a name and makes it fully qualified
+func canonicalName(name string) string {
+	return strings.ToLower(fqdn(name))
+}
+
+// nameLabels splits a name into labels, returning none for the root
+func nameLabels(name string) []string {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return nil
+	}
+	return strings.Split(name, ".")
+}
+
+// parentName returns the name with its first label removed
+func parentName(name string) string {
+	labels := nameLabels(name)
+	if len(labels) <= 1 {
+		return "."
+	}
+	return strings.Join(labels[1:], ".") + "."
+}
+
+// isSubdomain reports whether child is equal to or below parent
+func isSubdomain(child, parent string) bool {
+	child, parent = canonicalName(child), canonicalName(parent)
+	return parent == "." || child == parent || strings.HasSuffix(child, "."+parent)
+}
+
+// canonicalNameWire encodes a name in lowercase uncompressed wire format (RFC 4034 section 6.2)
+func canonicalNameWire(name string) []byte {
+	var wire []byte
+	for _, label := range nameLabels(canonicalName(name)) {
+		wire = append(wire, byte(len(label)))
+		wire = append(wire, label...)
+	}
+	return append(wire, 0)
+}
+
+// canonicalRdata encodes record data in canonical wire format
+func canonicalRdata(body dnsmessage.ResourceBody) ([]byte, error) {
+	var data []byte
+	switch rb := body.(type) {
+	case *dnsmessage.AResource:
+		data = append(data, rb.A[:]...)
+	case *dnsmessage.AAAAResource:
+		data = append(data, rb.AAAA[:]...)
+	case *dnsmessage.NSResource:
+		data = canonicalNameWire(rb.NS.String())
+	case *dnsmessage.CNAMEResource:
+		data = canonicalNameWire(rb.CNAME.String())
+	case *dnsmessage.PTRResource:
+		data = canonicalNameWire(rb.PTR.String())
+	case *dnsmessage.MXResource:
+		data = binary.BigEndian.AppendUint16(data, rb.Pref)
+		data = append(data, canonicalNameWire(rb.MX.String())...)
+	case *dnsmessage.SOAResource:
+		data = append(canonicalNameWire(rb.NS.String()), canonicalNameWire(rb.MBox.String())...)
+		for _, value := range []uint32{rb.Serial, rb.Refresh, rb.Retry, rb.Expire, rb.MinTTL} {
+			data = binary.BigEndian.AppendUint32(data, value)
+		}
+	case *dnsmessage.TXTResource:
+		for _, txt := range rb.TXT {
+			if len(txt) > 255 {
+				return nil, fmt.Errorf("TXT string too long")
+			}
+			data = append(data, byte(len(txt)))
+			data = append(data, txt...)
+		}
+	case *dnsmessage.SRVResource:
+		data = binary.BigEndian.AppendUint16(data, rb.Priority)
+		data = binary.BigEndian.AppendUint16(data, rb.Weight)
+		data = binary.BigEndian.AppendUint16(data, rb.Port)
+		data = append(data, canonicalNameWire(rb.Target.String())...)
+	case *dnsmessage.UnknownResource:
+		data = append(data, rb.Data...)
+	default:
+		return nil, fmt.Errorf("cannot canonicalize %T", body)
+	}
+	return data, nil
+}
+
+// rrsetSignedData builds the data covered by an RRSIG over the RRset (RFC 4034 section 3.1.8.1)
+func rrsetSignedData(sig *rrsigRecord, rrset []dnsmessage.Resource) ([]byte, error) {
+	owner := canonicalName(rrset[0].Header.Name.String())
+
+	// Wildcard expansions are signed with the wildcard owner name
+	if labels := nameLabels(owner); int(sig.labels) < len(labels) {
+		owner = "*." + strings.Join(labels[len(labels)-int(sig.labels):], ".") + "."
+	}
+	ownerWire := canonicalNameWire(owner)
+
+	var rdatas [][]byte
+	for _, rr := range rrset {
+		rdata, err := canonicalRdata(rr.Body)
+		if err != nil {
+			return nil, err
+		}
+		rdatas = append(rdatas, rdata)
+	}
+	sort.Slice(rdatas, func(i, j int) bool { return bytes.Compare(rdatas[i], rdatas[j]) < 0 })
+
+	data := append([]byte{}, sig.signedData...)
+	for i, rdata := range rdatas {
+		// Duplicate records are only signed once
+		if i > 0 && bytes.Equal(rdata, rdatas[i-1]) {
+			continue
+		}
+		data = append(data, ownerWire...)
+		data = binary.BigEndian.AppendUint16(data, uint16(rrset[0].Header.Type))
+		data = binary.BigEndian.AppendUint16(data, uint16(rrset[0].Header.Class))
+		data = binary.BigEndian.AppendUint32(data, sig.originalTTL)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(rdata)))
+		data = append(data, rdata...)
+	}
+	return data, nil
+}
+
+// verifySignature checks an RRSIG signature over data with the given key
+func verifySignature(sig *rrsigRecord, key *dnskeyRecord, data []byte) error {
+	switch sig.algorithm {
+	case dnssecAlgRSASHA1, dnssecAlgRSASHA1NSEC3, dnssecAlgRSASHA256, dnssecAlgRSASHA512:
+		pub, err := parseRSAPublicKey(key.publicKey)
+		if err != nil {
+			return err
+		}
+		hash := crypto.SHA256
+		switch sig.algorithm {
+		case dnssecAlgRSASHA1, dnssecAlgRSASHA1NSEC3:
+			hash = crypto.SHA1
+		case dnssecAlgRSASHA512:
+			hash = crypto.SHA512
+		}
+		h := hash.New()
+		h.Write(data)
+		return rsa.VerifyPKCS1v15(pub, hash, h.Sum(nil), sig.signature)
+
+	case dnssecAlgECDSAP256SHA256, dnssecAlgECDSAP384SHA384:
+		curve, size, hash := elliptic.P256(), 32, crypto.SHA256
+		if sig.algorithm == dnssecAlgECDSAP384SHA384 {
+			curve, size, hash = elliptic.P384(), 48, crypto.SHA384
+		}
+		if len(key.publicKey) != 2*size || len(sig.signature) != 2*size {
+			return fmt.Errorf("invalid ECDSA key or signature length")
+		}
+		pub := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(key.publicKey[:size]),
+			Y:     new(big.Int).SetBytes(key.publicKey[size:]),
+		}
+		h := hash.New()
+		h.Write(data)
+		r := new(big.Int).SetBytes(sig.signature[:size])
+		s := new(big.Int).SetBytes(sig.signature[size:])
+		if !ecdsa.Verify(pub, h.Sum(nil), r, s) {
+			return fmt.Errorf("ECDSA signature mismatch")
+		}
+		return nil
+
+	case dnssecAlgED25519:
+		if len(key.publicKey) != ed25519.PublicKeySize {
+			return fmt.Errorf("invalid Ed25519 key length")
+		}
+		if !ed25519.Verify(ed25519.PublicKey(key.publicKey), data, sig.signature) {
+			return fmt.Errorf("Ed25519 signature mismatch")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unsupported algorithm %d", sig.algorithm)
+}
+
+// parseRSAPublicKey decodes an RFC 3110 RSA public key
+func parseRSAPublicKey(key []byte) (*rsa.PublicKey, error) {
+	if len(key) < 3 {
+		return nil, fmt.Errorf("RSA key too short")
+	}
+
+	expLen, offset := int(key[0]), 1
+	if expLen == 0 {
+		expLen, offset = int(binary.BigEndian.Uint16(key[1:3])), 3
+	}
+	if expLen == 0 || expLen > 4 || len(key) <= offset+expLen {
+		return nil, fmt.Errorf("unsupported RSA exponent")
+	}
+
+	exponent := 0
+	for _, b := range key[offset : offset+expLen] {
+		exponent = exponent<<8 | int(b)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(key[offset+expLen:]),
+		E: exponent,
+	}, nil
+}
+
+// dsDigest computes the digest a DS record holds for the key (RFC 4034 section 5.1.4)
+func dsDigest(key *dnskeyRecord, digestType uint8) ([]byte, error) {
+	data := append(canonicalNameWire(key.owner), key.rdata...)
+	switch digestType {
+	case dsDigestSHA1:
+		sum := sha1.Sum(data)
+		return sum[:], nil
+	case dsDigestSHA256:
+		sum := sha256.Sum256(data)
+		return sum[:], nil
+	case dsDigestSHA384:
+		sum := sha512.Sum384(data)
+		return sum[:], nil
+	}
+	return nil, fmt.Errorf("unsupported digest type %d", digestType)
+}
+
+// matchesDS reports whether the key is the one the DS record refers to
+func (k *dnskeyRecord) matchesDS(ds *dsRecord) bool {
+	if k.keyTag != ds.keyTag || k.algorithm != ds.algorithm || !canonicalNameEqual(k.owner, ds.owner) {
+		return false
+	}
+	digest, err := dsDigest(k, ds.digestType)
+	return err == nil && bytes.Equal(digest, ds.digest)
+}
+
+func canonicalNameEqual(a, b string) bool {
+	return canonicalName(a) == canonicalName(b)
+}
+
+// signatureTime converts an RRSIG timestamp to a time using serial number
+// arithmetic relative to now (RFC 4034 section 3.1.5)
+func signatureTime(value uint32, now time.Time) time.Time {
+	delta := int32(value - uint32(now.Unix()))
+	return now.Truncate(time.Second).Add(time.Duration(delta) * time.Second)
+}
+
+// typeBitmapHas reports whether an NSEC/NSEC3 type bitmap includes the type (RFC 4034 section 4.1.2)
+func typeBitmapHas(bitmap []byte, t dnsmessage.Type) bool {
+	window, bit := byte(uint16(t)>>8), uint16(t)&0xFF
+	for len(bitmap) >= 2 {
+		length := int(bitmap[1])
+		if length == 0 || len(bitmap) < 2+length {
+			return false
+		}
+		if bitmap[0] == window {
+			index := int(bit / 8)
+			return index < length && bitmap[2+index]&(0x80>>(bit%8)) != 0
+		}
+		bitmap = bitmap[2+length:]
+	}
+	return false
+}
+
+// nsecTypeBitmap returns the type bitmap of NSEC record data
+func nsecTypeBitmap(data []byte) ([]byte, error) {
+	_, n, err := readWireName(data)
+	if err != nil {
+		return nil, err
+	}
+	return data[n:], nil
+}
+
+// nsec3FlagOptOut marks an NSEC3 record that may cover unsigned delegations
+const nsec3FlagOptOut = 0x01
+
+// nsec3Record holds the fields of an NSEC3 record needed to match a name
+type nsec3Record struct {
+	hashAlgorithm uint8
+	flags         uint8
+	iterations    uint16
+	salt          []byte
+	nextHash      string // Next hashed owner name, base32hex in lower case
+	bitmap        []byte
+}
+
+func parseNSEC3(data []byte) (*nsec3Record, error) {
+	if len(data) < 5 {
+		return nil, fmt.Errorf("NSEC3 too short")
+	}
+	saltLen := int(data[4])
+	if len(data) < 6+saltLen {
+		return nil, fmt.Errorf("NSEC3 truncated")
+	}
+	hashLen := int(data[5+saltLen])
+	if len(data) < 6+saltLen+hashLen {
+		return nil, fmt.Errorf("NSEC3 truncated")
+	}
+	return &nsec3Record{
+		hashAlgorithm: data[0],
+		flags:         data[1],
+		iterations:    binary.BigEndian.Uint16(data[2:4]),
+		salt:          data[5 : 5+saltLen],
+		nextHash:      strings.ToLower(nsec3Encoding.EncodeToString(data[6+saltLen : 6+saltLen+hashLen])),
+		bitmap:        data[6+saltLen+hashLen:],
+	}, nil
+}
+
+var nsec3Encoding = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// nsec3Covers reports whether hash falls strictly between the owner hash and
+// the next hash, wrapping around after the last record of the zone. Base32hex
+// keeps the order of the hashes.
+func nsec3Covers(ownerHash, nextHash, hash string) bool {
+	if ownerHash < nextHash {
+		return ownerHash < hash && hash < nextHash
+	}
+	return hash > ownerHash || hash < nextHash
+}
+
+// nsec3Hash computes the hashed owner label of a name (RFC 5155 section 5)
+func nsec3Hash(name string, record *nsec3Record) (string, error) {
+	if record.hashAlgorithm != 1 {
+		return "", fmt.Errorf("unsupported NSEC3 hash algorithm %d", record.hashAlgorithm)
+	}
+	sum := sha1.Sum(append(canonicalNameWire(name), record.salt...))
+	for i := 0; i < int(record.iterations); i++ {
+		sum = sha1.Sum(append(sum[:], record.salt...))
+	}
+	return strings.ToLower(nsec3Encoding.EncodeToString(sum[:])), nil
+}
@@ -2,10 +2,15 @@
 package operations
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
+	neturl "net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"service-operation/types"
@@ -25,7 +30,48 @@ func NewHTTPOperation(timeout time.Duration) *HTTPOperation {
 	}
 }
 
+// HTTPRequestOptions customizes the request sent by an HTTP check
+type HTTPRequestOptions struct {
+	Method             string
+	Headers            map[string]string
+	Body               string
+	ContentType        string
+	AuthType           string // "basic" or "bearer"
+	Username           string
+	Password           string
+	BearerToken        string
+	QueryParams        map[string]string
+	InsecureSkipVerify bool
+	RedirectPolicy     string // "follow" (default) or "none"
+	MaxRedirects       int    // Hop limit when following, defaults to 10
+}
+
+// HTTPRequestOptionsFromRequest extracts the HTTP request customization of an operation request
+func HTTPRequestOptionsFromRequest(req types.OperationRequest) HTTPRequestOptions {
+	return HTTPRequestOptions{
+		Method:             req.Method,
+		Headers:            req.Headers,
+		Body:               req.Body,
+		ContentType:        req.ContentType,
+		AuthType:           req.AuthType,
+		Username:           req.Username,
+		Password:           req.Password,
+		BearerToken:        req.BearerToken,
+		QueryParams:        req.QueryParams,
+		InsecureSkipVerify: req.InsecureSkipVerify,
+		RedirectPolicy:     req.RedirectPolicy,
+		MaxRedirects:       req.MaxRedirects,
+	}
+}
+
 func (h *HTTPOperation) Execute(url, method string) (*types.OperationResult, error) {
+	return h.ExecuteWithOptions(url, HTTPRequestOptions{Method: method})
+}
+
+// ExecuteWithOptions performs the HTTP check with custom headers, body, auth,
+// query parameters, TLS settings and redirect policy
+func (h *HTTPOperation) ExecuteWithOptions(url string, opts HTTPRequestOptions) (*types.OperationResult, error) {
+	method := strings.ToUpper(opts.Method)
 	result := &types.OperationResult{
 		Type:       types.OperationHTTP,
 		StartTime:  time.Now(),
@@ -43,27 +89,69 @@ func (h *HTTPOperation) Execute(url, method string) (*types.OperationResult, err
 		url = "https://" + url
 	}
 
+	if len(opts.QueryParams) > 0 {
+		withQuery, err := addQueryParams(url, opts.QueryParams)
+		if err != nil {
+			result.Error = fmt.Sprintf("Failed to build request URL: %v", err)
+			result.Success = false
+			result.EndTime = time.Now()
+			return result, nil
+		}
+		url = withQuery
+	}
+
 	start := time.Now()
 
-	req, err := http.NewRequest(method, url, nil)
+	var requestBody io.Reader
+	if opts.Body != "" {
+		requestBody = strings.NewReader(opts.Body)
+	}
+
+	req, err := http.NewRequest(method, url, requestBody)
 	if err != nil {
 		result.Error = fmt.Sprintf("Failed to create request: %v", err)
 		result.Success = false
 		result.EndTime = time.Now()
 		return result, nil
 	}
+	result.Host = req.URL.Hostname()
 
-	// Set a user agent
+	// Set a user agent, custom headers may override it
 	req.Header.Set("User-Agent", "ServiceOperation/1.0")
+	if opts.ContentType != "" {
+		req.Header.Set("Content-Type", opts.ContentType)
+	}
+	for key, value := range opts.Headers {
+		if strings.EqualFold(key, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+
+	switch strings.ToLower(opts.AuthType) {
+	case "basic":
+		req.SetBasicAuth(opts.Username, opts.Password)
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+opts.BearerToken)
+	}
+
+	// Trace the request phases for the timing breakdown
+	timing := &httpTimingTrace{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timing.clientTrace()))
 
-	resp, err := h.client.Do(req)
+	redirects := newRedirectRecorder(strings.ToLower(opts.RedirectPolicy), opts.MaxRedirects, start)
+	resp, err := h.clientFor(opts, redirects.checkRedirect).Do(req)
 	
 	result.ResponseTime = time.Since(start)
 	result.EndTime = time.Now()
+	result.RedirectChain = redirects.finish(resp, err, result.EndTime)
 
 	if err != nil {
 		// More detailed error messages
-		if strings.Contains(err.Error(), "timeout") {
+		if errors.Is(err, errTooManyRedirects) {
+			result.Error = fmt.Sprintf("↩️ Too many redirects - Stopped after %d hops", redirects.maxHops)
+		} else if strings.Contains(err.Error(), "timeout") {
 			result.Error = fmt.Sprintf("🕐 Request timeout after %.2fs - Server did not respond within the expected time", h.timeout.Seconds())
 		} else if strings.Contains(err.Error(), "connection refused") {
 			result.Error = "🚫 Connection refused - Server is not accepting connections on this port"
@@ -75,11 +163,13 @@ func (h *HTTPOperation) Execute(url, method string) (*types.OperationResult, err
 			result.Error = fmt.Sprintf("🔌 Connection error: %v", err)
 		}
 		result.Success = false
+		timing.apply(result, time.Time{})
 		return result, nil
 	}
 	defer resp.Body.Close()
 
 	result.HTTPStatusCode = resp.StatusCode
+	result.FinalURL = resp.Request.URL.String()
 	result.ContentLength = resp.ContentLength
 	result.Success = resp.StatusCode >= 200 && resp.StatusCode < 400
 
@@ -96,6 +186,7 @@ func (h *HTTPOperation) Execute(url, method string) (*types.OperationResult, err
 
 	// Read response body for keyword checking and additional details
 	body, err := io.ReadAll(resp.Body)
+	timing.apply(result, time.Now())
 	if err == nil && len(body) > 0 {
 		result.ResponseBody = string(body)
 		// Update content length if not set by server
@@ -104,33 +195,78 @@ func (h *HTTPOperation) Execute(url, method string) (*types.OperationResult, err
 		}
 	}
 
-	// Create detailed status message with emoji
-	if !result.Success {
+	result.Error = httpStatusMessage(resp.StatusCode, resp.Status, result.Success)
+
+	return result, nil
+}
+
+// httpStatusMessage creates the detailed status message with emoji for a response
+func httpStatusMessage(statusCode int, status string, success bool) string {
+	if !success {
 		switch {
-		case resp.StatusCode >= 500:
-			result.Error = fmt.Sprintf("🔥 Server Error (HTTP %d): %s - The server encountered an internal error", resp.StatusCode, resp.Status)
-		case resp.StatusCode >= 400:
-			result.Error = fmt.Sprintf("❌ Client Error (HTTP %d): %s - The request was invalid or unauthorized", resp.StatusCode, resp.Status)
-		case resp.StatusCode >= 300:
-			result.Error = fmt.Sprintf("↩️ Redirect (HTTP %d): %s - Resource has moved", resp.StatusCode, resp.Status)
-		default:
-			result.Error = fmt.Sprintf("⚠️ Unexpected Status (HTTP %d): %s", resp.StatusCode, resp.Status)
-		}
-	} else {
-		// Success message with emoji
-		switch resp.StatusCode {
-		case 200:
-			result.Error = "✅ OK - Request successful"
-		case 201:
-			result.Error = "🆕 Created - Resource created successfully"
-		case 202:
-			result.Error = "⏳ Accepted - Request accepted for processing"
-		case 204:
-			result.Error = "📭 No Content - Request successful, no content returned"
+		case statusCode >= 500:
+			return fmt.Sprintf("🔥 Server Error (HTTP %d): %s - The server encountered an internal error", statusCode, status)
+		case statusCode >= 400:
+			return fmt.Sprintf("❌ Client Error (HTTP %d): %s - The request was invalid or unauthorized", statusCode, status)
+		case statusCode >= 300:
+			return fmt.Sprintf("↩️ Redirect (HTTP %d): %s - Resource has moved", statusCode, status)
 		default:
-			result.Error = fmt.Sprintf("✅ Success (HTTP %d): %s", resp.StatusCode, resp.Status)
+			return fmt.Sprintf("⚠️ Unexpected Status (HTTP %d): %s", statusCode, status)
 		}
 	}
 
-	return result, nil
-}
\ No newline at end of file
+	// Success message with emoji
+	switch statusCode {
+	case 200:
+		return "✅ OK - Request successful"
+	case 201:
+		return "🆕 Created - Resource created successfully"
+	case 202:
+		return "⏳ Accepted - Request accepted for processing"
+	case 204:
+		return "📭 No Content - Request successful, no content returned"
+	default:
+		return fmt.Sprintf("✅ Success (HTTP %d): %s", statusCode, status)
+	}
+}
+
+// Checks that skip TLS verification share one transport and its connection pool
+var (
+	insecureTransportOnce sync.Once
+	insecureTransport     *http.Transport
+)
+
+// clientFor returns a client for the check, using the shared insecure
+// transport when TLS verification is skipped
+func (h *HTTPOperation) clientFor(opts HTTPRequestOptions, checkRedirect func(*http.Request, []*http.Request) error) *http.Client {
+	transport := h.client.Transport
+	if opts.InsecureSkipVerify {
+		insecureTransportOnce.Do(func() {
+			insecureTransport = http.DefaultTransport.(*http.Transport).Clone()
+			insecureTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		})
+		transport = insecureTransport
+	}
+
+	return &http.Client{
+		Timeout:       h.timeout,
+		Transport:     transport,
+		CheckRedirect: checkRedirect,
+	}
+}
+
+// addQueryParams merges additional query parameters into the URL
+func addQueryParams(rawURL string, params map[string]string) (string, error) {
+	parsed, err := neturl.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := parsed.Query()
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	parsed.RawQuery = query.Encode()
+
+	return parsed.String(), nil
+}
@@ -0,0 +1,113 @@
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"service-operation/types"
+)
+
+// Redirect policies supported by HTTP checks
+const (
+	RedirectPolicyFollow = "follow"
+	RedirectPolicyNone   = "none"
+)
+
+// defaultMaxRedirects matches the limit of the default http.Client
+const defaultMaxRedirects = 10
+
+var errTooManyRedirects = errors.New("too many redirects")
+
+// redirectRecorder applies the redirect policy of a check and records each hop
+type redirectRecorder struct {
+	policy   string
+	maxHops  int
+	hopStart time.Time
+	chain    []types.RedirectHop
+}
+
+func newRedirectRecorder(policy string, maxHops int, start time.Time) *redirectRecorder {
+	if maxHops <= 0 {
+		maxHops = defaultMaxRedirects
+	}
+	return &redirectRecorder{
+		policy:   policy,
+		maxHops:  maxHops,
+		hopStart: start,
+	}
+}
+
+// checkRedirect is used as http.Client.CheckRedirect
+func (r *redirectRecorder) checkRedirect(req *http.Request, via []*http.Request) error {
+	now := time.Now()
+	previous := via[len(via)-1]
+
+	hop := types.RedirectHop{
+		URL:     previous.URL.String(),
+		Latency: now.Sub(r.hopStart),
+	}
+	if req.Response != nil {
+		hop.StatusCode = req.Response.StatusCode
+	}
+	r.chain = append(r.chain, hop)
+	r.hopStart = now
+
+	if r.policy == RedirectPolicyNone {
+		return http.ErrUseLastResponse
+	}
+	if len(via) > r.maxHops {
+		return fmt.Errorf("%w: stopped after %d redirects at %s", errTooManyRedirects, r.maxHops, req.URL)
+	}
+	return nil
+}
+
+// finish records the final hop and returns the full chain
+func (r *redirectRecorder) finish(resp *http.Response, err error, end time.Time) []types.RedirectHop {
+	if err != nil || resp == nil || resp.Request == nil {
+		return r.chain
+	}
+
+	// With the "none" policy the redirect response itself is the final hop
+	if r.policy == RedirectPolicyNone && len(r.chain) > 0 {
+		return r.chain
+	}
+
+	return append(r.chain, types.RedirectHop{
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Latency:    end.Sub(r.hopStart),
+	})
+}
+
+// CheckFinalURL asserts that the URL the check ended on matches the expected
+// pattern, catching broken or hijacked redirects such as a missing HTTP to HTTPS hop
+func (h *HTTPOperation) CheckFinalURL(result *types.OperationResult, pattern string) {
+	if result == nil || pattern == "" || result.HTTPStatusCode == 0 {
+		return
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		result.Success = false
+		result.Error = fmt.Sprintf("⚠️ Final URL pattern error: %v", err)
+		return
+	}
+
+	if re.MatchString(result.FinalURL) || !result.Success {
+		return
+	}
+
+	result.Success = false
+	result.Error = fmt.Sprintf("↩️ Redirect check failed (HTTP %d): final URL %s does not match %s after %d redirect(s)",
+		result.HTTPStatusCode, result.FinalURL, pattern, redirectCount(result.RedirectChain))
+}
+
+func redirectCount(chain []types.RedirectHop) int {
+	if len(chain) == 0 {
+		return 0
+	}
+	return len(chain) - 1
+}
@@ -0,0 +1,95 @@
+package operations
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"service-operation/types"
+)
+
+// httpTimingTrace collects per-phase timings of an HTTP request through httptrace.
+// When redirects are followed the phases of the last connection win.
+type httpTimingTrace struct {
+	mu sync.Mutex
+
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	reusedConn   bool
+}
+
+func (t *httpTimingTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mark(&t.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mark(&t.dnsDone)
+		},
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// Only keep the first dial attempt when several addresses are raced
+			if t.connectStart.IsZero() || !t.connectDone.IsZero() {
+				t.connectStart = time.Now()
+				t.connectDone = time.Time{}
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err == nil {
+				t.mark(&t.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mark(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mark(&t.tlsDone)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reusedConn = info.Reused
+			t.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mark(&t.wroteRequest)
+		},
+		GotFirstResponseByte: func() {
+			t.mark(&t.firstByte)
+		},
+	}
+}
+
+func (t *httpTimingTrace) mark(field *time.Time) {
+	t.mu.Lock()
+	*field = time.Now()
+	t.mu.Unlock()
+}
+
+// apply copies the collected phase durations into the result. Content transfer
+// is measured from the first response byte until the body was fully read.
+func (t *httpTimingTrace) apply(result *types.OperationResult, bodyDone time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result.DNSLookupTime = phaseDuration(t.dnsStart, t.dnsDone)
+	result.TCPConnectTime = phaseDuration(t.connectStart, t.connectDone)
+	result.TLSHandshakeTime = phaseDuration(t.tlsStart, t.tlsDone)
+	result.TimeToFirstByte = phaseDuration(t.wroteRequest, t.firstByte)
+	result.ContentTransferTime = phaseDuration(t.firstByte, bodyDone)
+	result.ConnectionReused = t.reusedConn
+}
+
+func phaseDuration(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
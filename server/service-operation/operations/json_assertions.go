@@ -0,0 +1,396 @@
+package operations
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"service-operation/types"
+)
+
+// Supported comparison operators, longest first so "<=" wins over "<"
+var jsonAssertionOperators = []string{"==", "!=", "<=", ">=", "=~", "<", ">", " contains "}
+
+// JSONAssertion is a parsed JSONPath-style assertion such as `$.queue.depth < 1000`.
+// An assertion with only a path checks that the path exists.
+type JSONAssertion struct {
+	Expression string
+	Path       []interface{} // string keys and int indexes
+	Operator   string
+	Expected   interface{}
+}
+
+// ParseJSONAssertion parses an assertion expression
+func ParseJSONAssertion(expression string) (*JSONAssertion, error) {
+	expression = strings.TrimSpace(expression)
+	assertion := &JSONAssertion{Expression: expression}
+
+	pathPart := expression
+	if idx, op := findAssertionOperator(expression); idx >= 0 {
+		pathPart = strings.TrimSpace(expression[:idx])
+		assertion.Operator = strings.TrimSpace(op)
+
+		expected, err := parseExpectedValue(strings.TrimSpace(expression[idx+len(op):]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid assertion %q: %v", expression, err)
+		}
+		assertion.Expected = expected
+	}
+
+	path, err := parseJSONPath(pathPart)
+	if err != nil {
+		return nil, fmt.Errorf("invalid assertion %q: %v", expression, err)
+	}
+	assertion.Path = path
+
+	if assertion.Operator == "=~" {
+		pattern, ok := assertion.Expected.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid assertion %q: regex must be a string", expression)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid assertion %q: %v", expression, err)
+		}
+	}
+
+	return assertion, nil
+}
+
+// findAssertionOperator finds the leftmost operator outside of quoted strings
+// and brackets, so operators inside the expected value are left alone
+func findAssertionOperator(s string) (int, string) {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']' && depth > 0:
+			depth--
+		case depth == 0:
+			for _, op := range jsonAssertionOperators {
+				if strings.HasPrefix(s[i:], op) {
+					return i, op
+				}
+			}
+		}
+	}
+	return -1, ""
+}
+
+// parseExpectedValue decodes the right hand side as a JSON literal, falling
+// back to a bare string for unquoted values
+func parseExpectedValue(raw string) (interface{}, error) {
+	if raw == "" {
+		return nil, fmt.Errorf("missing expected value")
+	}
+	if strings.HasPrefix(raw, "'") && strings.HasSuffix(raw, "'") && len(raw) >= 2 {
+		return raw[1 : len(raw)-1], nil
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err == nil && !decoder.More() {
+		return normalizeJSONValue(value), nil
+	}
+	if strings.HasPrefix(raw, "\"") {
+		return nil, fmt.Errorf("malformed string %s", raw)
+	}
+	return raw, nil
+}
+
+// parseJSONPath parses `$.a.b[0]['c d']` into its keys and indexes
+func parseJSONPath(path string) ([]interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path must start with $")
+	}
+
+	var parts []interface{}
+	rest := path[1:]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in path %s", path)
+			}
+			parts = append(parts, rest[:end])
+			rest = rest[end:]
+
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed bracket in path %s", path)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				parts = append(parts, inner[1:len(inner)-1])
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid index [%s] in path %s", inner, path)
+			}
+			parts = append(parts, index)
+
+		default:
+			return nil, fmt.Errorf("unexpected %q in path %s", rest[0], path)
+		}
+	}
+
+	return parts, nil
+}
+
+// Evaluate runs the assertion against a decoded JSON document
+func (a *JSONAssertion) Evaluate(document interface{}) types.AssertionResult {
+	result := types.AssertionResult{Expression: a.Expression}
+
+	actual, found := lookupJSONPath(document, a.Path)
+	if found {
+		result.Actual = formatJSONValue(actual)
+	}
+
+	if a.Operator == "" {
+		result.Passed = found
+		if !found {
+			result.Error = "path not found"
+		}
+		return result
+	}
+
+	if !found {
+		result.Error = "path not found"
+		return result
+	}
+
+	passed, err := compareJSONValues(actual, a.Operator, a.Expected)
+	result.Passed = passed
+	if err != nil {
+		result.Error = err.Error()
+	} else if !passed {
+		result.Error = fmt.Sprintf("expected %s %s, got %s", a.Operator, formatJSONValue(a.Expected), result.Actual)
+	}
+
+	return result
+}
+
+func lookupJSONPath(document interface{}, path []interface{}) (interface{}, bool) {
+	current := document
+	for _, part := range path {
+		switch key := part.(type) {
+		case string:
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			value, exists := object[key]
+			if !exists {
+				return nil, false
+			}
+			current = value
+		case int:
+			array, ok := current.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			if key < 0 {
+				key += len(array)
+			}
+			if key < 0 || key >= len(array) {
+				return nil, false
+			}
+			current = array[key]
+		}
+	}
+	return current, true
+}
+
+func compareJSONValues(actual interface{}, operator string, expected interface{}) (bool, error) {
+	switch operator {
+	case "==":
+		return jsonValuesEqual(actual, expected), nil
+	case "!=":
+		return !jsonValuesEqual(actual, expected), nil
+	case "=~":
+		str, ok := actual.(string)
+		if !ok {
+			return false, fmt.Errorf("type mismatch: %s is not a string", jsonTypeName(actual))
+		}
+		return regexp.MustCompile(expected.(string)).MatchString(str), nil
+	case "contains":
+		switch value := actual.(type) {
+		case string:
+			needle, ok := expected.(string)
+			if !ok {
+				return false, fmt.Errorf("type mismatch: cannot search a string for %s", jsonTypeName(expected))
+			}
+			return strings.Contains(value, needle), nil
+		case []interface{}:
+			for _, item := range value {
+				if jsonValuesEqual(item, expected) {
+					return true, nil
+				}
+			}
+			return false, nil
+		default:
+			return false, fmt.Errorf("type mismatch: contains needs a string or array, got %s", jsonTypeName(actual))
+		}
+	}
+
+	// Ordering comparisons need two numbers or two strings
+	var cmp int
+	switch a := actual.(type) {
+	case float64:
+		b, ok := expected.(float64)
+		if !ok {
+			return false, fmt.Errorf("type mismatch: cannot compare number with %s", jsonTypeName(expected))
+		}
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		}
+	case string:
+		b, ok := expected.(string)
+		if !ok {
+			return false, fmt.Errorf("type mismatch: cannot compare string with %s", jsonTypeName(expected))
+		}
+		cmp = strings.Compare(a, b)
+	default:
+		return false, fmt.Errorf("type mismatch: %s cannot be ordered", jsonTypeName(actual))
+	}
+
+	switch operator {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+	return false, fmt.Errorf("unsupported operator %s", operator)
+}
+
+func jsonValuesEqual(a, b interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(aJSON, bJSON)
+}
+
+// normalizeJSONValue converts json.Number values to float64 for typed comparisons
+func normalizeJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return v.String()
+		}
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeJSONValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeJSONValue(item)
+		}
+	}
+	return value
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func formatJSONValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	formatted := string(data)
+	if len(formatted) > 100 {
+		formatted = formatted[:100] + "..."
+	}
+	return formatted
+}
+
+// CheckJSONAssertions evaluates JSON assertions against the captured response
+// body, recording each result and marking the check failed if any assertion fails
+func (h *HTTPOperation) CheckJSONAssertions(result *types.OperationResult, expressions []string) {
+	if result == nil || len(expressions) == 0 {
+		return
+	}
+
+	var document interface{}
+	decoder := json.NewDecoder(strings.NewReader(result.ResponseBody))
+	decoder.UseNumber()
+	decodeErr := decoder.Decode(&document)
+	document = normalizeJSONValue(document)
+
+	var failed []string
+	for _, expression := range expressions {
+		if strings.TrimSpace(expression) == "" {
+			continue
+		}
+
+		var assertionResult types.AssertionResult
+		assertion, err := ParseJSONAssertion(expression)
+		switch {
+		case err != nil:
+			assertionResult = types.AssertionResult{Expression: expression, Error: err.Error()}
+		case decodeErr != nil:
+			assertionResult = types.AssertionResult{Expression: expression, Error: "response body is not valid JSON"}
+		default:
+			assertionResult = assertion.Evaluate(document)
+		}
+
+		result.Assertions = append(result.Assertions, assertionResult)
+		if !assertionResult.Passed {
+			failed = append(failed, fmt.Sprintf("%s (%s)", assertionResult.Expression, assertionResult.Error))
+		}
+	}
+
+	// Connection-level failures and earlier check failures keep their error
+	if len(failed) == 0 || result.HTTPStatusCode == 0 || !result.Success {
+		return
+	}
+
+	result.Success = false
+	result.Error = fmt.Sprintf("🧪 JSON assertion failed (HTTP %d): %d of %d failed - %s",
+		result.HTTPStatusCode, len(failed), len(result.Assertions), strings.Join(failed, "; "))
+}
@@ -0,0 +1,101 @@
+package operations
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"service-operation/types"
+)
+
+// Keyword match modes supported by services.keyword_mode
+const (
+	KeywordModeContains    = "contains"
+	KeywordModeNotContains = "not_contains"
+	KeywordModeRegex       = "regex"
+)
+
+// KeywordCheck describes a keyword assertion against an HTTP response body
+type KeywordCheck struct {
+	Keyword    string
+	Mode       string
+	IgnoreCase bool
+}
+
+// Evaluate looks for the keyword in the body and reports whether it was found
+// along with the keyword (or regex match) that should be recorded
+func (k KeywordCheck) Evaluate(body string) (bool, string, error) {
+	switch strings.ToLower(k.Mode) {
+	case "", KeywordModeContains, KeywordModeNotContains:
+		return k.contains(body), k.Keyword, nil
+
+	case KeywordModeRegex:
+		pattern := k.Keyword
+		if k.IgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, k.Keyword, fmt.Errorf("invalid keyword regex %q: %v", k.Keyword, err)
+		}
+		if loc := re.FindStringIndex(body); loc != nil {
+			return true, body[loc[0]:loc[1]], nil
+		}
+		return false, k.Keyword, nil
+
+	default:
+		return false, k.Keyword, fmt.Errorf("unsupported keyword mode %q", k.Mode)
+	}
+}
+
+// Passed reports whether a found/not-found outcome satisfies the check
+func (k KeywordCheck) Passed(found bool) bool {
+	if strings.ToLower(k.Mode) == KeywordModeNotContains {
+		return !found
+	}
+	return found
+}
+
+func (k KeywordCheck) contains(body string) bool {
+	if k.IgnoreCase {
+		return strings.Contains(strings.ToLower(body), strings.ToLower(k.Keyword))
+	}
+	return strings.Contains(body, k.Keyword)
+}
+
+// CheckKeyword applies a keyword assertion to a completed HTTP result, marking
+// it as failed when the assertion does not hold
+func (h *HTTPOperation) CheckKeyword(result *types.OperationResult, check KeywordCheck) {
+	if result == nil || check.Keyword == "" {
+		return
+	}
+
+	found, keyword, err := check.Evaluate(result.ResponseBody)
+	result.Keyword = keyword
+	result.KeywordFound = found
+
+	// Connection-level failures already carry a more useful error
+	if result.HTTPStatusCode == 0 {
+		return
+	}
+
+	if err != nil {
+		result.Success = false
+		result.Error = fmt.Sprintf("⚠️ Keyword check error: %v", err)
+		return
+	}
+
+	if check.Passed(found) || !result.Success {
+		return
+	}
+
+	result.Success = false
+	switch strings.ToLower(check.Mode) {
+	case KeywordModeNotContains:
+		result.Error = fmt.Sprintf("🔍 Keyword check failed (HTTP %d): forbidden keyword \"%s\" found in response body", result.HTTPStatusCode, check.Keyword)
+	case KeywordModeRegex:
+		result.Error = fmt.Sprintf("🔍 Keyword check failed (HTTP %d): response body does not match pattern \"%s\"", result.HTTPStatusCode, check.Keyword)
+	default:
+		result.Error = fmt.Sprintf("🔍 Keyword check failed (HTTP %d): keyword \"%s\" not found in response body", result.HTTPStatusCode, check.Keyword)
+	}
+}
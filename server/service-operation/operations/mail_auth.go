@@ -0,0 +1,394 @@
+package operations
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"service-operation/types"
+)
+
+// Mail authentication checks
+const (
+	MailAuthSPF    = "spf"
+	MailAuthDMARC  = "dmarc"
+	MailAuthDKIM   = "dkim"
+	MailAuthMTASTS = "mta-sts"
+)
+
+// Finding severities; any error marks the audit failed
+const (
+	FindingError   = "error"
+	FindingWarning = "warning"
+	FindingInfo    = "info"
+)
+
+type MailAuthOperation struct {
+	timeout time.Duration
+}
+
+// MailAuthOptions configures a mail authentication audit
+type MailAuthOptions struct {
+	DKIMSelectors []string
+	Nameservers   []string // Empty uses the system resolvers
+	Transport     string   // auto, udp or tcp
+}
+
+func NewMailAuthOperation(timeout time.Duration) *MailAuthOperation {
+	return &MailAuthOperation{timeout: timeout}
+}
+
+func (m *MailAuthOperation) Execute(domain string, selectors []string) (*types.OperationResult, error) {
+	return m.ExecuteWithOptions(domain, MailAuthOptions{DKIMSelectors: selectors})
+}
+
+// ExecuteWithOptions fetches and audits the SPF, DMARC, DKIM and MTA-STS records of a domain
+func (m *MailAuthOperation) ExecuteWithOptions(domain string, opts MailAuthOptions) (*types.OperationResult, error) {
+	domain = strings.TrimSuffix(strings.TrimSpace(domain), ".")
+	if domain == "" {
+		return nil, fmt.Errorf("domain cannot be empty")
+	}
+
+	result := &types.OperationResult{
+		Type:            types.OperationMailAuth,
+		Host:            domain,
+		StartTime:       time.Now(),
+		MailAuthRecords: make(map[string]string),
+	}
+
+	audit := &mailAuthAudit{
+		client: NewDNSClient(m.timeout, opts.Nameservers, opts.Transport),
+		result: result,
+	}
+
+	audit.checkSPF(domain)
+	audit.checkDMARC(domain)
+	audit.checkDKIM(domain, opts.DKIMSelectors)
+	audit.checkMTASTS(domain)
+
+	result.EndTime = time.Now()
+	result.ResponseTime = result.EndTime.Sub(result.StartTime)
+
+	var errors []string
+	for _, finding := range result.MailAuthFindings {
+		if finding.Severity == FindingError {
+			errors = append(errors, fmt.Sprintf("%s: %s", strings.ToUpper(finding.Check), finding.Message))
+		}
+	}
+	result.Success = len(errors) == 0
+	if !result.Success {
+		result.Error = "Mail authentication issues - " + strings.Join(errors, "; ")
+	}
+	result.Details = m.createDetailedMessage(result)
+
+	return result, nil
+}
+
+// mailAuthAudit collects the records and findings of one audit
+type mailAuthAudit struct {
+	client *DNSClient
+	result *types.OperationResult
+}
+
+func (a *mailAuthAudit) add(check, severity, format string, args ...interface{}) {
+	a.result.MailAuthFindings = append(a.result.MailAuthFindings, types.MailAuthFinding{
+		Check:    check,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// lookupTXT returns the TXT strings at name, with no records and no error for
+// names that don't exist
+func (a *mailAuthAudit) lookupTXT(name string) ([]string, error) {
+	resp, err := a.client.Query(name, dnsmessage.TypeTXT)
+	if err != nil {
+		return nil, err
+	}
+
+	rcode := resp.Message.Header.RCode
+	if rcode == dnsmessage.RCodeNameError {
+		return nil, nil
+	}
+	if err := dnsRcodeError(rcode); err != nil {
+		return nil, err
+	}
+	return answerValues(resp.Message.Answers, dnsmessage.TypeTXT), nil
+}
+
+// lookupTagRecord returns the TXT records at name that start with the version tag
+func (a *mailAuthAudit) lookupTagRecord(name, version string) ([]string, error) {
+	txts, err := a.lookupTXT(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []string
+	for _, txt := range txts {
+		tags := parseTagList(txt)
+		if len(tags) > 0 && tags[0].name == "v" && strings.EqualFold(tags[0].value, version) {
+			records = append(records, txt)
+		}
+	}
+	return records, nil
+}
+
+func (a *mailAuthAudit) checkDMARC(domain string) {
+	records, err := a.lookupTagRecord("_dmarc."+domain, "DMARC1")
+	switch {
+	case err != nil:
+		a.add(MailAuthDMARC, FindingError, "lookup of _dmarc.%s failed: %v", domain, err)
+		return
+	case len(records) == 0:
+		a.add(MailAuthDMARC, FindingError, "no DMARC record at _dmarc.%s", domain)
+		return
+	case len(records) > 1:
+		a.add(MailAuthDMARC, FindingError, "%d DMARC records found, receivers will ignore all of them", len(records))
+		return
+	}
+
+	record := records[0]
+	a.result.MailAuthRecords[MailAuthDMARC] = record
+
+	tags := make(map[string]string)
+	for _, tag := range parseTagList(record) {
+		tags[tag.name] = tag.value
+	}
+
+	switch policy := strings.ToLower(tags["p"]); policy {
+	case "reject", "quarantine":
+	case "none":
+		a.add(MailAuthDMARC, FindingWarning, "policy p=none only monitors, spoofed mail is still delivered")
+	case "":
+		a.add(MailAuthDMARC, FindingError, "required policy tag p is missing")
+	default:
+		a.add(MailAuthDMARC, FindingError, "invalid policy p=%s", tags["p"])
+	}
+
+	if sp, ok := tags["sp"]; ok {
+		switch strings.ToLower(sp) {
+		case "reject", "quarantine":
+		case "none":
+			a.add(MailAuthDMARC, FindingWarning, "subdomain policy sp=none leaves subdomains unprotected")
+		default:
+			a.add(MailAuthDMARC, FindingError, "invalid subdomain policy sp=%s", sp)
+		}
+	}
+
+	if pct, ok := tags["pct"]; ok {
+		value, err := strconv.Atoi(pct)
+		switch {
+		case err != nil || value < 0 || value > 100:
+			a.add(MailAuthDMARC, FindingError, "invalid pct=%s", pct)
+		case value < 100:
+			a.add(MailAuthDMARC, FindingWarning, "policy only applies to %d%% of failing mail (pct=%d)", value, value)
+		}
+	}
+
+	for _, alignment := range []string{"adkim", "aspf"} {
+		if value, ok := tags[alignment]; ok && value != "r" && value != "s" {
+			a.add(MailAuthDMARC, FindingError, "invalid %s=%s, expected r or s", alignment, value)
+		}
+	}
+
+	if _, ok := tags["rua"]; !ok {
+		a.add(MailAuthDMARC, FindingInfo, "no rua tag, aggregate reports are not requested")
+	}
+	for _, reportTag := range []string{"rua", "ruf"} {
+		for _, uri := range strings.Split(tags[reportTag], ",") {
+			uri = strings.TrimSpace(uri)
+			if uri != "" && !strings.HasPrefix(strings.ToLower(uri), "mailto:") {
+				a.add(MailAuthDMARC, FindingWarning, "%s URI %q is not a mailto: address", reportTag, uri)
+			}
+		}
+	}
+}
+
+func (a *mailAuthAudit) checkDKIM(domain string, selectors []string) {
+	if len(selectors) == 0 {
+		a.add(MailAuthDKIM, FindingInfo, "no DKIM selectors configured, DKIM keys were not checked")
+		return
+	}
+
+	for _, selector := range selectors {
+		selector = strings.TrimSpace(selector)
+		if selector == "" {
+			continue
+		}
+		name := selector + "._domainkey." + domain
+
+		txts, err := a.lookupTXT(name)
+		if err != nil {
+			a.add(MailAuthDKIM, FindingError, "lookup of %s failed: %v", name, err)
+			continue
+		}
+		if len(txts) == 0 {
+			a.add(MailAuthDKIM, FindingError, "no DKIM key for selector %s", selector)
+			continue
+		}
+
+		record := txts[0]
+		a.result.MailAuthRecords[MailAuthDKIM+":"+selector] = record
+		a.checkDKIMKey(selector, record)
+	}
+}
+
+func (a *mailAuthAudit) checkDKIMKey(selector, record string) {
+	tags := parseTagList(record)
+	values := make(map[string]string)
+	for i, tag := range tags {
+		if tag.name == "v" && i != 0 {
+			a.add(MailAuthDKIM, FindingError, "selector %s: v= must be the first tag", selector)
+		}
+		values[tag.name] = tag.value
+	}
+
+	if version, ok := values["v"]; ok && version != "DKIM1" {
+		a.add(MailAuthDKIM, FindingError, "selector %s: unsupported version v=%s", selector, version)
+	}
+	if strings.Contains(values["t"], "y") {
+		a.add(MailAuthDKIM, FindingWarning, "selector %s is in testing mode (t=y)", selector)
+	}
+
+	publicKey, ok := values["p"]
+	if !ok {
+		a.add(MailAuthDKIM, FindingError, "selector %s: required public key tag p is missing", selector)
+		return
+	}
+	if publicKey == "" {
+		a.add(MailAuthDKIM, FindingError, "selector %s: key has been revoked (empty p=)", selector)
+		return
+	}
+
+	keyData, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(publicKey), ""))
+	if err != nil {
+		a.add(MailAuthDKIM, FindingError, "selector %s: public key is not valid base64", selector)
+		return
+	}
+
+	switch keyType := strings.ToLower(values["k"]); keyType {
+	case "", "rsa":
+		key, err := x509.ParsePKIXPublicKey(keyData)
+		if err != nil {
+			key, err = x509.ParsePKCS1PublicKey(keyData)
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if err != nil || !ok {
+			a.add(MailAuthDKIM, FindingError, "selector %s: invalid RSA public key", selector)
+			return
+		}
+		bits := rsaKey.N.BitLen()
+		switch {
+		case bits < 1024:
+			a.add(MailAuthDKIM, FindingError, "selector %s: %d-bit RSA key is too weak", selector, bits)
+		case bits < 2048:
+			a.add(MailAuthDKIM, FindingWarning, "selector %s: %d-bit RSA key, 2048 bits recommended", selector, bits)
+		}
+	case "ed25519":
+		if len(keyData) != ed25519.PublicKeySize {
+			a.add(MailAuthDKIM, FindingError, "selector %s: invalid Ed25519 public key", selector)
+		}
+	default:
+		a.add(MailAuthDKIM, FindingError, "selector %s: unsupported key type k=%s", selector, keyType)
+	}
+}
+
+var mtaSTSIDPattern = regexp.MustCompile(`^[A-Za-z0-9]{1,32}$`)
+
+func (a *mailAuthAudit) checkMTASTS(domain string) {
+	records, err := a.lookupTagRecord("_mta-sts."+domain, "STSv1")
+	switch {
+	case err != nil:
+		a.add(MailAuthMTASTS, FindingWarning, "lookup of _mta-sts.%s failed: %v", domain, err)
+		return
+	case len(records) == 0:
+		a.add(MailAuthMTASTS, FindingInfo, "no MTA-STS record, inbound TLS is not enforced")
+		return
+	case len(records) > 1:
+		a.add(MailAuthMTASTS, FindingError, "%d MTA-STS records found, senders will ignore all of them", len(records))
+		return
+	}
+
+	record := records[0]
+	a.result.MailAuthRecords[MailAuthMTASTS] = record
+
+	id := ""
+	for _, tag := range parseTagList(record) {
+		if tag.name == "id" {
+			id = tag.value
+		}
+	}
+	if !mtaSTSIDPattern.MatchString(id) {
+		a.add(MailAuthMTASTS, FindingError, "invalid or missing policy id %q", id)
+	}
+}
+
+type recordTag struct {
+	name  string
+	value string
+}
+
+// parseTagList parses "tag=value; tag=value" records used by DMARC, DKIM and MTA-STS
+func parseTagList(record string) []recordTag {
+	var tags []recordTag
+	for _, part := range strings.Split(record, ";") {
+		name, value, found := strings.Cut(part, "=")
+		if !found {
+			continue
+		}
+		tags = append(tags, recordTag{
+			name:  strings.ToLower(strings.TrimSpace(name)),
+			value: strings.TrimSpace(value),
+		})
+	}
+	return tags
+}
+
+func (m *MailAuthOperation) createDetailedMessage(result *types.OperationResult) string {
+	var details strings.Builder
+
+	counts := make(map[string]int)
+	for _, finding := range result.MailAuthFindings {
+		counts[finding.Severity]++
+	}
+
+	if result.Success {
+		details.WriteString(fmt.Sprintf("📧 MAIL AUTH OK - %s", result.Host))
+	} else {
+		details.WriteString(fmt.Sprintf("❌ MAIL AUTH ISSUES - %s", result.Host))
+	}
+
+	if _, ok := result.MailAuthRecords[MailAuthSPF]; ok {
+		details.WriteString(fmt.Sprintf(" | SPF: %d/%d lookups", result.SPFLookups, spfLookupLimit))
+	}
+	if dmarc, ok := result.MailAuthRecords[MailAuthDMARC]; ok {
+		for _, tag := range parseTagList(dmarc) {
+			if tag.name == "p" {
+				details.WriteString(fmt.Sprintf(" | DMARC: p=%s", tag.value))
+			}
+		}
+	}
+	dkimKeys := 0
+	for name := range result.MailAuthRecords {
+		if strings.HasPrefix(name, MailAuthDKIM+":") {
+			dkimKeys++
+		}
+	}
+	if dkimKeys > 0 {
+		details.WriteString(fmt.Sprintf(" | DKIM: %d keys", dkimKeys))
+	}
+	if _, ok := result.MailAuthRecords[MailAuthMTASTS]; ok {
+		details.WriteString(" | MTA-STS: yes")
+	}
+
+	details.WriteString(fmt.Sprintf(" | %d errors, %d warnings", counts[FindingError], counts[FindingWarning]))
+	return details.String()
+}
@@ -0,0 +1,117 @@
+package operations
+
+import "strings"
+
+// probeIMAP opens the session and logs in with LOGIN
+func probeIMAP(s *mailSession) error {
+	preauth, err := s.imapOpen()
+	if err != nil {
+		return err
+	}
+
+	if login, err := s.checkLogin(); err != nil {
+		return err
+	} else if login && !preauth {
+		if s.hasCapability("LOGINDISABLED") {
+			return stageError(MailErrorAuth, "server has LOGIN disabled")
+		}
+		s.authMechanism = "LOGIN"
+		username, err := imapQuote(s.opts.Username)
+		if err != nil {
+			return err
+		}
+		password, err := imapQuote(s.opts.Password)
+		if err != nil {
+			return err
+		}
+		if err := s.imapCommand(MailErrorAuth, "LOGIN %s %s", username, password); err != nil {
+			return err
+		}
+		s.authenticated = true
+	}
+
+	s.imapCommand(MailErrorProtocol, "LOGOUT")
+	return nil
+}
+
+// imapOpen reads the greeting, lists the capabilities and upgrades with
+// STARTTLS when configured. It reports whether the server greeted with
+// PREAUTH, which leaves nothing to log in to.
+func (s *mailSession) imapOpen() (bool, error) {
+	greeting, err := s.readGreeting()
+	if err != nil {
+		return false, err
+	}
+	status, text, _ := strings.Cut(strings.TrimPrefix(greeting, "* "), " ")
+	switch {
+	case !strings.HasPrefix(greeting, "* "):
+		return false, stageError(MailErrorGreeting, "not an IMAP server (greeting %q)", s.greeting)
+	case strings.EqualFold(status, "BYE"):
+		return false, stageError(MailErrorGreeting, "server refused the session: %s", sanitizeBanner([]byte(text)))
+	case !strings.EqualFold(status, "OK") && !strings.EqualFold(status, "PREAUTH"):
+		return false, stageError(MailErrorGreeting, "not an IMAP server (greeting %q)", s.greeting)
+	}
+	preauth := strings.EqualFold(status, "PREAUTH")
+
+	if err := s.imapCapability(); err != nil {
+		return preauth, err
+	}
+	err = s.startTLS("STARTTLS", func() error {
+		return s.imapCommand(MailErrorTLS, "STARTTLS")
+	})
+	if err != nil {
+		return preauth, err
+	}
+	if s.tlsState != nil && s.opts.TLS == MailTLSSTARTTLS {
+		// Capabilities must be requested again after the upgrade
+		return preauth, s.imapCapability()
+	}
+	return preauth, nil
+}
+
+// imapCapability asks for the capability list
+func (s *mailSession) imapCapability() error {
+	s.capabilities = nil
+	return s.imapCommand(MailErrorProtocol, "CAPABILITY")
+}
+
+// imapCommand sends a tagged command and reads responses until its tagged
+// completion, which fails with the given kind unless it is OK. Untagged
+// CAPABILITY responses along the way are recorded.
+func (s *mailSession) imapCommand(kind, format string, args ...interface{}) error {
+	if err := s.writeLine("a1 "+format, args...); err != nil {
+		return stageError(kind, "sending command: %v", err)
+	}
+	for {
+		line, err := s.text.ReadLine()
+		if err != nil {
+			return stageError(kind, "reading response: %v", err)
+		}
+		if rest, ok := strings.CutPrefix(line, "* "); ok {
+			if word, list, _ := strings.Cut(rest, " "); strings.EqualFold(word, "CAPABILITY") {
+				for _, capability := range strings.Fields(list) {
+					s.capabilities = append(s.capabilities, sanitizeBanner([]byte(capability)))
+				}
+			}
+			continue
+		}
+		rest, ok := strings.CutPrefix(line, "a1 ")
+		if !ok {
+			continue // Continuation requests and literal data are not expected
+		}
+		status, text, _ := strings.Cut(rest, " ")
+		if !strings.EqualFold(status, "OK") {
+			return stageError(kind, "%s %s", strings.ToUpper(status), sanitizeBanner([]byte(text)))
+		}
+		return nil
+	}
+}
+
+// imapQuote encodes a LOGIN argument as a quoted string
+func imapQuote(value string) (string, error) {
+	if strings.ContainsAny(value, "\r\n\x00") {
+		return "", stageError(MailErrorAuth, "credentials contain characters IMAP LOGIN cannot send")
+	}
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+	return `"` + value + `"`, nil
+}
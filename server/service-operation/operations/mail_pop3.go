@@ -0,0 +1,100 @@
+package operations
+
+import "strings"
+
+// probePOP3 opens the session and logs in with USER and PASS
+func probePOP3(s *mailSession) error {
+	if err := s.pop3Open(); err != nil {
+		return err
+	}
+
+	if login, err := s.checkLogin(); err != nil {
+		return err
+	} else if login {
+		if strings.ContainsAny(s.opts.Username+s.opts.Password, "\r\n") {
+			return stageError(MailErrorAuth, "credentials contain line breaks")
+		}
+		s.authMechanism = "USER"
+		if err := s.pop3Command(MailErrorAuth, "USER %s", s.opts.Username); err != nil {
+			return err
+		}
+		if err := s.pop3Command(MailErrorAuth, "PASS %s", s.opts.Password); err != nil {
+			return err
+		}
+		s.authenticated = true
+	}
+
+	s.pop3Command(MailErrorProtocol, "QUIT")
+	return nil
+}
+
+// pop3Open reads the greeting, lists the capabilities and upgrades with STLS
+// when configured
+func (s *mailSession) pop3Open() error {
+	greeting, err := s.readGreeting()
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(greeting, "-ERR") {
+		return stageError(MailErrorGreeting, "server refused the session: %s", s.greeting)
+	}
+	if !strings.HasPrefix(greeting, "+OK") {
+		return stageError(MailErrorGreeting, "not a POP3 server (greeting %q)", s.greeting)
+	}
+
+	if err := s.pop3Capa(); err != nil {
+		return err
+	}
+	err = s.startTLS("STLS", func() error {
+		return s.pop3Command(MailErrorTLS, "STLS")
+	})
+	if err != nil {
+		return err
+	}
+	if s.tlsState != nil && s.opts.TLS == MailTLSSTARTTLS {
+		// Capabilities must be requested again after the upgrade
+		return s.pop3Capa()
+	}
+	return nil
+}
+
+// pop3Capa lists the capabilities. Servers predating CAPA answer -ERR and are
+// left without a capability list.
+func (s *mailSession) pop3Capa() error {
+	s.capabilities = nil
+	if err := s.writeLine("CAPA"); err != nil {
+		return stageError(MailErrorProtocol, "sending command: %v", err)
+	}
+	line, err := s.text.ReadLine()
+	if err != nil {
+		return stageError(MailErrorProtocol, "reading response: %v", err)
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return nil
+	}
+	lines, err := s.text.ReadDotLines()
+	if err != nil {
+		return stageError(MailErrorProtocol, "reading capabilities: %v", err)
+	}
+	for _, capability := range lines {
+		if capability = strings.TrimSpace(capability); capability != "" {
+			s.capabilities = append(s.capabilities, sanitizeBanner([]byte(capability)))
+		}
+	}
+	return nil
+}
+
+// pop3Command sends a command and fails with the given kind unless the server answers +OK
+func (s *mailSession) pop3Command(kind, format string, args ...interface{}) error {
+	if err := s.writeLine(format, args...); err != nil {
+		return stageError(kind, "sending command: %v", err)
+	}
+	line, err := s.text.ReadLine()
+	if err != nil {
+		return stageError(kind, "reading response: %v", err)
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return stageError(kind, "%s", sanitizeBanner([]byte(line)))
+	}
+	return nil
+}
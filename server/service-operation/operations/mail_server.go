@@ -0,0 +1,310 @@
+package operations
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"service-operation/types"
+)
+
+// Mail protocols probed by MailServerOperation, matching the operation and service types
+const (
+	MailProtocolSMTP = "smtp"
+	MailProtocolIMAP = "imap"
+	MailProtocolPOP3 = "pop3"
+)
+
+// TLS modes of a mail probe
+const (
+	MailTLSNone     = "none"
+	MailTLSSTARTTLS = "starttls"
+	MailTLSImplicit = "implicit"
+)
+
+// Stages at which a mail probe fails, reported as mail_error_kind
+const (
+	MailErrorConnection = "connection"
+	MailErrorGreeting   = "greeting"
+	MailErrorTLS        = "tls"
+	MailErrorAuth       = "auth"
+	MailErrorProtocol   = "protocol"
+)
+
+// mailEHLOName is the name the SMTP probe introduces itself with
+const mailEHLOName = "localhost"
+
+var defaultMailPorts = map[string]int{
+	MailProtocolSMTP: 25,
+	MailProtocolIMAP: 143,
+	MailProtocolPOP3: 110,
+}
+
+// implicitTLSPorts speak TLS from the first byte: SMTPS, IMAPS and POP3S
+var implicitTLSPorts = map[int]bool{465: true, 993: true, 995: true}
+
+type MailServerOperation struct {
+	timeout time.Duration
+}
+
+// MailServerOptions configures a mail protocol probe
+type MailServerOptions struct {
+	TLS                string // none, starttls or implicit; empty picks implicit on 465, 993 and 995
+	Username           string // Logs in when set, which requires TLS
+	Password           string
+	InsecureSkipVerify bool
+}
+
+// mailSession is an open probe connection and what was learned over it
+type mailSession struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	text   *textproto.Reader
+	host   string
+	opts   MailServerOptions
+
+	tlsConfig *tls.Config // Overrides the config built from host and opts
+
+	greeting      string
+	greetingAt    time.Time
+	capabilities  []string
+	tlsState      *tls.ConnectionState
+	tlsTime       time.Duration
+	authenticated bool
+	authMechanism string
+}
+
+func NewMailServerOperation(timeout time.Duration) *MailServerOperation {
+	return &MailServerOperation{timeout: timeout}
+}
+
+func (m *MailServerOperation) Execute(protocol, host string, port int) (*types.OperationResult, error) {
+	return m.ExecuteWithOptions(protocol, host, port, MailServerOptions{})
+}
+
+// ExecuteWithOptions connects to a mail server, reads its greeting, lists its
+// capabilities and optionally upgrades to TLS and logs in. A greeting
+// refusing service or a failed TLS upgrade fails the check.
+func (m *MailServerOperation) ExecuteWithOptions(protocol, host string, port int, opts MailServerOptions) (*types.OperationResult, error) {
+	if host == "" {
+		return nil, fmt.Errorf("host cannot be empty")
+	}
+
+	var opType types.OperationType
+	var probe func(*mailSession) error
+	switch strings.ToLower(protocol) {
+	case MailProtocolSMTP:
+		opType, probe = types.OperationSMTP, probeSMTP
+	case MailProtocolIMAP:
+		opType, probe = types.OperationIMAP, probeIMAP
+	case MailProtocolPOP3:
+		opType, probe = types.OperationPOP3, probePOP3
+	default:
+		return nil, fmt.Errorf("unsupported mail protocol %q", protocol)
+	}
+	if port <= 0 {
+		port = defaultMailPorts[string(opType)]
+	}
+
+	switch strings.ToLower(opts.TLS) {
+	case "":
+		opts.TLS = MailTLSNone
+		if implicitTLSPorts[port] {
+			opts.TLS = MailTLSImplicit
+		}
+	case MailTLSNone, MailTLSSTARTTLS, MailTLSImplicit:
+		opts.TLS = strings.ToLower(opts.TLS)
+	default:
+		return nil, fmt.Errorf("invalid mail TLS mode %q, expected none, starttls or implicit", opts.TLS)
+	}
+
+	result := &types.OperationResult{
+		Type:        opType,
+		Host:        host,
+		Port:        port,
+		MailTLSMode: opts.TLS,
+		StartTime:   time.Now(),
+	}
+
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	conn, err := net.DialTimeout("tcp", address, m.timeout)
+	result.TCPConnectTime = time.Since(result.StartTime)
+	if err != nil {
+		err = stageError(MailErrorConnection, "%v", err)
+	} else {
+		defer conn.Close()
+		if m.timeout > 0 {
+			conn.SetDeadline(result.StartTime.Add(m.timeout))
+		}
+
+		session := &mailSession{host: host, opts: opts}
+		session.setConn(conn)
+		if opts.TLS == MailTLSImplicit {
+			err = session.handshakeTLS()
+		}
+		if err == nil {
+			err = probe(session)
+		}
+
+		result.MailGreeting = session.greeting
+		result.MailCapabilities = session.capabilities
+		result.MailAuthenticated = session.authenticated
+		result.MailAuthMechanism = session.authMechanism
+		if !session.greetingAt.IsZero() {
+			result.MailGreetingTime = session.greetingAt.Sub(result.StartTime)
+		}
+		if session.tlsState != nil {
+			result.TLSVersion = tls.VersionName(session.tlsState.Version)
+			result.TLSCipherSuite = tls.CipherSuiteName(session.tlsState.CipherSuite)
+			result.TLSHandshakeTime = session.tlsTime
+		}
+	}
+
+	result.EndTime = time.Now()
+	result.ResponseTime = result.EndTime.Sub(result.StartTime)
+
+	if err != nil {
+		var probeErr *probeError
+		if !errors.As(err, &probeErr) {
+			probeErr = &probeError{kind: MailErrorProtocol, message: err.Error()}
+		}
+		result.Success = false
+		result.Error = probeErr.message
+		result.MailErrorKind = probeErr.kind
+	} else {
+		result.Success = true
+	}
+	result.Details = m.createDetailedMessage(result, address, opts.Username)
+
+	return result, nil
+}
+
+func (s *mailSession) setConn(conn net.Conn) {
+	s.conn = conn
+	s.reader = bufio.NewReader(conn)
+	s.text = textproto.NewReader(s.reader)
+}
+
+// readGreeting reads the first line the server sends and records when it arrived
+func (s *mailSession) readGreeting() (string, error) {
+	line, err := s.text.ReadLine()
+	if err != nil {
+		return "", stageError(MailErrorGreeting, "reading greeting: %v", err)
+	}
+	s.greetingAt = time.Now()
+	s.greeting = sanitizeBanner([]byte(line))
+	return line, nil
+}
+
+// writeLine sends one command line
+func (s *mailSession) writeLine(format string, args ...interface{}) error {
+	_, err := fmt.Fprintf(s.conn, format+"\r\n", args...)
+	return err
+}
+
+// handshakeTLS upgrades the connection, after a STARTTLS command was accepted
+// or right away for implicit TLS. Data buffered before the handshake was not
+// protected by TLS and is refused, as it could be injected by an attacker.
+func (s *mailSession) handshakeTLS() error {
+	if s.reader.Buffered() > 0 {
+		return stageError(MailErrorTLS, "server sent data before the TLS handshake")
+	}
+
+	config := s.tlsConfig
+	if config == nil {
+		config = &tls.Config{
+			ServerName:         s.host,
+			InsecureSkipVerify: s.opts.InsecureSkipVerify,
+		}
+	}
+	tlsConn := tls.Client(s.conn, config)
+	handshakeStart := time.Now()
+	if err := tlsConn.Handshake(); err != nil {
+		return stageError(MailErrorTLS, "TLS handshake failed: %v", err)
+	}
+	s.tlsTime = time.Since(handshakeStart)
+	state := tlsConn.ConnectionState()
+	s.tlsState = &state
+	s.setConn(tlsConn)
+	return nil
+}
+
+// startTLS upgrades the connection when configured. The command must be
+// advertised, unless the server lists no capabilities at all.
+func (s *mailSession) startTLS(capability string, command func() error) error {
+	if s.opts.TLS != MailTLSSTARTTLS {
+		return nil
+	}
+	if len(s.capabilities) > 0 && !s.hasCapability(capability) {
+		return stageError(MailErrorTLS, "server does not advertise %s", capability)
+	}
+	if err := command(); err != nil {
+		return err
+	}
+	return s.handshakeTLS()
+}
+
+// checkLogin reports whether to log in, refusing to send credentials in clear text
+func (s *mailSession) checkLogin() (bool, error) {
+	if s.opts.Username == "" {
+		return false, nil
+	}
+	if s.tlsState == nil {
+		return false, stageError(MailErrorAuth, "refusing to send credentials without TLS, use STARTTLS or implicit TLS")
+	}
+	return true, nil
+}
+
+// hasCapability matches a capability by its first word, case-insensitively
+func (s *mailSession) hasCapability(name string) bool {
+	return s.capability(name) != ""
+}
+
+// capability returns the advertised capability whose first word is name
+func (s *mailSession) capability(name string) string {
+	for _, capability := range s.capabilities {
+		word, _, _ := strings.Cut(capability, " ")
+		if strings.EqualFold(word, name) {
+			return capability
+		}
+	}
+	return ""
+}
+
+func (m *MailServerOperation) createDetailedMessage(result *types.OperationResult, address, username string) string {
+	var details strings.Builder
+	name := strings.ToUpper(string(result.Type))
+
+	switch {
+	case result.Success:
+		details.WriteString(fmt.Sprintf("✅ %s OK - %s", name, result.MailGreeting))
+	case result.MailErrorKind == MailErrorAuth:
+		details.WriteString(fmt.Sprintf("🔐 %s AUTH FAILED - %s", name, result.Error))
+	default:
+		details.WriteString(fmt.Sprintf("❌ %s %s FAILED - %s", name, strings.ToUpper(result.MailErrorKind), result.Error))
+	}
+
+	details.WriteString(fmt.Sprintf(" | Server: %s", address))
+	if result.MailGreetingTime > 0 {
+		details.WriteString(fmt.Sprintf(" | Greeting: %.2fms", float64(result.MailGreetingTime.Nanoseconds())/1e6))
+	}
+	if result.TLSVersion != "" {
+		details.WriteString(fmt.Sprintf(" | TLS: %s (%s)", result.TLSVersion, result.MailTLSMode))
+	} else {
+		details.WriteString(" | TLS: none")
+	}
+	if result.MailAuthenticated {
+		details.WriteString(fmt.Sprintf(" | User: %s (%s)", username, result.MailAuthMechanism))
+	}
+	if len(result.MailCapabilities) > 0 {
+		details.WriteString(fmt.Sprintf(" | Capabilities: %s", strings.Join(result.MailCapabilities, ", ")))
+	}
+
+	return details.String()
+}
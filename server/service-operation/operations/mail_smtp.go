@@ -0,0 +1,130 @@
+package operations
+
+import (
+	"encoding/base64"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// probeSMTP opens the session and logs in with AUTH PLAIN or LOGIN
+func probeSMTP(s *mailSession) error {
+	if err := s.smtpOpen(); err != nil {
+		return err
+	}
+
+	if login, err := s.checkLogin(); err != nil {
+		return err
+	} else if login {
+		if err := s.smtpAuth(); err != nil {
+			return err
+		}
+	}
+
+	s.writeLine("QUIT")
+	return nil
+}
+
+// smtpOpen reads the greeting, lists the EHLO extensions and upgrades with
+// STARTTLS when configured
+func (s *mailSession) smtpOpen() error {
+	code, message, err := s.text.ReadResponse(0)
+	if err != nil && code == 0 {
+		return stageError(MailErrorGreeting, "reading greeting: %v", err)
+	}
+	s.greetingAt = time.Now()
+	s.greeting = sanitizeBanner([]byte(firstLine(message)))
+	if code != 220 {
+		return stageError(MailErrorGreeting, "server refused the session: %d %s", code, s.greeting)
+	}
+
+	if err := s.smtpHello(); err != nil {
+		return err
+	}
+	err = s.startTLS("STARTTLS", func() error {
+		_, _, err := s.smtpCommand(MailErrorTLS, 220, "STARTTLS")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if s.tlsState != nil && s.opts.TLS == MailTLSSTARTTLS {
+		// Extensions must be listed again, the server may offer AUTH only over TLS
+		return s.smtpHello()
+	}
+	return nil
+}
+
+// smtpHello sends EHLO and records the extensions, falling back to HELO for
+// servers that do not know EHLO
+func (s *mailSession) smtpHello() error {
+	_, message, err := s.smtpCommand(MailErrorProtocol, 250, "EHLO %s", mailEHLOName)
+	if err != nil {
+		if _, _, err := s.smtpCommand(MailErrorProtocol, 250, "HELO %s", mailEHLOName); err != nil {
+			return err
+		}
+		s.capabilities = nil
+		return nil
+	}
+	// The first line is the server's greeting to the client, extensions follow
+	lines := strings.Split(message, "\n")
+	s.capabilities = nil
+	for _, line := range lines[1:] {
+		if line = strings.TrimSpace(line); line != "" {
+			s.capabilities = append(s.capabilities, sanitizeBanner([]byte(line)))
+		}
+	}
+	return nil
+}
+
+// smtpAuth logs in with AUTH PLAIN, or AUTH LOGIN when PLAIN is not offered
+func (s *mailSession) smtpAuth() error {
+	mechanisms := strings.Fields(strings.ToUpper(s.capability("AUTH")))
+	switch {
+	case containsString(mechanisms, "PLAIN"):
+		s.authMechanism = "PLAIN"
+		credentials := base64.StdEncoding.EncodeToString([]byte("\x00" + s.opts.Username + "\x00" + s.opts.Password))
+		if _, _, err := s.smtpCommand(MailErrorAuth, 235, "AUTH PLAIN %s", credentials); err != nil {
+			return err
+		}
+	case containsString(mechanisms, "LOGIN"):
+		s.authMechanism = "LOGIN"
+		if _, _, err := s.smtpCommand(MailErrorAuth, 334, "AUTH LOGIN"); err != nil {
+			return err
+		}
+		if _, _, err := s.smtpCommand(MailErrorAuth, 334, "%s", base64.StdEncoding.EncodeToString([]byte(s.opts.Username))); err != nil {
+			return err
+		}
+		if _, _, err := s.smtpCommand(MailErrorAuth, 235, "%s", base64.StdEncoding.EncodeToString([]byte(s.opts.Password))); err != nil {
+			return err
+		}
+	case len(mechanisms) == 0:
+		return stageError(MailErrorAuth, "server does not offer AUTH")
+	default:
+		return stageError(MailErrorAuth, "server offers no supported AUTH mechanism: %s", strings.Join(mechanisms[1:], ", "))
+	}
+	s.authenticated = true
+	return nil
+}
+
+// smtpCommand sends a command and reads its reply, failing with the given kind
+// unless the reply code is the expected one
+func (s *mailSession) smtpCommand(kind string, expect int, format string, args ...interface{}) (int, string, error) {
+	if err := s.writeLine(format, args...); err != nil {
+		return 0, "", stageError(kind, "sending command: %v", err)
+	}
+	code, message, err := s.text.ReadResponse(expect)
+	if err != nil {
+		if protoErr, ok := err.(*textproto.Error); ok {
+			return code, message, stageError(kind, "%d %s", protoErr.Code, sanitizeBanner([]byte(firstLine(protoErr.Msg))))
+		}
+		return code, message, stageError(kind, "reading reply: %v", err)
+	}
+	return code, message, nil
+}
+
+// firstLine returns the first line of a multi-line reply
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	return line
+}
@@ -0,0 +1,278 @@
+package operations
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// spfLookupLimit is the maximum number of DNS-querying terms an SPF
+// evaluation may use before it fails with a permerror (RFC 7208 section 4.6.4)
+const spfLookupLimit = 10
+
+// spfMaxDepth stops runaway include chains
+const spfMaxDepth = 10
+
+type spfTerm struct {
+	qualifier byte // '+', '-', '~' or '?'
+	name      string
+	value     string
+	modifier  bool
+}
+
+func (t spfTerm) String() string {
+	text := t.name
+	if t.modifier {
+		return text + "=" + t.value
+	}
+	if t.qualifier != '+' {
+		text = string(t.qualifier) + text
+	}
+	if t.value != "" {
+		text += ":" + t.value
+	}
+	return text
+}
+
+// parseSPF splits an SPF record into its terms, returning syntax problems separately
+func parseSPF(record string) ([]spfTerm, []string) {
+	fields := strings.Fields(record)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "v=spf1") {
+		return nil, []string{"record does not start with v=spf1"}
+	}
+
+	var terms []spfTerm
+	var problems []string
+	for _, field := range fields[1:] {
+		// Modifiers are name=value, mechanisms may carry ":domain" or "/cidr"
+		if name, value, found := strings.Cut(field, "="); found && !strings.ContainsAny(name, ":/") {
+			name = strings.ToLower(name)
+			if value == "" && (name == "redirect" || name == "exp") {
+				problems = append(problems, fmt.Sprintf("%s= needs a domain", name))
+			}
+			terms = append(terms, spfTerm{qualifier: '+', name: name, value: value, modifier: true})
+			continue
+		}
+
+		term := spfTerm{qualifier: '+'}
+		if strings.ContainsRune("+-~?", rune(field[0])) {
+			term.qualifier = field[0]
+			field = field[1:]
+		}
+
+		name := field
+		if idx := strings.IndexAny(field, ":/"); idx >= 0 {
+			name = field[:idx]
+			term.value = strings.TrimPrefix(field[idx:], ":")
+		}
+		term.name = strings.ToLower(name)
+
+		if problem := validateSPFMechanism(term); problem != "" {
+			problems = append(problems, problem)
+			continue
+		}
+		terms = append(terms, term)
+	}
+	return terms, problems
+}
+
+func validateSPFMechanism(term spfTerm) string {
+	switch term.name {
+	case "all":
+		if term.value != "" {
+			return fmt.Sprintf("%s takes no arguments", term)
+		}
+	case "include", "exists":
+		if term.value == "" {
+			return fmt.Sprintf("%s needs a domain", term.name)
+		}
+	case "a", "mx", "ptr":
+	case "ip4", "ip6":
+		value := term.value
+		if !strings.Contains(value, "/") {
+			if term.name == "ip4" {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+		ip, _, err := net.ParseCIDR(value)
+		if err != nil || (term.name == "ip4") != (ip.To4() != nil) {
+			return fmt.Sprintf("invalid %s address %q", term.name, term.value)
+		}
+	default:
+		return fmt.Sprintf("unknown mechanism %q", term.String())
+	}
+	return ""
+}
+
+// spfAudit walks an SPF record and the records it includes, counting DNS lookups
+type spfAudit struct {
+	audit    *mailAuthAudit
+	lookups  int
+	active   map[string]bool         // Domains on the current include path
+	finished map[string]spfSubResult // Domains already audited
+}
+
+// spfSubResult remembers what an audited include contributed
+type spfSubResult struct {
+	lookups int
+	all     *spfTerm
+}
+
+// lookupSPF returns the SPF records published at a domain
+func (a *mailAuthAudit) lookupSPF(domain string) ([]string, error) {
+	txts, err := a.lookupTXT(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []string
+	for _, txt := range txts {
+		fields := strings.Fields(txt)
+		if len(fields) > 0 && strings.EqualFold(fields[0], "v=spf1") {
+			records = append(records, txt)
+		}
+	}
+	return records, nil
+}
+
+func (a *mailAuthAudit) checkSPF(domain string) {
+	records, err := a.lookupSPF(domain)
+	switch {
+	case err != nil:
+		a.add(MailAuthSPF, FindingError, "lookup of %s failed: %v", domain, err)
+		return
+	case len(records) == 0:
+		a.add(MailAuthSPF, FindingError, "no SPF record at %s", domain)
+		return
+	case len(records) > 1:
+		a.add(MailAuthSPF, FindingError, "%d SPF records found, evaluation fails with permerror", len(records))
+		return
+	}
+
+	a.result.MailAuthRecords[MailAuthSPF] = records[0]
+
+	spf := &spfAudit{
+		audit:    a,
+		active:   map[string]bool{strings.ToLower(domain): true},
+		finished: make(map[string]spfSubResult),
+	}
+	all := spf.walk(domain, records[0], 0)
+
+	a.result.SPFLookups = spf.lookups
+	if spf.lookups > spfLookupLimit {
+		a.add(MailAuthSPF, FindingError, "%d DNS lookups exceed the limit of %d, evaluation fails with permerror",
+			spf.lookups, spfLookupLimit)
+	} else if spf.lookups == spfLookupLimit {
+		a.add(MailAuthSPF, FindingWarning, "%d DNS lookups, at the limit of %d", spf.lookups, spfLookupLimit)
+	}
+
+	switch {
+	case all == nil:
+		a.add(MailAuthSPF, FindingWarning, "no all mechanism, unmatched senders get a neutral result")
+	case all.qualifier == '+':
+		a.add(MailAuthSPF, FindingError, "+all allows any server to send mail for %s", domain)
+	case all.qualifier == '?':
+		a.add(MailAuthSPF, FindingWarning, "?all gives unmatched senders a neutral result")
+	}
+}
+
+// walk audits one SPF record and returns the all mechanism that ends its
+// evaluation, following redirect= when the record has none
+func (s *spfAudit) walk(domain, record string, depth int) *spfTerm {
+	a := s.audit
+	prefix := ""
+	if depth > 0 {
+		prefix = domain + ": "
+	}
+
+	terms, problems := parseSPF(record)
+	for _, problem := range problems {
+		a.add(MailAuthSPF, FindingError, "%s%s", prefix, problem)
+	}
+
+	var all *spfTerm
+	var redirect string
+	for i, term := range terms {
+		switch term.name {
+		case "all":
+			if all == nil {
+				all = &terms[i]
+				if i < len(terms)-1 && !terms[len(terms)-1].modifier {
+					a.add(MailAuthSPF, FindingWarning, "%sterms after %s are never evaluated", prefix, term)
+				}
+			}
+		case "include":
+			s.lookups++
+			if included := s.follow(term.value, depth); included != nil && included.qualifier == '+' {
+				a.add(MailAuthSPF, FindingError, "%sinclude:%s ends in +all and matches every sender", prefix, term.value)
+			}
+		case "a", "mx", "ptr", "exists":
+			s.lookups++
+			if term.name == "ptr" {
+				a.add(MailAuthSPF, FindingWarning, "%sptr mechanism is deprecated and slow", prefix)
+			}
+		case "redirect":
+			s.lookups++
+			redirect = term.value
+		}
+	}
+
+	if redirect != "" {
+		if all != nil {
+			a.add(MailAuthSPF, FindingInfo, "%sredirect=%s is ignored because the record has an all mechanism", prefix, redirect)
+		} else {
+			all = s.follow(redirect, depth)
+		}
+	}
+	return all
+}
+
+// follow audits the SPF record of an included or redirected domain
+func (s *spfAudit) follow(domain string, depth int) *spfTerm {
+	a := s.audit
+
+	// Macros are expanded per message and can't be resolved here
+	if strings.Contains(domain, "%{") {
+		return nil
+	}
+
+	key := strings.ToLower(strings.TrimSuffix(domain, "."))
+	if s.active[key] {
+		a.add(MailAuthSPF, FindingError, "%s includes itself, evaluation fails with permerror", domain)
+		return nil
+	}
+	// Duplicates are evaluated again by receivers and cost the same lookups
+	if previous, ok := s.finished[key]; ok {
+		a.add(MailAuthSPF, FindingWarning, "%s is included more than once", domain)
+		s.lookups += previous.lookups
+		return previous.all
+	}
+
+	if depth+1 > spfMaxDepth {
+		a.add(MailAuthSPF, FindingError, "include chain deeper than %d at %s", spfMaxDepth, domain)
+		return nil
+	}
+
+	records, err := a.lookupSPF(domain)
+	switch {
+	case err != nil:
+		a.add(MailAuthSPF, FindingError, "lookup of %s failed: %v", domain, err)
+		return nil
+	case len(records) == 0:
+		a.add(MailAuthSPF, FindingError, "%s has no SPF record, evaluation fails with permerror", domain)
+		return nil
+	case len(records) > 1:
+		a.add(MailAuthSPF, FindingError, "%s has %d SPF records", domain, len(records))
+		return nil
+	}
+
+	s.active[key] = true
+	before := s.lookups
+	all := s.walk(domain, records[0], depth+1)
+	delete(s.active, key)
+
+	s.finished[key] = spfSubResult{lookups: s.lookups - before, all: all}
+	return all
+}
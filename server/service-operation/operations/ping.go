@@ -1,9 +1,9 @@
 package operations
 
 import (
+	"errors"
 	"fmt"
 	"net"
-	"os"
 	"os/exec"
 	"regexp"
 	"runtime"
@@ -11,8 +11,7 @@ import (
 	"strings"
 	"time"
 
-	"golang.org/x/net/icmp"
-	"golang.org/x/net/ipv4"
+	"service-operation/ping"
 	"service-operation/types"
 )
 
@@ -20,43 +19,120 @@ type PingOperation struct {
 	timeout time.Duration
 }
 
+// PingOptions configures a ping check; zero values use the system ping defaults
+type PingOptions struct {
+	Family       string        // auto, ipv4 or ipv6
+	Size         int           // ICMP payload bytes
+	Interval     time.Duration // Delay between packets
+	TTL          int           // IPv4 TTL or IPv6 hop limit
+	DontFragment bool          // Set DF, for path MTU troubleshooting
+}
+
 func NewPingOperation(timeout time.Duration) *PingOperation {
 	return &PingOperation{timeout: timeout}
 }
 
 func (p *PingOperation) Execute(host string, count int) (*types.OperationResult, error) {
+	return p.ExecuteWithOptions(host, count, PingOptions{})
+}
+
+func (p *PingOperation) ExecuteWithOptions(host string, count int, opts PingOptions) (*types.OperationResult, error) {
 	// Validate host/IP
 	if host == "" {
 		return nil, fmt.Errorf("host cannot be empty")
 	}
 
-	// Always try system ping first for better reliability
-	result, err := p.executeSystemPing(host, count)
-	if err == nil && result.PacketsRecv > 0 {
+	result, err := p.executeICMP(host, count, opts)
+	if err == nil {
 		return result, nil
 	}
-	
-	// If system ping fails, try raw ICMP as fallback
-	fmt.Printf("System ping failed (%v), trying raw ICMP\n", err)
-	rawResult, rawErr := p.executeRawICMP(host, count)
-	if rawErr == nil && rawResult.PacketsRecv > 0 {
-		return rawResult, nil
+	if errors.Is(err, ping.ErrInvalidOptions) {
+		return nil, err
 	}
-	
-	// If both fail, return the system ping result with error info
-	if result != nil {
-		return result, nil
+
+	// Without any ICMP socket the system ping binary (setuid, or Windows) is the last resort
+	if errors.Is(err, ping.ErrNoICMPSocket) {
+		fmt.Printf("ICMP sockets unavailable (%v), falling back to system ping\n", err)
+		return p.executeSystemPing(host, count, opts)
 	}
-	
-	return nil, fmt.Errorf("both system ping and raw ICMP failed: system_err=%v, raw_err=%v", err, rawErr)
-}
 
-func (p *PingOperation) executeSystemPing(host string, count int) (*types.OperationResult, error) {
-	result := &types.OperationResult{
+	return &types.OperationResult{
 		Type:        types.OperationPing,
 		Host:        host,
 		PacketsSent: count,
-		StartTime:   time.Now(),
+		PacketLoss:  100,
+		Error:       err.Error(),
+		Details:     p.createDetailedErrorMessage(err.Error(), host, ""),
+	}, nil
+}
+
+// executeICMP pings with the native pinger, over an unprivileged datagram
+// socket where net.ipv4.ping_group_range allows it and a raw socket otherwise
+func (p *PingOperation) executeICMP(host string, count int, opts PingOptions) (*types.OperationResult, error) {
+	pingResult, err := ping.NewICMPPinger(p.timeout).PingWithOptions(host, count, ping.PingOptions{
+		Family:       opts.Family,
+		Size:         opts.Size,
+		Interval:     opts.Interval,
+		TTL:          opts.TTL,
+		DontFragment: opts.DontFragment,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.OperationResult{
+		Type:           types.OperationPing,
+		Host:           host,
+		Success:        pingResult.Success,
+		PacketsSent:    pingResult.PacketsSent,
+		PacketsRecv:    pingResult.PacketsRecv,
+		PacketLoss:     pingResult.PacketLoss,
+		MinRTT:         pingResult.MinRTT,
+		MaxRTT:         pingResult.MaxRTT,
+		AvgRTT:         pingResult.AvgRTT,
+		RTTs:           pingResult.RTTs,
+		PingAddress:    pingResult.Address,
+		PingFamily:     pingResult.Family,
+		PingPacketSize: pingResult.PacketSize,
+		RTTMeanDev:     pingResult.MeanDevRTT,
+		RTTStdDev:      pingResult.StdDevRTT,
+		PingDuplicates: pingResult.Duplicates,
+		PingOutOfOrder: pingResult.OutOfOrder,
+		StartTime:      pingResult.StartTime,
+		EndTime:        pingResult.EndTime,
+	}
+
+	if result.Success {
+		result.ResponseTime = result.AvgRTT
+		result.Details = p.createDetailedSuccessMessage(result, host, pingResult.Address)
+	} else {
+		result.Error = pingResult.Error
+		result.Details = p.createDetailedErrorMessage(pingResult.Error, host, pingResult.Address)
+	}
+	result.Details += fmt.Sprintf(" | Family: %s", familyLabel(pingResult.Family))
+
+	return result, nil
+}
+
+func (p *PingOperation) executeSystemPing(host string, count int, opts PingOptions) (*types.OperationResult, error) {
+	result := &types.OperationResult{
+		Type:           types.OperationPing,
+		Host:           host,
+		PacketsSent:    count,
+		PingPacketSize: opts.Size,
+		StartTime:      time.Now(),
+	}
+	family := opts.Family
+
+	// Family flags understood by iputils, busybox and Windows ping
+	var familyArgs []string
+	switch family {
+	case ping.FamilyIPv4:
+		familyArgs = []string{"-4"}
+		result.PingFamily = ping.FamilyIPv4
+	case ping.FamilyIPv6:
+		familyArgs = []string{"-6"}
+		result.PingFamily = ping.FamilyIPv6
 	}
 
 	// Resolve host to get IP address for better details
@@ -73,23 +149,52 @@ func (p *PingOperation) executeSystemPing(host string, count int) (*types.Operat
 		timeoutSeconds = 10 // Minimum 10 seconds timeout
 	}
 
+	optionArgs := systemPingOptionArgs(runtime.GOOS, opts)
+
 	switch runtime.GOOS {
 	case "linux":
 		// Linux ping: -c count -W timeout_in_seconds
-		cmd = exec.Command("ping", "-c", fmt.Sprintf("%d", count), "-W", fmt.Sprintf("%d", timeoutSeconds), host)
+		args := append(familyArgs, "-c", fmt.Sprintf("%d", count), "-W", fmt.Sprintf("%d", timeoutSeconds))
+		args = append(append(args, optionArgs...), host)
+		cmd = exec.Command("ping", args...)
 	case "darwin":
+		if family == ping.FamilyIPv6 {
+			// macOS ping6 has no reply timeout flag, and sets the hop limit with -h
+			args := []string{"-c", fmt.Sprintf("%d", count)}
+			if opts.Size > 0 {
+				args = append(args, "-s", strconv.Itoa(opts.Size))
+			}
+			if opts.Interval > 0 {
+				args = append(args, "-i", formatSeconds(opts.Interval))
+			}
+			if opts.TTL > 0 {
+				args = append(args, "-h", strconv.Itoa(opts.TTL))
+			}
+			cmd = exec.Command("ping6", append(args, host)...)
+			break
+		}
 		// macOS ping: -c count -W timeout_in_milliseconds
-		cmd = exec.Command("ping", "-c", fmt.Sprintf("%d", count), "-W", fmt.Sprintf("%d", timeoutSeconds*1000), host)
+		args := append([]string{"-c", fmt.Sprintf("%d", count), "-W", fmt.Sprintf("%d", timeoutSeconds*1000)}, optionArgs...)
+		cmd = exec.Command("ping", append(args, host)...)
 	case "windows":
 		// Windows ping: -n count -w timeout_in_milliseconds
-		cmd = exec.Command("ping", "-n", fmt.Sprintf("%d", count), "-w", fmt.Sprintf("%d", timeoutSeconds*1000), host)
+		args := append(familyArgs, "-n", fmt.Sprintf("%d", count), "-w", fmt.Sprintf("%d", timeoutSeconds*1000))
+		args = append(append(args, optionArgs...), host)
+		cmd = exec.Command("ping", args...)
 	default:
 		// Default to Linux-style
-		cmd = exec.Command("ping", "-c", fmt.Sprintf("%d", count), "-W", fmt.Sprintf("%d", timeoutSeconds), host)
+		args := append(familyArgs, "-c", fmt.Sprintf("%d", count), "-W", fmt.Sprintf("%d", timeoutSeconds))
+		args = append(append(args, optionArgs...), host)
+		cmd = exec.Command("ping", args...)
 	}
 
-	// Set command timeout slightly longer than ping timeout
-	cmdTimeout := time.Duration(timeoutSeconds+5) * time.Second
+	// Set command timeout slightly longer than ping timeout, plus the time
+	// spent between packets
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = ping.DefaultInterval
+	}
+	cmdTimeout := time.Duration(timeoutSeconds+5)*time.Second + time.Duration(count-1)*interval
 	done := make(chan error, 1)
 	var output []byte
 	var cmdErr error
@@ -139,6 +244,65 @@ func (p *PingOperation) executeSystemPing(host string, count int) (*types.Operat
 	}
 }
 
+// systemPingOptionArgs translates packet options into system ping flags.
+// Windows ping has no interval flag, so packets keep its 1s spacing there.
+func systemPingOptionArgs(goos string, opts PingOptions) []string {
+	var args []string
+	switch goos {
+	case "windows":
+		if opts.Size > 0 {
+			args = append(args, "-l", strconv.Itoa(opts.Size))
+		}
+		if opts.TTL > 0 {
+			args = append(args, "-i", strconv.Itoa(opts.TTL))
+		}
+		if opts.DontFragment {
+			args = append(args, "-f")
+		}
+	case "darwin":
+		if opts.Size > 0 {
+			args = append(args, "-s", strconv.Itoa(opts.Size))
+		}
+		if opts.Interval > 0 {
+			args = append(args, "-i", formatSeconds(opts.Interval))
+		}
+		if opts.TTL > 0 {
+			args = append(args, "-m", strconv.Itoa(opts.TTL))
+		}
+		if opts.DontFragment {
+			args = append(args, "-D")
+		}
+	default:
+		// iputils flags
+		if opts.Size > 0 {
+			args = append(args, "-s", strconv.Itoa(opts.Size))
+		}
+		if opts.Interval > 0 {
+			args = append(args, "-i", formatSeconds(opts.Interval))
+		}
+		if opts.TTL > 0 {
+			args = append(args, "-t", strconv.Itoa(opts.TTL))
+		}
+		if opts.DontFragment {
+			args = append(args, "-M", "do")
+		}
+	}
+	return args
+}
+
+// formatSeconds formats a duration as the fractional seconds ping -i expects
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// familyLabel formats an address family for details messages
+func familyLabel(family string) string {
+	if family == ping.FamilyIPv6 {
+		return "IPv6"
+	}
+	return "IPv4"
+}
+
 func (p *PingOperation) createDetailedSuccessMessage(result *types.OperationResult, host, resolvedIP string) string {
 	var details strings.Builder
 	
@@ -165,12 +329,26 @@ func (p *PingOperation) createDetailedSuccessMessage(result *types.OperationResu
 			float64(result.MaxRTT.Nanoseconds())/1000000))
 	}
 	
+	// Jitter needs at least two replies
+	if result.PacketsRecv > 1 {
+		details.WriteString(fmt.Sprintf(" | Jitter: %.2fms (mdev %.2fms)",
+			float64(result.RTTMeanDev.Nanoseconds())/1000000,
+			float64(result.RTTStdDev.Nanoseconds())/1000000))
+	}
+	
 	// Packet loss information
 	if result.PacketLoss > 0 {
 		details.WriteString(fmt.Sprintf(" | Packet Loss: %.1f%%", result.PacketLoss))
 	} else {
 		details.WriteString(" | No packet loss")
 	}
+
+	if result.PingDuplicates > 0 {
+		details.WriteString(fmt.Sprintf(" | Duplicates: %d", result.PingDuplicates))
+	}
+	if result.PingOutOfOrder > 0 {
+		details.WriteString(fmt.Sprintf(" | Out of order: %d", result.PingOutOfOrder))
+	}
 	
 	return details.String()
 }
@@ -186,7 +364,7 @@ func (p *PingOperation) createDetailedErrorMessage(errorMsg, host, resolvedIP st
 		details.WriteString("🚫 HOST UNREACHABLE - Network path to host is blocked")
 	} else if strings.Contains(errorLower, "no route") {
 		details.WriteString("🛤️ NO ROUTE - No network route to destination")
-	} else if strings.Contains(errorLower, "name resolution") || strings.Contains(errorLower, "unknown host") {
+	} else if strings.Contains(errorLower, "name resolution") || strings.Contains(errorLower, "unknown host") || strings.Contains(errorLower, "resolve host") {
 		details.WriteString("🔍 DNS RESOLUTION FAILED - Unable to resolve hostname")
 	} else if strings.Contains(errorLower, "permission denied") {
 		details.WriteString("🔐 PERMISSION DENIED - Insufficient privileges for ICMP")
@@ -222,7 +400,7 @@ func (p *PingOperation) getShortErrorMessage(errorMessage string) string {
 		return "Host unreachable"
 	} else if strings.Contains(errorLower, "no route") {
 		return "No route to host"
-	} else if strings.Contains(errorLower, "name resolution") || strings.Contains(errorLower, "unknown host") {
+	} else if strings.Contains(errorLower, "name resolution") || strings.Contains(errorLower, "unknown host") || strings.Contains(errorLower, "resolve host") {
 		return "DNS resolution failed"
 	} else if strings.Contains(errorLower, "permission denied") {
 		return "Permission denied"
@@ -248,8 +426,8 @@ func (p *PingOperation) parseSystemPingOutput(result *types.OperationResult, out
 		}
 	}
 
-	// Extract timing information (Linux/macOS format)
-	timingRegex := regexp.MustCompile(`rtt min/avg/max/(?:mdev|stddev) = ([\d.]+)/([\d.]+)/([\d.]+)/([\d.]+) ms`)
+	// Extract timing information (Linux/macOS format; macOS prints round-trip)
+	timingRegex := regexp.MustCompile(`(?:rtt|round-trip) min/avg/max/(?:mdev|stddev) = ([\d.]+)/([\d.]+)/([\d.]+)/([\d.]+) ms`)
 	if matches := timingRegex.FindStringSubmatch(output); len(matches) > 4 {
 		if min, err := strconv.ParseFloat(matches[1], 64); err == nil {
 			result.MinRTT = time.Duration(min * float64(time.Millisecond))
@@ -261,11 +439,24 @@ func (p *PingOperation) parseSystemPingOutput(result *types.OperationResult, out
 		if max, err := strconv.ParseFloat(matches[3], 64); err == nil {
 			result.MaxRTT = time.Duration(max * float64(time.Millisecond))
 		}
+		if mdev, err := strconv.ParseFloat(matches[4], 64); err == nil {
+			result.RTTStdDev = time.Duration(mdev * float64(time.Millisecond))
+		}
+	}
+
+	// Duplicate replies are flagged with (DUP!) and must not count as received
+	var replies []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, "(DUP!)") {
+			result.PingDuplicates++
+			continue
+		}
+		replies = append(replies, line)
 	}
 
 	// Extract individual ping times and count successful pings
 	timeRegex := regexp.MustCompile(`time[<=]([\d.]+) ?ms`)
-	timeMatches := timeRegex.FindAllStringSubmatch(output, -1)
+	timeMatches := timeRegex.FindAllStringSubmatch(strings.Join(replies, "\n"), -1)
 	result.PacketsRecv = len(timeMatches)
 	
 	for _, match := range timeMatches {
@@ -295,6 +486,13 @@ func (p *PingOperation) parseSystemPingOutput(result *types.OperationResult, out
 		result.ResponseTime = result.AvgRTT
 	}
 
+	// ping only reports mdev; the mean deviation comes from the individual times
+	meanDev, stdDev := ping.Deviation(result.RTTs)
+	result.RTTMeanDev = meanDev
+	if result.RTTStdDev == 0 {
+		result.RTTStdDev = stdDev
+	}
+
 	// Recalculate packet loss if we have individual pings
 	if expectedCount > 0 {
 		result.PacketLoss = float64(expectedCount-result.PacketsRecv) / float64(expectedCount) * 100
@@ -340,6 +538,7 @@ func (p *PingOperation) parseWindowsPingOutput(result *types.OperationResult, ou
 		}
 		result.AvgRTT = total / time.Duration(len(result.RTTs))
 		result.ResponseTime = result.AvgRTT
+		result.RTTMeanDev, result.RTTStdDev = ping.Deviation(result.RTTs)
 		result.Success = true
 	}
 
@@ -347,114 +546,3 @@ func (p *PingOperation) parseWindowsPingOutput(result *types.OperationResult, ou
 		result.PacketLoss = float64(expectedCount-result.PacketsRecv) / float64(expectedCount) * 100
 	}
 }
-
-func (p *PingOperation) executeRawICMP(host string, count int) (*types.OperationResult, error) {
-	dst, err := net.ResolveIPAddr("ip4", host)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve host %s: %v", host, err)
-	}
-
-	// Try to create ICMP connection with better error handling
-	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create ICMP connection (requires root/admin privileges): %v", err)
-	}
-	defer conn.Close()
-
-	result := &types.OperationResult{
-		Type:        types.OperationPing,
-		Host:        host,
-		PacketsSent: count,
-		StartTime:   time.Now(),
-	}
-
-	var totalRTT time.Duration
-	var minRTT, maxRTT time.Duration
-	pid := os.Getpid() & 0xffff
-
-	for i := 0; i < count; i++ {
-		message := &icmp.Message{
-			Type: ipv4.ICMPTypeEcho,
-			Code: 0,
-			Body: &icmp.Echo{
-				ID:   pid,
-				Seq:  i + 1,
-				Data: []byte(fmt.Sprintf("Hello, World! %d", i)),
-			},
-		}
-
-		data, err := message.Marshal(nil)
-		if err != nil {
-			continue
-		}
-
-		start := time.Now()
-		_, err = conn.WriteTo(data, dst)
-		if err != nil {
-			continue
-		}
-
-		// Set read deadline
-		err = conn.SetReadDeadline(time.Now().Add(p.timeout))
-		if err != nil {
-			continue
-		}
-
-		reply := make([]byte, 1500)
-		_, peer, err := conn.ReadFrom(reply)
-		if err != nil {
-			continue
-		}
-
-		rtt := time.Since(start)
-
-		// Parse the reply with better error handling - fix the type conversion
-		rm, err := icmp.ParseMessage(int(ipv4.ICMPTypeEchoReply), reply)
-		if err != nil {
-			continue
-		}
-
-		switch rm.Type {
-		case ipv4.ICMPTypeEchoReply:
-			if peer.String() == dst.String() {
-				echoReply, ok := rm.Body.(*icmp.Echo)
-				if ok && echoReply.ID == pid {
-					result.PacketsRecv++
-					totalRTT += rtt
-
-					if result.PacketsRecv == 1 || rtt < minRTT {
-						minRTT = rtt
-					}
-					if result.PacketsRecv == 1 || rtt > maxRTT {
-						maxRTT = rtt
-					}
-
-					result.RTTs = append(result.RTTs, rtt)
-				}
-			}
-		}
-
-		// Sleep between pings except for the last one
-		if i < count-1 {
-			time.Sleep(1 * time.Second)
-		}
-	}
-
-	result.EndTime = time.Now()
-	result.PacketLoss = float64(count-result.PacketsRecv) / float64(count) * 100
-
-	if result.PacketsRecv > 0 {
-		result.MinRTT = minRTT
-		result.MaxRTT = maxRTT
-		result.AvgRTT = totalRTT / time.Duration(result.PacketsRecv)
-		result.ResponseTime = result.AvgRTT
-		result.Success = true
-		// Create detailed success message for raw ICMP
-		result.Details = p.createDetailedSuccessMessage(result, host, dst.String())
-	} else {
-		result.Error = "No ICMP echo replies received"
-		result.Details = p.createDetailedErrorMessage("no replies", host, dst.String())
-	}
-
-	return result, nil
-}
\ No newline at end of file
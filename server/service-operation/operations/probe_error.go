@@ -0,0 +1,18 @@
+package operations
+
+import "fmt"
+
+// probeError is a failure of a protocol probe and the stage it happened at,
+// such as the handshake or authentication
+type probeError struct {
+	kind    string
+	message string
+}
+
+func (e *probeError) Error() string {
+	return e.message
+}
+
+func stageError(kind, format string, args ...interface{}) error {
+	return &probeError{kind: kind, message: fmt.Sprintf(format, args...)}
+}
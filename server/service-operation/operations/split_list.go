@@ -0,0 +1,17 @@
+package operations
+
+import "strings"
+
+// SplitList splits a comma or newline separated list of hosts, nameservers,
+// selectors or values, dropping empty entries
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '\n'
+	}) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,195 @@
+package operations
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"service-operation/types"
+)
+
+// Outcomes of certificate chain verification, reported as ssl_chain_status
+const (
+	SSLChainValid      = "valid"      // Chains to a trusted root with what the server sent
+	SSLChainIncomplete = "incomplete" // Only chains after fetching intermediates the server left out
+	SSLChainUntrusted  = "untrusted"  // Does not chain to a trusted root, e.g. self-signed
+	SSLChainInvalid    = "invalid"    // Chains, but a certificate is expired or not allowed to sign
+)
+
+// sslMaxFetchedIssuers bounds how many missing intermediates are fetched for one chain
+const sslMaxFetchedIssuers = 3
+
+// sslMaxIssuerSize caps the size of a certificate downloaded from an AIA URL
+const sslMaxIssuerSize = 64 << 10
+
+// chainVerification is the verified chain, or the chain as presented when
+// verification failed, and what is wrong with it
+type chainVerification struct {
+	status  string
+	chain   []*x509.Certificate
+	sent    map[*x509.Certificate]bool // Certificates the server presented
+	fetched []*x509.Certificate        // Missing intermediates fetched from AIA URLs
+	err     error
+}
+
+// rootPool returns the system roots with the certificates of a PEM bundle
+// added, or nil for the system roots alone
+func rootPool(caBundle string) (*x509.CertPool, error) {
+	if caBundle == "" {
+		return nil, nil
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM([]byte(caBundle)) {
+		return nil, fmt.Errorf("CA bundle contains no PEM certificates")
+	}
+	return pool, nil
+}
+
+// verifyChain builds the chain from the leaf to a trusted root with the
+// intermediates the server sent. When the issuer is unknown it fetches the
+// missing intermediates from the Authority Information Access URLs, so an
+// incomplete chain can be told apart from an untrusted one.
+func (op *SSLOperation) verifyChain(peers []*x509.Certificate, roots *x509.CertPool) chainVerification {
+	result := chainVerification{chain: peers, sent: make(map[*x509.Certificate]bool)}
+	for _, cert := range peers {
+		result.sent[cert] = true
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range peers[1:] {
+		intermediates.AddCert(cert)
+	}
+	opts := x509.VerifyOptions{Roots: roots, Intermediates: intermediates}
+
+	chains, err := peers[0].Verify(opts)
+	var unknownAuthority x509.UnknownAuthorityError
+	for i := 0; err != nil && errors.As(err, &unknownAuthority) && i < sslMaxFetchedIssuers; i++ {
+		last := peers[len(peers)-1]
+		if len(result.fetched) > 0 {
+			last = result.fetched[len(result.fetched)-1]
+		}
+		issuer := op.fetchIssuer(last)
+		if issuer == nil {
+			break
+		}
+		result.fetched = append(result.fetched, issuer)
+		intermediates.AddCert(issuer)
+		chains, err = peers[0].Verify(opts)
+	}
+
+	switch {
+	case err == nil && len(result.fetched) > 0:
+		result.status = SSLChainIncomplete
+		result.chain = chains[0]
+		result.err = fmt.Errorf("certificate chain is incomplete: server does not send intermediate %s", certificateName(result.fetched[0]))
+	case err == nil:
+		result.status = SSLChainValid
+		result.chain = chains[0]
+	case errors.As(err, &unknownAuthority):
+		result.status = SSLChainUntrusted
+		if len(peers) == 1 && isSelfSigned(peers[0]) {
+			result.err = fmt.Errorf("certificate is self-signed and not trusted")
+		} else {
+			result.err = fmt.Errorf("certificate chain is not trusted: unknown issuer %s", certificateName(peers[len(peers)-1]))
+		}
+	default:
+		result.status = SSLChainInvalid
+		result.err = fmt.Errorf("certificate chain verification failed: %v", err)
+	}
+	return result
+}
+
+// fetchIssuer downloads the issuer of cert from its CA Issuers URLs
+func (op *SSLOperation) fetchIssuer(cert *x509.Certificate) *x509.Certificate {
+	client := &http.Client{Timeout: op.timeout}
+	for _, url := range cert.IssuingCertificateURL {
+		resp, err := client.Get(url)
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, sslMaxIssuerSize))
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			continue
+		}
+		// Usually DER, some CAs publish PEM
+		if block, _ := pem.Decode(data); block != nil {
+			data = block.Bytes
+		}
+		issuer, err := x509.ParseCertificate(data)
+		if err == nil && cert.CheckSignatureFrom(issuer) == nil {
+			return issuer
+		}
+	}
+	return nil
+}
+
+// report describes every certificate of the chain, leaf first
+func (c chainVerification) report() []types.SSLChainCert {
+	certs := make([]types.SSLChainCert, 0, len(c.chain))
+	for i, cert := range c.chain {
+		role := "intermediate"
+		switch {
+		case i == 0:
+			role = "leaf"
+		case isSelfSigned(cert):
+			role = "root"
+		}
+		certs = append(certs, types.SSLChainCert{
+			Subject:   certificateName(cert),
+			Issuer:    certificateIssuerName(cert),
+			Role:      role,
+			ValidFrom: cert.NotBefore,
+			ValidTill: cert.NotAfter,
+			DaysLeft:  int(time.Until(cert.NotAfter).Hours() / 24),
+			Sent:      c.sent[cert],
+		})
+	}
+	return certs
+}
+
+// expiryIssues lists CA certificates in the chain that expire before the leaf
+func (c chainVerification) expiryIssues() []string {
+	var issues []string
+	if len(c.chain) == 0 {
+		return nil
+	}
+	leaf := c.chain[0]
+	for _, cert := range c.chain[1:] {
+		if cert.NotAfter.Before(leaf.NotAfter) {
+			issues = append(issues, fmt.Sprintf("%s expires %s, before the leaf certificate",
+				certificateName(cert), cert.NotAfter.Format("2006-01-02")))
+		}
+	}
+	return issues
+}
+
+// isSelfSigned reports whether cert is signed by its own key
+func isSelfSigned(cert *x509.Certificate) bool {
+	// CheckSignatureFrom would also require the CA flag, which self-signed leaves lack
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// certificateName is the common name, or the full subject when it has none
+func certificateName(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	return cert.Subject.String()
+}
+
+func certificateIssuerName(cert *x509.Certificate) string {
+	if cert.Issuer.CommonName != "" {
+		return cert.Issuer.CommonName
+	}
+	return cert.Issuer.String()
+}
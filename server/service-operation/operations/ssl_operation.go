@@ -3,8 +3,10 @@ package operations
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,16 +14,33 @@ import (
 )
 
 type SSLOperation struct {
-	timeout time.Duration
+	timeout     time.Duration
+	revocations *revocationCache // OCSP responses and CRLs shared between checks
+}
+
+// SSLOptions selects how an SSL check reaches the certificate
+type SSLOptions struct {
+	Protocol string // tls (default), smtp, imap, pop3, ftp, ldap or postgres
+	Port     int    // Used when the domain has no port; defaults to the protocol's port
+	CABundle string // PEM certificates trusted in addition to the system roots
+
+	SkipRevocation bool // Skips the OCSP and CRL revocation checks
 }
 
 func NewSSLOperation(timeout time.Duration) *SSLOperation {
 	return &SSLOperation{
-		timeout: timeout,
+		timeout:     timeout,
+		revocations: sharedRevocationCache,
 	}
 }
 
 func (op *SSLOperation) Execute(domain string) (*types.OperationResult, error) {
+	return op.ExecuteWithOptions(domain, SSLOptions{})
+}
+
+// ExecuteWithOptions checks the certificate of domain, negotiating STARTTLS
+// first when the protocol upgrades a plain connection
+func (op *SSLOperation) ExecuteWithOptions(domain string, opts SSLOptions) (*types.OperationResult, error) {
 	startTime := time.Now()
 	
 	// Clean and normalize domain
@@ -32,26 +51,39 @@ func (op *SSLOperation) Execute(domain string) (*types.OperationResult, error) {
 		return op.createErrorResult(domain, startTime, "domain cannot be empty")
 	}
 	
+	protocol := strings.ToLower(opts.Protocol)
+	if protocol == "" {
+		protocol = SSLProtocolTLS
+	}
+	defaultPort, ok := defaultSSLPorts[protocol]
+	if !ok {
+		return op.createErrorResult(domain, startTime, fmt.Sprintf("unsupported SSL protocol %q", opts.Protocol))
+	}
+	if opts.Port > 0 {
+		defaultPort = opts.Port
+	}
+	
 	// Add port if not present
 	host := domain
 	if !strings.Contains(host, ":") {
-		host = host + ":443"
+		host = net.JoinHostPort(host, strconv.Itoa(defaultPort))
 	}
-
-	// Set up TLS connection with timeout
-	dialer := &net.Dialer{
-		Timeout: op.timeout,
+	
+	roots, err := rootPool(opts.CABundle)
+	if err != nil {
+		return op.createErrorResult(domain, startTime, err.Error())
 	}
 	
-	// Create TLS config with proper verification
+	// Verification is done after the handshake by verifyChain, so that
+	// certificates failing it are still reported
 	tlsConfig := &tls.Config{
 		ServerName:         strings.Split(host, ":")[0],
-		InsecureSkipVerify: false,
+		InsecureSkipVerify: true,
 		MinVersion:         tls.VersionTLS12,
 	}
 	
 	// Attempt TLS connection
-	conn, err := tls.DialWithDialer(dialer, "tcp", host, tlsConfig)
+	conn, err := op.dialTLS(protocol, host, tlsConfig)
 	
 	endTime := time.Now()
 	responseTime := endTime.Sub(startTime)
@@ -63,6 +95,7 @@ func (op *SSLOperation) Execute(domain string) (*types.OperationResult, error) {
 			Success:      false,
 			ResponseTime: responseTime,
 			Error:        fmt.Sprintf("TLS connection failed: %v", err),
+			SSLProtocol:  protocol,
 			StartTime:    startTime,
 			EndTime:      endTime,
 		}, nil
@@ -88,6 +121,24 @@ func (op *SSLOperation) Execute(domain string) (*types.OperationResult, error) {
 	
 	// Perform comprehensive certificate validation
 	validationError := op.validateCertificate(cert, hostname)
+	chain := op.verifyChain(state.PeerCertificates, roots)
+	if validationError == nil {
+		validationError = chain.err
+	}
+	
+	// Check revocation with the issuer from the chain, stapled response first
+	var issuer *x509.Certificate
+	if len(chain.chain) > 1 {
+		issuer = chain.chain[1]
+	}
+	var revocation revocationCheck
+	if !opts.SkipRevocation {
+		revocation = op.checkRevocation(cert, issuer, state.OCSPResponse)
+	}
+	if validationError == nil && revocation.status == RevocationRevoked {
+		validationError = fmt.Errorf("certificate was revoked on %s (%s)",
+			revocation.revokedAt.Format("2006-01-02 15:04:05 MST"), revocation.reason)
+	}
 	
 	// Calculate days left until expiration
 	daysLeft := int(time.Until(cert.NotAfter).Hours() / 24)
@@ -140,6 +191,21 @@ func (op *SSLOperation) Execute(domain string) (*types.OperationResult, error) {
 		SSLAlgorithm:     algorithm,
 		SSLSANs:          strings.Join(sans, ","),
 		SSLResolvedIP:    resolvedIP,
+		SSLProtocol:      protocol,
+		TLSVersion:       tls.VersionName(state.Version),
+		TLSCipherSuite:   tls.CipherSuiteName(state.CipherSuite),
+		SSLChainStatus:   chain.status,
+		SSLChain:         chain.report(),
+		SSLChainIssues:   chain.expiryIssues(),
+		SSLRevocationStatus:     revocation.status,
+		SSLRevocationSource:     revocation.source,
+		SSLRevocationReason:     revocation.reason,
+		SSLRevokedAt:            revocation.revokedAt,
+		SSLRevocationThisUpdate: revocation.thisUpdate,
+		SSLRevocationNextUpdate: revocation.nextUpdate,
+	}
+	if revocation.err != nil {
+		result.SSLRevocationError = revocation.err.Error()
 	}
 
 	return result, nil
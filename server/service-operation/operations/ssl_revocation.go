@@ -0,0 +1,483 @@
+package operations
+
+import (
+	"bytes"
+	"crypto"
+	_ "crypto/sha1" // Hashes for OCSP CertIDs
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Revocation states, reported as ssl_revocation_status
+const (
+	RevocationGood    = "good"
+	RevocationRevoked = "revoked"
+	RevocationUnknown = "unknown" // No responder or CRL gave a usable answer
+)
+
+// Where a revocation status came from, reported as ssl_revocation_source
+const (
+	RevocationSourceStapled = "ocsp_stapled"
+	RevocationSourceOCSP    = "ocsp"
+	RevocationSourceCRL     = "crl"
+)
+
+// sslMaxOCSPResponse and sslMaxCRLSize cap downloads from responders and
+// distribution points
+const (
+	sslMaxOCSPResponse = 64 << 10
+	sslMaxCRLSize      = 16 << 20
+)
+
+// revocationClockSkew tolerates responders whose clocks run slightly ahead
+const revocationClockSkew = 5 * time.Minute
+
+// revocationCacheSize bounds how many OCSP responses and CRLs are kept
+const revocationCacheSize = 1024
+
+// crlReasons names the RFC 5280 CRLReason codes, shared by OCSP and CRLs
+var crlReasons = map[int]string{
+	0:  "unspecified",
+	1:  "key compromise",
+	2:  "CA compromise",
+	3:  "affiliation changed",
+	4:  "superseded",
+	5:  "cessation of operation",
+	6:  "certificate hold",
+	8:  "remove from CRL",
+	9:  "privilege withdrawn",
+	10: "AA compromise",
+}
+
+var (
+	oidSHA1              = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidOCSPBasicResponse = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+)
+
+// ocspCertIDHashes maps the hash OIDs a CertID may be computed with
+var ocspCertIDHashes = map[string]crypto.Hash{
+	"1.3.14.3.2.26":          crypto.SHA1,
+	"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+	"2.16.840.1.101.3.4.2.2": crypto.SHA384,
+	"2.16.840.1.101.3.4.2.3": crypto.SHA512,
+}
+
+// ocspSignatureAlgorithms maps the signature OIDs responders use
+var ocspSignatureAlgorithms = map[string]x509.SignatureAlgorithm{
+	"1.2.840.113549.1.1.5":  x509.SHA1WithRSA,
+	"1.2.840.113549.1.1.11": x509.SHA256WithRSA,
+	"1.2.840.113549.1.1.12": x509.SHA384WithRSA,
+	"1.2.840.113549.1.1.13": x509.SHA512WithRSA,
+	"1.2.840.10045.4.1":     x509.ECDSAWithSHA1,
+	"1.2.840.10045.4.3.2":   x509.ECDSAWithSHA256,
+	"1.2.840.10045.4.3.3":   x509.ECDSAWithSHA384,
+	"1.2.840.10045.4.3.4":   x509.ECDSAWithSHA512,
+	"1.3.101.112":           x509.PureEd25519,
+}
+
+// OCSP structures of RFC 6960, as far as the check needs them
+type ocspCertID struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	NameHash      []byte
+	IssuerKeyHash []byte
+	SerialNumber  *big.Int
+}
+
+type ocspRequestEntry struct {
+	Cert ocspCertID
+}
+
+type ocspTBSRequest struct {
+	Version     int `asn1:"explicit,tag:0,default:0,optional"`
+	RequestList []ocspRequestEntry
+}
+
+type ocspRequest struct {
+	TBSRequest ocspTBSRequest
+}
+
+type ocspResponse struct {
+	Status   asn1.Enumerated
+	Response ocspResponseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData    ocspResponseData
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseData struct {
+	Raw            asn1.RawContent
+	Version        int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID asn1.RawValue
+	ProducedAt     time.Time `asn1:"generalized"`
+	Responses      []ocspSingleResponse
+}
+
+type ocspSingleResponse struct {
+	CertID           ocspCertID
+	Good             asn1.Flag        `asn1:"tag:0,optional"`
+	Revoked          ocspRevokedInfo  `asn1:"tag:1,optional"`
+	Unknown          asn1.Flag        `asn1:"tag:2,optional"`
+	ThisUpdate       time.Time        `asn1:"generalized"`
+	NextUpdate       time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+	SingleExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspRevokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+// revocationCheck is the revocation status of a certificate and its source
+type revocationCheck struct {
+	status     string
+	source     string
+	reason     string
+	revokedAt  time.Time
+	thisUpdate time.Time
+	nextUpdate time.Time
+	err        error // Why the status is unknown
+}
+
+// revocationCache keeps OCSP responses and CRLs until their next update, so
+// certificates of one CA share a CRL download and rechecks reuse responses
+type revocationCache struct {
+	mu      sync.Mutex
+	entries map[string]revocationCacheEntry
+}
+
+type revocationCacheEntry struct {
+	value   interface{} // OCSP response DER or a verified *x509.RevocationList
+	expires time.Time
+}
+
+var sharedRevocationCache = newRevocationCache()
+
+func newRevocationCache() *revocationCache {
+	return &revocationCache{entries: make(map[string]revocationCacheEntry)}
+}
+
+func (c *revocationCache) get(key string) interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil
+	}
+	return entry.value
+}
+
+// put caches value until nextUpdate; values without one are not cached
+func (c *revocationCache) put(key string, value interface{}, nextUpdate time.Time) {
+	if nextUpdate.IsZero() || time.Now().After(nextUpdate) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= revocationCacheSize {
+		now := time.Now()
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= revocationCacheSize {
+			return
+		}
+	}
+	c.entries[key] = revocationCacheEntry{value: value, expires: nextUpdate}
+}
+
+// checkRevocation uses the OCSP response stapled to the handshake, then
+// queries the certificate's OCSP responders and finally its CRL distribution
+// points, stopping at the first source that answers good or revoked
+func (op *SSLOperation) checkRevocation(leaf, issuer *x509.Certificate, stapled []byte) revocationCheck {
+	if issuer == nil {
+		return revocationCheck{status: RevocationUnknown, err: fmt.Errorf("issuer certificate not available")}
+	}
+
+	var problems []string
+	if len(stapled) > 0 {
+		check, err := parseOCSPResponse(stapled, leaf, issuer)
+		if err == nil {
+			check.source = RevocationSourceStapled
+			return check
+		}
+		problems = append(problems, fmt.Sprintf("stapled OCSP response: %v", err))
+	}
+
+	for _, server := range leaf.OCSPServer {
+		check, err := op.queryOCSP(server, leaf, issuer)
+		if err == nil {
+			check.source = RevocationSourceOCSP
+			return check
+		}
+		problems = append(problems, fmt.Sprintf("OCSP %s: %v", server, err))
+	}
+
+	for _, url := range leaf.CRLDistributionPoints {
+		check, err := op.checkCRL(url, leaf, issuer)
+		if err == nil {
+			check.source = RevocationSourceCRL
+			return check
+		}
+		problems = append(problems, fmt.Sprintf("CRL %s: %v", url, err))
+	}
+
+	if len(problems) == 0 {
+		problems = append(problems, "certificate names no OCSP responder or CRL distribution point")
+	}
+	return revocationCheck{status: RevocationUnknown, err: errors.New(strings.Join(problems, "; "))}
+}
+
+// queryOCSP posts an OCSP request for leaf to a responder
+func (op *SSLOperation) queryOCSP(server string, leaf, issuer *x509.Certificate) (revocationCheck, error) {
+	request, err := newOCSPRequest(leaf, issuer)
+	if err != nil {
+		return revocationCheck{}, err
+	}
+
+	// Responses are per certificate, so the request is part of the key
+	key := server + "|" + hex.EncodeToString(request)
+	if cached, ok := op.revocations.get(key).([]byte); ok {
+		if check, err := parseOCSPResponse(cached, leaf, issuer); err == nil {
+			return check, nil
+		}
+	}
+
+	client := &http.Client{Timeout: op.timeout}
+	resp, err := client.Post(server, "application/ocsp-request", bytes.NewReader(request))
+	if err != nil {
+		return revocationCheck{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return revocationCheck{}, fmt.Errorf("responder returned HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, sslMaxOCSPResponse))
+	if err != nil {
+		return revocationCheck{}, err
+	}
+	check, err := parseOCSPResponse(body, leaf, issuer)
+	if err == nil {
+		op.revocations.put(key, body, check.nextUpdate)
+	}
+	return check, err
+}
+
+// newOCSPRequest encodes a request for one certificate, identified with SHA-1
+// hashes of the issuer's name and key as every responder supports
+func newOCSPRequest(leaf, issuer *x509.Certificate) ([]byte, error) {
+	certID, err := newOCSPCertID(leaf, issuer, oidSHA1)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ocspRequest{
+		TBSRequest: ocspTBSRequest{RequestList: []ocspRequestEntry{{Cert: certID}}},
+	})
+}
+
+// newOCSPCertID identifies leaf by its serial and hashes of its issuer's name
+// and key, computed with the hash algorithm
+func newOCSPCertID(leaf, issuer *x509.Certificate, algorithm asn1.ObjectIdentifier) (ocspCertID, error) {
+	hash, ok := ocspCertIDHashes[algorithm.String()]
+	if !ok || !hash.Available() {
+		return ocspCertID{}, fmt.Errorf("unsupported CertID hash algorithm %v", algorithm)
+	}
+	var publicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &publicKeyInfo); err != nil {
+		return ocspCertID{}, fmt.Errorf("parsing issuer public key: %v", err)
+	}
+	nameHash := hash.New()
+	nameHash.Write(issuer.RawSubject)
+	keyHash := hash.New()
+	keyHash.Write(publicKeyInfo.PublicKey.RightAlign())
+	return ocspCertID{
+		HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: algorithm, Parameters: asn1.NullRawValue},
+		NameHash:      nameHash.Sum(nil),
+		IssuerKeyHash: keyHash.Sum(nil),
+		SerialNumber:  leaf.SerialNumber,
+	}, nil
+}
+
+// parseOCSPResponse verifies a response's signature, by the issuer or a
+// responder it delegated to, and returns the status it gives for leaf
+func parseOCSPResponse(der []byte, leaf, issuer *x509.Certificate) (revocationCheck, error) {
+	var resp ocspResponse
+	if rest, err := asn1.Unmarshal(der, &resp); err != nil || len(rest) > 0 {
+		return revocationCheck{}, fmt.Errorf("malformed OCSP response")
+	}
+	if resp.Status != 0 {
+		return revocationCheck{}, fmt.Errorf("responder error status %d", resp.Status)
+	}
+	if !resp.Response.ResponseType.Equal(oidOCSPBasicResponse) {
+		return revocationCheck{}, fmt.Errorf("unsupported OCSP response type %v", resp.Response.ResponseType)
+	}
+	var basic ocspBasicResponse
+	if rest, err := asn1.Unmarshal(resp.Response.Response, &basic); err != nil || len(rest) > 0 {
+		return revocationCheck{}, fmt.Errorf("malformed basic OCSP response")
+	}
+
+	now := time.Now()
+	signer := issuer
+	if len(basic.Certificates) > 0 {
+		responder, err := x509.ParseCertificate(basic.Certificates[0].FullBytes)
+		if err != nil {
+			return revocationCheck{}, fmt.Errorf("parsing responder certificate: %v", err)
+		}
+		if !bytes.Equal(responder.Raw, issuer.Raw) {
+			if err := responder.CheckSignatureFrom(issuer); err != nil {
+				return revocationCheck{}, fmt.Errorf("responder certificate not issued by the certificate's issuer")
+			}
+			if !hasExtKeyUsage(responder, x509.ExtKeyUsageOCSPSigning) {
+				return revocationCheck{}, fmt.Errorf("responder certificate is not authorized to sign OCSP responses")
+			}
+			if now.Add(revocationClockSkew).Before(responder.NotBefore) || now.After(responder.NotAfter.Add(revocationClockSkew)) {
+				return revocationCheck{}, fmt.Errorf("responder certificate is only valid from %s to %s",
+					responder.NotBefore.Format(time.RFC3339), responder.NotAfter.Format(time.RFC3339))
+			}
+			signer = responder
+		}
+	}
+	algorithm, ok := ocspSignatureAlgorithms[basic.SignatureAlgorithm.Algorithm.String()]
+	if !ok {
+		return revocationCheck{}, fmt.Errorf("unsupported signature algorithm %v", basic.SignatureAlgorithm.Algorithm)
+	}
+	if err := signer.CheckSignature(algorithm, basic.TBSResponseData.Raw, basic.Signature.RightAlign()); err != nil {
+		return revocationCheck{}, fmt.Errorf("invalid response signature: %v", err)
+	}
+
+	for _, single := range basic.TBSResponseData.Responses {
+		if single.CertID.SerialNumber == nil || single.CertID.SerialNumber.Cmp(leaf.SerialNumber) != 0 {
+			continue
+		}
+		// The serial alone may belong to another issuer's certificate, so the
+		// CertID must match with the hash algorithm the responder chose
+		expected, err := newOCSPCertID(leaf, issuer, single.CertID.HashAlgorithm.Algorithm)
+		if err != nil || !bytes.Equal(single.CertID.NameHash, expected.NameHash) ||
+			!bytes.Equal(single.CertID.IssuerKeyHash, expected.IssuerKeyHash) {
+			continue
+		}
+
+		if single.ThisUpdate.After(now.Add(revocationClockSkew)) {
+			return revocationCheck{}, fmt.Errorf("response is not valid until %s", single.ThisUpdate.Format(time.RFC3339))
+		}
+		if !single.NextUpdate.IsZero() && now.After(single.NextUpdate.Add(revocationClockSkew)) {
+			return revocationCheck{}, fmt.Errorf("response expired on %s", single.NextUpdate.Format(time.RFC3339))
+		}
+
+		check := revocationCheck{thisUpdate: single.ThisUpdate, nextUpdate: single.NextUpdate}
+		switch {
+		case bool(single.Good):
+			check.status = RevocationGood
+		case !single.Revoked.RevocationTime.IsZero():
+			check.status = RevocationRevoked
+			check.revokedAt = single.Revoked.RevocationTime
+			check.reason = crlReasonName(int(single.Revoked.Reason))
+		default:
+			return revocationCheck{}, fmt.Errorf("responder does not know the certificate")
+		}
+		return check, nil
+	}
+	return revocationCheck{}, fmt.Errorf("response does not cover the certificate")
+}
+
+// checkCRL downloads a CRL, verifies it was signed by the issuer and looks
+// the certificate up in it
+func (op *SSLOperation) checkCRL(url string, leaf, issuer *x509.Certificate) (revocationCheck, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return revocationCheck{}, fmt.Errorf("unsupported distribution point")
+	}
+
+	crl, ok := op.revocations.get(url).(*x509.RevocationList)
+	if !ok || crl.CheckSignatureFrom(issuer) != nil {
+		var err error
+		if crl, err = op.fetchCRL(url, issuer); err != nil {
+			return revocationCheck{}, err
+		}
+		op.revocations.put(url, crl, crl.NextUpdate)
+	}
+
+	check := revocationCheck{status: RevocationGood, thisUpdate: crl.ThisUpdate, nextUpdate: crl.NextUpdate}
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+			check.status = RevocationRevoked
+			check.revokedAt = entry.RevocationTime
+			check.reason = crlReasonName(entry.ReasonCode)
+			break
+		}
+	}
+	return check, nil
+}
+
+// fetchCRL downloads a CRL and checks it is signed by the issuer and current
+func (op *SSLOperation) fetchCRL(url string, issuer *x509.Certificate) (*x509.RevocationList, error) {
+	client := &http.Client{Timeout: op.timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	der, err := io.ReadAll(io.LimitReader(resp.Body, sslMaxCRLSize))
+	if err != nil {
+		return nil, err
+	}
+
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		return nil, fmt.Errorf("parsing CRL: %v", err)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("CRL not signed by the certificate's issuer: %v", err)
+	}
+	if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate.Add(revocationClockSkew)) {
+		return nil, fmt.Errorf("CRL expired on %s", crl.NextUpdate.Format(time.RFC3339))
+	}
+	return crl, nil
+}
+
+func crlReasonName(code int) string {
+	if name, ok := crlReasons[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("reason %d", code)
+}
+
+func hasExtKeyUsage(cert *x509.Certificate, usage x509.ExtKeyUsage) bool {
+	for _, u := range cert.ExtKeyUsage {
+		if u == usage {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,465 @@
+package operations
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	testSerial         int64
+	testRevocationTime = time.Now().Add(-2 * time.Hour).UTC().Truncate(time.Second)
+)
+
+// testCA is a throwaway certificate authority
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  string
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key := newTestKey(t)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(atomic.AddInt64(&testSerial, 1)),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))}
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+// issue signs a certificate for template's key, filling in the common fields
+func (ca *testCA) issue(t *testing.T, template *x509.Certificate, key *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	template.SerialNumber = big.NewInt(atomic.AddInt64(&testSerial, 1))
+	if template.NotBefore.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Hour)
+		template.NotAfter = time.Now().Add(12 * time.Hour)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("issue certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert
+}
+
+// issueLeaf issues a server certificate for 127.0.0.1 naming the revocation URLs
+func (ca *testCA) issueLeaf(t *testing.T, key *ecdsa.PrivateKey, ocspURL, crlURL string) *x509.Certificate {
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost"},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ocspURL != "" {
+		template.OCSPServer = []string{ocspURL}
+	}
+	if crlURL != "" {
+		template.CRLDistributionPoints = []string{crlURL}
+	}
+	return ca.issue(t, template, key)
+}
+
+// ocspTemplate describes a response built by buildOCSPResponse
+type ocspTemplate struct {
+	status     string // good, revoked or unknown
+	hash       asn1.ObjectIdentifier
+	certIDCA   *testCA           // Issuer the CertID is computed for, the signer's CA by default
+	signer     *x509.Certificate // Included when it is not the CA
+	signerKey  *ecdsa.PrivateKey
+	nextUpdate time.Time
+}
+
+type testSingleResponse struct {
+	CertID     ocspCertID
+	Status     asn1.RawValue
+	ThisUpdate time.Time `asn1:"generalized"`
+	NextUpdate time.Time `asn1:"generalized,explicit,tag:0,optional"`
+}
+
+type testResponseData struct {
+	ResponderID asn1.RawValue
+	ProducedAt  time.Time `asn1:"generalized"`
+	Responses   []testSingleResponse
+}
+
+type testBasicResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+// buildOCSPResponse encodes and signs an OCSP response about leaf
+func buildOCSPResponse(t *testing.T, ca *testCA, leaf *x509.Certificate, tmpl ocspTemplate) []byte {
+	t.Helper()
+	if tmpl.hash == nil {
+		tmpl.hash = oidSHA1
+	}
+	if tmpl.certIDCA == nil {
+		tmpl.certIDCA = ca
+	}
+	if tmpl.signer == nil {
+		tmpl.signer, tmpl.signerKey = ca.cert, ca.key
+	}
+	if tmpl.nextUpdate.IsZero() {
+		tmpl.nextUpdate = time.Now().Add(time.Hour)
+	}
+
+	certID, err := newOCSPCertID(leaf, tmpl.certIDCA.cert, tmpl.hash)
+	if err != nil {
+		t.Fatalf("CertID: %v", err)
+	}
+	var status asn1.RawValue
+	switch tmpl.status {
+	case "good":
+		status = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0}
+	case "revoked":
+		revokedAt, _ := asn1.MarshalWithParams(testRevocationTime, "generalized")
+		reason, _ := asn1.MarshalWithParams(asn1.Enumerated(1), "explicit,tag:0")
+		status = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: append(revokedAt, reason...)}
+	default:
+		status = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2}
+	}
+
+	tbs, err := asn1.Marshal(testResponseData{
+		ResponderID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: tmpl.signer.RawSubject},
+		ProducedAt:  time.Now().UTC().Truncate(time.Second),
+		Responses: []testSingleResponse{{
+			CertID:     certID,
+			Status:     status,
+			ThisUpdate: time.Now().Add(-time.Minute).UTC().Truncate(time.Second),
+			NextUpdate: tmpl.nextUpdate.UTC().Truncate(time.Second),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("marshal response data: %v", err)
+	}
+	digest := sha256.Sum256(tbs)
+	signature, err := ecdsa.SignASN1(rand.Reader, tmpl.signerKey, digest[:])
+	if err != nil {
+		t.Fatalf("sign response: %v", err)
+	}
+
+	basic := testBasicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+		Signature:          asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+	}
+	if tmpl.signer != ca.cert {
+		basic.Certificates = []asn1.RawValue{{FullBytes: tmpl.signer.Raw}}
+	}
+	basicDER, err := asn1.Marshal(basic)
+	if err != nil {
+		t.Fatalf("marshal basic response: %v", err)
+	}
+	der, err := asn1.Marshal(ocspResponse{Response: ocspResponseBytes{ResponseType: oidOCSPBasicResponse, Response: basicDER}})
+	if err != nil {
+		t.Fatalf("marshal response: %v", err)
+	}
+	return der
+}
+
+// revocationServer is a local OCSP responder and CRL distribution point
+type revocationServer struct {
+	*httptest.Server
+	ocspHits int32
+	crlHits  int32
+
+	ocspStatus string // good, revoked, unknown, or error for HTTP 500
+	crlRevoked []*big.Int
+}
+
+func newRevocationServer(t *testing.T, ca *testCA, leaf **x509.Certificate) *revocationServer {
+	t.Helper()
+	rs := &revocationServer{ocspStatus: "good"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ocsp", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&rs.ocspHits, 1)
+		body, _ := io.ReadAll(r.Body)
+		var request ocspRequest
+		if _, err := asn1.Unmarshal(body, &request); err != nil || len(request.TBSRequest.RequestList) != 1 ||
+			request.TBSRequest.RequestList[0].Cert.SerialNumber.Cmp((*leaf).SerialNumber) != 0 {
+			t.Errorf("unexpected OCSP request: %v", err)
+		}
+		if r.Header.Get("Content-Type") != "application/ocsp-request" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		if rs.ocspStatus == "error" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(buildOCSPResponse(t, ca, *leaf, ocspTemplate{status: rs.ocspStatus}))
+	})
+	mux.HandleFunc("/crl", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&rs.crlHits, 1)
+		template := &x509.RevocationList{
+			Number:     big.NewInt(1),
+			ThisUpdate: time.Now().Add(-time.Minute),
+			NextUpdate: time.Now().Add(time.Hour),
+		}
+		for _, serial := range rs.crlRevoked {
+			template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+				SerialNumber:   serial,
+				RevocationTime: testRevocationTime,
+				ReasonCode:     4,
+			})
+		}
+		der, err := x509.CreateRevocationList(rand.Reader, template, ca.cert, ca.key)
+		if err != nil {
+			t.Errorf("create CRL: %v", err)
+		}
+		w.Write(der)
+	})
+	rs.Server = httptest.NewServer(mux)
+	t.Cleanup(rs.Close)
+	return rs
+}
+
+// hits reports how often the OCSP responder and the CRL were fetched
+func (rs *revocationServer) hits() (int32, int32) {
+	return atomic.LoadInt32(&rs.ocspHits), atomic.LoadInt32(&rs.crlHits)
+}
+
+// serveTLS accepts TLS handshakes with leaf, stapling staple when set
+func serveTLS(t *testing.T, leaf *x509.Certificate, key *ecdsa.PrivateKey, staple []byte) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	config := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{leaf.Raw}, PrivateKey: key, OCSPStaple: staple}}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			tlsConn := tls.Server(conn, config)
+			tlsConn.Handshake()
+			tlsConn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func newTestSSLOperation() *SSLOperation {
+	op := NewSSLOperation(5 * time.Second)
+	op.revocations = newRevocationCache()
+	return op
+}
+
+func TestSSLRevocationSources(t *testing.T) {
+	tests := []struct {
+		name       string
+		ocsp       string // Status the responder answers, error for HTTP 500
+		crlRevoked bool
+		staple     string // Status of a stapled response
+		noURLs     bool
+
+		wantStatus  string
+		wantSource  string
+		wantReason  string
+		wantSuccess bool
+		wantError   string
+		ocspHits    int32
+		crlHits     int32
+	}{
+		{name: "ocsp good", ocsp: "good", wantStatus: RevocationGood, wantSource: RevocationSourceOCSP, wantSuccess: true, ocspHits: 1},
+		{name: "ocsp revoked", ocsp: "revoked", wantStatus: RevocationRevoked, wantSource: RevocationSourceOCSP,
+			wantReason: "key compromise", wantError: "certificate was revoked", ocspHits: 1},
+		{name: "ocsp unknown falls back to crl", ocsp: "unknown", wantStatus: RevocationGood, wantSource: RevocationSourceCRL,
+			wantSuccess: true, ocspHits: 1, crlHits: 1},
+		{name: "crl revoked", ocsp: "error", crlRevoked: true, wantStatus: RevocationRevoked, wantSource: RevocationSourceCRL,
+			wantReason: "superseded", wantError: "certificate was revoked", ocspHits: 1, crlHits: 1},
+		{name: "stapled revoked", ocsp: "good", staple: "revoked", wantStatus: RevocationRevoked, wantSource: RevocationSourceStapled,
+			wantReason: "key compromise", wantError: "certificate was revoked"},
+		{name: "stapled good", ocsp: "revoked", staple: "good", wantStatus: RevocationGood, wantSource: RevocationSourceStapled, wantSuccess: true},
+		{name: "no urls", noURLs: true, wantStatus: RevocationUnknown, wantSuccess: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ca := newTestCA(t, "Test CA")
+			key := newTestKey(t)
+			var leaf *x509.Certificate
+			rs := newRevocationServer(t, ca, &leaf)
+			rs.ocspStatus = tt.ocsp
+
+			if tt.noURLs {
+				leaf = ca.issueLeaf(t, key, "", "")
+			} else {
+				leaf = ca.issueLeaf(t, key, rs.URL+"/ocsp", rs.URL+"/crl")
+			}
+			if tt.crlRevoked {
+				rs.crlRevoked = []*big.Int{leaf.SerialNumber}
+			}
+			var staple []byte
+			if tt.staple != "" {
+				staple = buildOCSPResponse(t, ca, leaf, ocspTemplate{status: tt.staple})
+			}
+
+			result, err := newTestSSLOperation().ExecuteWithOptions(serveTLS(t, leaf, key, staple), SSLOptions{CABundle: ca.pem})
+			if err != nil {
+				t.Fatalf("ExecuteWithOptions: %v", err)
+			}
+			if result.SSLRevocationStatus != tt.wantStatus || result.SSLRevocationSource != tt.wantSource {
+				t.Errorf("revocation = %q from %q, want %q from %q (error %q)", result.SSLRevocationStatus,
+					result.SSLRevocationSource, tt.wantStatus, tt.wantSource, result.SSLRevocationError)
+			}
+			if result.SSLRevocationReason != tt.wantReason {
+				t.Errorf("SSLRevocationReason = %q, want %q", result.SSLRevocationReason, tt.wantReason)
+			}
+			if tt.wantStatus == RevocationRevoked && !result.SSLRevokedAt.Equal(testRevocationTime) {
+				t.Errorf("SSLRevokedAt = %v, want %v", result.SSLRevokedAt, testRevocationTime)
+			}
+			if tt.wantStatus != RevocationUnknown && (result.SSLRevocationThisUpdate.IsZero() || result.SSLRevocationNextUpdate.IsZero()) {
+				t.Errorf("update times not reported: %v, %v", result.SSLRevocationThisUpdate, result.SSLRevocationNextUpdate)
+			}
+			if tt.wantStatus == RevocationUnknown && result.SSLRevocationError == "" {
+				t.Errorf("SSLRevocationError is empty for an unknown status")
+			}
+			if result.Success != tt.wantSuccess || !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("Success = %v, Error = %q; want %v, %q", result.Success, result.Error, tt.wantSuccess, tt.wantError)
+			}
+			if ocspHits, crlHits := rs.hits(); ocspHits != tt.ocspHits || crlHits != tt.crlHits {
+				t.Errorf("responder hits = %d OCSP, %d CRL; want %d, %d", ocspHits, crlHits, tt.ocspHits, tt.crlHits)
+			}
+		})
+	}
+}
+
+func TestSSLRevocationCacheAndSkip(t *testing.T) {
+	ca := newTestCA(t, "Test CA")
+	key := newTestKey(t)
+	var leaf *x509.Certificate
+	rs := newRevocationServer(t, ca, &leaf)
+	leaf = ca.issueLeaf(t, key, rs.URL+"/ocsp", "")
+	address := serveTLS(t, leaf, key, nil)
+
+	op := newTestSSLOperation()
+	for i := 0; i < 3; i++ {
+		result, _ := op.ExecuteWithOptions(address, SSLOptions{CABundle: ca.pem})
+		if result.SSLRevocationStatus != RevocationGood {
+			t.Fatalf("check %d: status %q (%s)", i, result.SSLRevocationStatus, result.SSLRevocationError)
+		}
+	}
+	if ocspHits, _ := rs.hits(); ocspHits != 1 {
+		t.Errorf("OCSP responder queried %d times, want the response cached until its next update", ocspHits)
+	}
+
+	// CRLs are shared by all certificates of the CA
+	rs.ocspStatus = "error"
+	other := ca.issueLeaf(t, key, rs.URL+"/ocsp", rs.URL+"/crl")
+	leaf = other
+	for i := 0; i < 2; i++ {
+		result, _ := op.ExecuteWithOptions(serveTLS(t, other, key, nil), SSLOptions{CABundle: ca.pem})
+		if result.SSLRevocationSource != RevocationSourceCRL {
+			t.Fatalf("check %d: source %q (%s)", i, result.SSLRevocationSource, result.SSLRevocationError)
+		}
+	}
+	if _, crlHits := rs.hits(); crlHits != 1 {
+		t.Errorf("CRL downloaded %d times, want it cached", crlHits)
+	}
+
+	before, _ := rs.hits()
+	result, _ := newTestSSLOperation().ExecuteWithOptions(address, SSLOptions{CABundle: ca.pem, SkipRevocation: true})
+	if after, _ := rs.hits(); result.SSLRevocationStatus != "" || after != before {
+		t.Errorf("SkipRevocation: status %q after %d queries, want no check", result.SSLRevocationStatus, after-before)
+	}
+}
+
+func TestParseOCSPResponseTrust(t *testing.T) {
+	ca := newTestCA(t, "Test CA")
+	// Another CA with the same name issues a certificate with the same serial
+	impostor := newTestCA(t, "Test CA")
+	leaf := ca.issueLeaf(t, newTestKey(t), "", "")
+
+	responderKey := newTestKey(t)
+	delegate := func(usage []x509.ExtKeyUsage, notAfter time.Time) *x509.Certificate {
+		return ca.issue(t, &x509.Certificate{
+			Subject:     pkix.Name{CommonName: "Test OCSP Responder"},
+			ExtKeyUsage: usage,
+			NotBefore:   time.Now().Add(-48 * time.Hour),
+			NotAfter:    notAfter,
+		}, responderKey)
+	}
+	ocspSigning := []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning}
+
+	tests := []struct {
+		name      string
+		tmpl      ocspTemplate
+		wantError string
+	}{
+		{name: "sha1 certid", tmpl: ocspTemplate{status: "good"}},
+		{name: "sha256 certid", tmpl: ocspTemplate{status: "good", hash: oidSHA256}},
+		{name: "other issuer sha1", tmpl: ocspTemplate{status: "good", certIDCA: impostor}, wantError: "does not cover"},
+		{name: "other issuer sha256", tmpl: ocspTemplate{status: "good", hash: oidSHA256, certIDCA: impostor}, wantError: "does not cover"},
+		{name: "delegated responder", tmpl: ocspTemplate{status: "revoked",
+			signer: delegate(ocspSigning, time.Now().Add(time.Hour)), signerKey: responderKey}},
+		{name: "expired responder", tmpl: ocspTemplate{status: "good",
+			signer: delegate(ocspSigning, time.Now().Add(-24*time.Hour)), signerKey: responderKey}, wantError: "responder certificate is only valid"},
+		{name: "responder without ocsp signing", tmpl: ocspTemplate{status: "good",
+			signer: delegate(nil, time.Now().Add(time.Hour)), signerKey: responderKey}, wantError: "not authorized"},
+		{name: "wrong signer", tmpl: ocspTemplate{status: "good", signer: ca.cert, signerKey: impostor.key}, wantError: "invalid response signature"},
+		{name: "expired response", tmpl: ocspTemplate{status: "good", nextUpdate: time.Now().Add(-time.Hour)}, wantError: "response expired"},
+		{name: "unknown", tmpl: ocspTemplate{status: "unknown"}, wantError: "does not know"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := parseOCSPResponse(buildOCSPResponse(t, ca, leaf, tt.tmpl), leaf, ca.cert)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("error = %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseOCSPResponse: %v", err)
+			}
+			if check.status != tt.tmpl.status {
+				t.Errorf("status = %q, want %q", check.status, tt.tmpl.status)
+			}
+		})
+	}
+}
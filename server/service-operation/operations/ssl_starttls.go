@@ -0,0 +1,237 @@
+package operations
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"time"
+)
+
+// Protocols an SSL check connects with. tls starts the handshake right away,
+// the others negotiate STARTTLS on a plain connection first.
+const (
+	SSLProtocolTLS      = "tls"
+	SSLProtocolSMTP     = "smtp"
+	SSLProtocolIMAP     = "imap"
+	SSLProtocolPOP3     = "pop3"
+	SSLProtocolFTP      = "ftp"
+	SSLProtocolLDAP     = "ldap"
+	SSLProtocolPostgres = "postgres"
+)
+
+var defaultSSLPorts = map[string]int{
+	SSLProtocolTLS:      443,
+	SSLProtocolSMTP:     25,
+	SSLProtocolIMAP:     143,
+	SSLProtocolPOP3:     110,
+	SSLProtocolFTP:      21,
+	SSLProtocolLDAP:     389,
+	SSLProtocolPostgres: 5432,
+}
+
+// ldapStartTLSOID names the LDAP StartTLS extended operation (RFC 4511)
+const ldapStartTLSOID = "1.3.6.1.4.1.1466.20037"
+
+// dialTLS connects and performs the TLS handshake, negotiating STARTTLS
+// first for protocols that upgrade a plain connection
+func (op *SSLOperation) dialTLS(protocol, address string, config *tls.Config) (*tls.Conn, error) {
+	dialer := &net.Dialer{Timeout: op.timeout}
+	if protocol == SSLProtocolTLS {
+		return tls.DialWithDialer(dialer, "tcp", address, config)
+	}
+
+	conn, err := dialer.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if op.timeout > 0 {
+		conn.SetDeadline(time.Now().Add(op.timeout))
+	}
+	tlsConn, err := startTLSConn(conn, protocol, config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("STARTTLS over %s failed: %v", protocol, err)
+	}
+	conn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
+
+// startTLSConn asks the server to upgrade a plain connection and performs the
+// TLS handshake. Mail protocols reuse the mail probe sessions, which require
+// STARTTLS to be advertised and refuse data sent ahead of the handshake.
+func startTLSConn(conn net.Conn, protocol string, config *tls.Config) (*tls.Conn, error) {
+	switch protocol {
+	case SSLProtocolSMTP, SSLProtocolIMAP, SSLProtocolPOP3:
+		session := &mailSession{
+			host:      config.ServerName,
+			opts:      MailServerOptions{TLS: MailTLSSTARTTLS},
+			tlsConfig: config,
+		}
+		session.setConn(conn)
+		var err error
+		switch protocol {
+		case SSLProtocolSMTP:
+			err = session.smtpOpen()
+		case SSLProtocolIMAP:
+			_, err = session.imapOpen()
+		case SSLProtocolPOP3:
+			err = session.pop3Open()
+		}
+		if err != nil {
+			return nil, err
+		}
+		return session.conn.(*tls.Conn), nil
+
+	case SSLProtocolFTP:
+		reader := bufio.NewReader(conn)
+		text := textproto.NewReader(reader)
+		if code, message, err := text.ReadResponse(220); err != nil {
+			return nil, fmt.Errorf("unexpected greeting: %d %s", code, sanitizeBanner([]byte(firstLine(message))))
+		}
+		if _, err := conn.Write([]byte("AUTH TLS\r\n")); err != nil {
+			return nil, err
+		}
+		if code, message, err := text.ReadResponse(234); err != nil {
+			return nil, fmt.Errorf("AUTH TLS refused: %d %s", code, sanitizeBanner([]byte(firstLine(message))))
+		}
+		if reader.Buffered() > 0 {
+			return nil, fmt.Errorf("server sent data before the TLS handshake")
+		}
+
+	case SSLProtocolLDAP:
+		if err := ldapStartTLS(conn); err != nil {
+			return nil, err
+		}
+
+	case SSLProtocolPostgres:
+		accepted, err := postgresRequestSSL(conn)
+		if err != nil {
+			return nil, err
+		}
+		if !accepted {
+			return nil, fmt.Errorf("server does not accept SSL connections")
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported protocol %q", protocol)
+	}
+
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// ldapStartTLS sends the StartTLS extended request and checks its result code
+func ldapStartTLS(conn net.Conn) error {
+	// ExtendedRequest [APPLICATION 23] with the OID as requestName [0]
+	request := berTLV(0x80, []byte(ldapStartTLSOID))
+	request = berTLV(0x77, request)
+	request = berTLV(0x30, append([]byte{0x02, 0x01, 0x01}, request...)) // messageID 1
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	// The response is read unbuffered, nothing may follow it before the handshake
+	tag, message, err := readBER(conn)
+	if err != nil {
+		return fmt.Errorf("reading StartTLS response: %v", err)
+	}
+	if tag != 0x30 {
+		return fmt.Errorf("not an LDAP server (message tag 0x%02x)", tag)
+	}
+	_, _, rest, ok := parseBER(message) // messageID
+	if !ok {
+		return fmt.Errorf("malformed StartTLS response")
+	}
+	tag, response, _, ok := parseBER(rest)
+	if !ok || tag != 0x78 { // ExtendedResponse [APPLICATION 24]
+		return fmt.Errorf("unexpected StartTLS response")
+	}
+	tag, code, rest, ok := parseBER(response)
+	if !ok || tag != 0x0a || len(code) != 1 { // resultCode ENUMERATED
+		return fmt.Errorf("malformed StartTLS result")
+	}
+	if code[0] != 0 {
+		diagnostic := ""
+		if _, _, rest, ok = parseBER(rest); ok { // matchedDN
+			if _, text, _, ok := parseBER(rest); ok && len(text) > 0 {
+				diagnostic = ": " + sanitizeBanner(text)
+			}
+		}
+		return fmt.Errorf("server refused StartTLS (result code %d)%s", code[0], diagnostic)
+	}
+	return nil
+}
+
+// berTLV encodes a BER element with a definite length
+func berTLV(tag byte, content []byte) []byte {
+	element := []byte{tag}
+	switch n := len(content); {
+	case n < 0x80:
+		element = append(element, byte(n))
+	case n <= 0xff:
+		element = append(element, 0x81, byte(n))
+	default:
+		element = append(element, 0x82, byte(n>>8), byte(n))
+	}
+	return append(element, content...)
+}
+
+// readBER reads one BER element with a definite length from r
+func readBER(r io.Reader) (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := int(header[1])
+	if length&0x80 != 0 {
+		octets := length & 0x7f
+		if octets == 0 || octets > 3 {
+			return 0, nil, fmt.Errorf("unsupported BER length encoding")
+		}
+		buf := make([]byte, octets)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return 0, nil, err
+		}
+		length = 0
+		for _, b := range buf {
+			length = length<<8 | int(b)
+		}
+	}
+	if length > databaseMaxMessage {
+		return 0, nil, fmt.Errorf("BER element of %d bytes is too large", length)
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return 0, nil, err
+	}
+	return header[0], content, nil
+}
+
+// parseBER splits the first BER element off data
+func parseBER(data []byte) (byte, []byte, []byte, bool) {
+	if len(data) < 2 {
+		return 0, nil, nil, false
+	}
+	tag, length, data := data[0], int(data[1]), data[2:]
+	if length&0x80 != 0 {
+		octets := length & 0x7f
+		if octets == 0 || octets > 3 || len(data) < octets {
+			return 0, nil, nil, false
+		}
+		length = 0
+		for _, b := range data[:octets] {
+			length = length<<8 | int(b)
+		}
+		data = data[octets:]
+	}
+	if len(data) < length {
+		return 0, nil, nil, false
+	}
+	return tag, data[:length], data[length:], true
+}
@@ -6,7 +6,8 @@ import (
 	"time"
 )
 
-// validateCertificate performs comprehensive certificate validation
+// validateCertificate validates the leaf certificate. Trust, signatures and
+// extended key usage are checked with the chain in verifyChain.
 func (op *SSLOperation) validateCertificate(cert *x509.Certificate, hostname string) error {
 	now := time.Now()
 	
@@ -28,34 +29,6 @@ func (op *SSLOperation) validateCertificate(cert *x509.Certificate, hostname str
 		return fmt.Errorf("certificate missing required digital signature key usage")
 	}
 	
-	// Check if certificate is self-signed (basic check)
-	if cert.Issuer.CommonName == cert.Subject.CommonName && len(cert.Subject.Organization) == 0 {
-		return fmt.Errorf("certificate appears to be self-signed")
-	}
-	
-	// Validate certificate chain if intermediate certificates are present
-	if err := op.validateCertificateChain(cert); err != nil {
-		return fmt.Errorf("certificate chain validation failed: %v", err)
-	}
-	
-	return nil
-}
-
-// validateCertificateChain performs basic certificate chain validation
-func (op *SSLOperation) validateCertificateChain(cert *x509.Certificate) error {
-	// Check if the certificate has proper extensions for SSL/TLS
-	hasServerAuth := false
-	for _, usage := range cert.ExtKeyUsage {
-		if usage == x509.ExtKeyUsageServerAuth {
-			hasServerAuth = true
-			break
-		}
-	}
-	
-	if !hasServerAuth {
-		return fmt.Errorf("certificate does not have server authentication extension")
-	}
-	
 	// Check certificate version (should be v3 for modern certificates)
 	if cert.Version < 3 {
 		return fmt.Errorf("certificate version %d is outdated (should be v3)", cert.Version)
@@ -0,0 +1,140 @@
+package operations
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"service-operation/types"
+)
+
+type statusCodeRange struct {
+	min int
+	max int
+}
+
+// StatusCodeSet is a parsed services.status_codes rule such as "200-299,301,418"
+type StatusCodeSet struct {
+	rule   string
+	ranges []statusCodeRange
+}
+
+// ParseStatusCodes parses a comma separated list of codes and code ranges.
+// Class shorthands like "2xx" are accepted as well.
+func ParseStatusCodes(spec string) (*StatusCodeSet, error) {
+	set := &StatusCodeSet{}
+	var parts []string
+
+	for _, part := range strings.FieldsFunc(spec, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' '
+	}) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		r, err := parseStatusCodeRange(part)
+		if err != nil {
+			return nil, err
+		}
+		set.ranges = append(set.ranges, r)
+		parts = append(parts, part)
+	}
+
+	if len(set.ranges) == 0 {
+		return nil, fmt.Errorf("no status codes in %q", spec)
+	}
+
+	set.rule = strings.Join(parts, ",")
+	return set, nil
+}
+
+func parseStatusCodeRange(part string) (statusCodeRange, error) {
+	lower := strings.ToLower(part)
+	if len(lower) == 3 && strings.HasSuffix(lower, "xx") {
+		class, err := strconv.Atoi(lower[:1])
+		if err != nil || class < 1 || class > 5 {
+			return statusCodeRange{}, fmt.Errorf("invalid status code class %q", part)
+		}
+		return statusCodeRange{min: class * 100, max: class*100 + 99}, nil
+	}
+
+	if idx := strings.Index(part, "-"); idx > 0 {
+		min, err := parseStatusCode(part[:idx])
+		if err != nil {
+			return statusCodeRange{}, err
+		}
+		max, err := parseStatusCode(part[idx+1:])
+		if err != nil {
+			return statusCodeRange{}, err
+		}
+		if min > max {
+			return statusCodeRange{}, fmt.Errorf("invalid status code range %q", part)
+		}
+		return statusCodeRange{min: min, max: max}, nil
+	}
+
+	code, err := parseStatusCode(part)
+	if err != nil {
+		return statusCodeRange{}, err
+	}
+	return statusCodeRange{min: code, max: code}, nil
+}
+
+func parseStatusCode(value string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || code < 100 || code > 599 {
+		return 0, fmt.Errorf("invalid status code %q", value)
+	}
+	return code, nil
+}
+
+// Contains reports whether the status code is accepted by the rule
+func (s *StatusCodeSet) Contains(code int) bool {
+	for _, r := range s.ranges {
+		if code >= r.min && code <= r.max {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the normalized rule
+func (s *StatusCodeSet) String() string {
+	return s.rule
+}
+
+// CheckStatusCodes re-evaluates a completed HTTP result against an accepted
+// status code rule, replacing the default 2xx/3xx success range
+func (h *HTTPOperation) CheckStatusCodes(result *types.OperationResult, spec string) {
+	if result == nil || strings.TrimSpace(spec) == "" {
+		return
+	}
+
+	set, err := ParseStatusCodes(spec)
+	if err != nil {
+		result.StatusCodes = spec
+		if result.HTTPStatusCode > 0 {
+			result.Success = false
+			result.Error = fmt.Sprintf("⚠️ Status code rule error: %v", err)
+		}
+		return
+	}
+
+	result.StatusCodes = set.String()
+
+	// Connection-level failures have no status code to evaluate
+	if result.HTTPStatusCode == 0 {
+		return
+	}
+
+	code := result.HTTPStatusCode
+	status := fmt.Sprintf("%d %s", code, http.StatusText(code))
+	result.Success = set.Contains(code)
+	if result.Success {
+		result.Error = httpStatusMessage(code, status, true)
+	} else {
+		result.Error = fmt.Sprintf("%s (expected %s)", httpStatusMessage(code, status, false), set.String())
+	}
+}
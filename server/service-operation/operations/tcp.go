@@ -1,23 +1,58 @@
-
 package operations
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"strconv"
+	"strings"
 	"time"
 
 	"service-operation/types"
 )
 
+// Payload formats supported by services.tcp_send_format
+const (
+	TCPPayloadText = "text"
+	TCPPayloadHex  = "hex"
+)
+
+// tcpMaxResponse caps how much of a response or banner is read
+const tcpMaxResponse = 4096
+
+// tcpMaxBanner caps the banner kept in the result
+const tcpMaxBanner = 512
+
 type TCPOperation struct {
 	timeout time.Duration
 }
 
+// TCPProbeOptions configures what is exchanged once the connection is open.
+// With all fields empty the check only verifies that the port accepts connections.
+type TCPProbeOptions struct {
+	Send        string        // Payload written after connecting
+	SendFormat  string        // text (with \r, \n, \t, \0 and \\ escapes) or hex
+	Expect      KeywordCheck  // Response match, Keyword empty to accept any response
+	ReadBanner  bool          // Read what the server sends even without a payload or expectation
+	ReadTimeout time.Duration // Defaults to the operation timeout
+}
+
+// reads reports whether the probe needs to read from the connection
+func (o TCPProbeOptions) reads() bool {
+	return o.Send != "" || o.Expect.Keyword != "" || o.ReadBanner
+}
+
 func NewTCPOperation(timeout time.Duration) *TCPOperation {
 	return &TCPOperation{timeout: timeout}
 }
 
 func (t *TCPOperation) Execute(host string, port int) (*types.OperationResult, error) {
+	return t.ExecuteWithOptions(host, port, TCPProbeOptions{})
+}
+
+func (t *TCPOperation) ExecuteWithOptions(host string, port int, opts TCPProbeOptions) (*types.OperationResult, error) {
 	result := &types.OperationResult{
 		Type:      types.OperationTCP,
 		Host:      host,
@@ -25,11 +60,16 @@ func (t *TCPOperation) Execute(host string, port int) (*types.OperationResult, e
 		StartTime: time.Now(),
 	}
 
+	payload, err := decodeTCPPayload(opts.Send, opts.SendFormat)
+	if err != nil {
+		return nil, err
+	}
+
 	start := time.Now()
-	
-	address := fmt.Sprintf("%s:%d", host, port)
+
+	address := net.JoinHostPort(host, strconv.Itoa(port))
 	conn, err := net.DialTimeout("tcp", address, t.timeout)
-	
+
 	result.ResponseTime = time.Since(start)
 	result.EndTime = time.Now()
 
@@ -37,13 +77,156 @@ func (t *TCPOperation) Execute(host string, port int) (*types.OperationResult, e
 		result.Error = err.Error()
 		result.TCPConnected = false
 		result.Success = false
-		result.Details = fmt.Sprintf("Failed to connect to %s:%d - %s", host, port, err.Error())
-	} else {
-		conn.Close()
-		result.TCPConnected = true
-		result.Success = true
-		result.Details = fmt.Sprintf("Successfully connected to %s:%d", host, port)
+		result.Details = fmt.Sprintf("Failed to connect to %s - %s", address, err.Error())
+		return result, nil
+	}
+	defer conn.Close()
+
+	result.TCPConnected = true
+	result.Success = true
+	result.Details = fmt.Sprintf("Successfully connected to %s", address)
+
+	if !opts.reads() {
+		return result, nil
 	}
 
+	t.exchange(conn, address, payload, opts, result)
+	result.EndTime = time.Now()
+	result.TCPExchangeTime = result.EndTime.Sub(start) - result.ResponseTime
+
 	return result, nil
-}
\ No newline at end of file
+}
+
+// exchange writes the payload and reads the reply, stopping as soon as the
+// expectation is met, the server closes the connection or the read times out
+func (t *TCPOperation) exchange(conn net.Conn, address string, payload []byte, opts TCPProbeOptions, result *types.OperationResult) {
+	readTimeout := opts.ReadTimeout
+	if readTimeout <= 0 {
+		readTimeout = t.timeout
+	}
+	conn.SetDeadline(time.Now().Add(readTimeout))
+
+	if len(payload) > 0 {
+		if _, err := conn.Write(payload); err != nil {
+			result.Success = false
+			result.Error = fmt.Sprintf("failed to send payload: %v", err)
+			result.Details = fmt.Sprintf("Connected to %s but sending failed - %v", address, err)
+			return
+		}
+	}
+
+	expect := opts.Expect
+	stopOnMatch := expect.Keyword != "" && strings.ToLower(expect.Mode) != KeywordModeNotContains
+
+	var response []byte
+	var readErr error
+	buf := make([]byte, 1024)
+	for len(response) < tcpMaxResponse {
+		n, err := conn.Read(buf)
+		response = append(response, buf[:n]...)
+		if err != nil {
+			readErr = err
+			break
+		}
+		if stopOnMatch {
+			if found, _, _ := expect.Evaluate(string(response)); found {
+				break
+			}
+		} else if expect.Keyword == "" {
+			// Without an expectation the first chunk is the banner
+			break
+		}
+	}
+	if len(response) > tcpMaxResponse {
+		response = response[:tcpMaxResponse]
+	}
+
+	result.TCPBanner = sanitizeBanner(response)
+
+	var netErr net.Error
+	timedOut := errors.As(readErr, &netErr) && netErr.Timeout()
+	if readErr != nil && readErr != io.EOF && !timedOut {
+		result.Success = false
+		result.Error = fmt.Sprintf("failed to read response: %v", readErr)
+		result.Details = fmt.Sprintf("Connected to %s but reading failed - %v", address, readErr)
+		return
+	}
+
+	if expect.Keyword == "" {
+		// A payload that gets no reply at all points at a hung service
+		if len(payload) > 0 && len(response) == 0 {
+			result.Success = false
+			result.Error = fmt.Sprintf("no response within %v", readTimeout)
+			result.Details = fmt.Sprintf("Connected to %s but got no response to the payload", address)
+			return
+		}
+		result.Details = fmt.Sprintf("Successfully connected to %s, read %d bytes", address, len(response))
+		return
+	}
+
+	found, _, err := expect.Evaluate(string(response))
+	result.TCPExpectMatched = expect.Passed(found)
+	switch {
+	case err != nil:
+		result.Success = false
+		result.Error = err.Error()
+	case !result.TCPExpectMatched && len(response) == 0:
+		result.Success = false
+		result.Error = fmt.Sprintf("no response within %v", readTimeout)
+	case !result.TCPExpectMatched && strings.ToLower(expect.Mode) == KeywordModeNotContains:
+		result.Success = false
+		result.Error = fmt.Sprintf("response contains forbidden \"%s\"", expect.Keyword)
+	case !result.TCPExpectMatched && strings.ToLower(expect.Mode) == KeywordModeRegex:
+		result.Success = false
+		result.Error = fmt.Sprintf("response does not match pattern \"%s\"", expect.Keyword)
+	case !result.TCPExpectMatched:
+		result.Success = false
+		result.Error = fmt.Sprintf("response does not contain \"%s\"", expect.Keyword)
+	}
+
+	if result.Success {
+		result.Details = fmt.Sprintf("Successfully connected to %s, response matched", address)
+	} else {
+		result.Details = fmt.Sprintf("Connected to %s but %s", address, result.Error)
+	}
+}
+
+// decodeTCPPayload turns a configured payload into the bytes to send
+func decodeTCPPayload(payload, format string) ([]byte, error) {
+	if payload == "" {
+		return nil, nil
+	}
+
+	switch strings.ToLower(format) {
+	case "", TCPPayloadText:
+		return []byte(tcpEscapes.Replace(payload)), nil
+	case TCPPayloadHex:
+		cleaned := strings.NewReplacer(" ", "", ":", "", "\n", "", "\r", "", "\t", "").Replace(payload)
+		cleaned = strings.TrimPrefix(strings.TrimPrefix(cleaned, "0x"), "0X")
+		data, err := hex.DecodeString(cleaned)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex payload: %v", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unsupported payload format %q", format)
+	}
+}
+
+// tcpEscapes lets text payloads carry line endings, e.g. "PING\r\n"
+var tcpEscapes = strings.NewReplacer(`\r`, "\r", `\n`, "\n", `\t`, "\t", `\0`, "\x00", `\\`, `\`)
+
+// sanitizeBanner makes a response safe to store and display, escaping control
+// characters and truncating long replies
+func sanitizeBanner(data []byte) string {
+	truncated := len(data) > tcpMaxBanner
+	if truncated {
+		data = data[:tcpMaxBanner]
+	}
+	text := strconv.QuoteToGraphic(string(data))
+	text = text[1 : len(text)-1]
+	if truncated {
+		text += "..."
+	}
+	return text
+}
@@ -0,0 +1,152 @@
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"service-operation/ping"
+	"service-operation/types"
+)
+
+// maxTraceProbeTimeout bounds the wait for each probe; a trace sends several
+// rounds and every round waits for its slowest probe
+const maxTraceProbeTimeout = 2 * time.Second
+
+type TracerouteOperation struct {
+	timeout time.Duration
+}
+
+// TracerouteOptions configures a traceroute; zero values use the ping package defaults
+type TracerouteOptions struct {
+	Protocol string // icmp, udp or tcp
+	Port     int    // Destination port of UDP and TCP probes
+	Family   string // auto, ipv4 or ipv6
+	MaxHops  int
+	Rounds   int // Probes per hop
+}
+
+func NewTracerouteOperation(timeout time.Duration) *TracerouteOperation {
+	return &TracerouteOperation{timeout: timeout}
+}
+
+func (t *TracerouteOperation) Execute(host string) (*types.OperationResult, error) {
+	return t.ExecuteWithOptions(host, TracerouteOptions{})
+}
+
+// ExecuteWithOptions traces the path to a host. The result fails when the
+// destination never answered, with the error naming where the path stops.
+func (t *TracerouteOperation) ExecuteWithOptions(host string, opts TracerouteOptions) (*types.OperationResult, error) {
+	if host == "" {
+		return nil, fmt.Errorf("host cannot be empty")
+	}
+
+	probeTimeout := t.timeout
+	if probeTimeout <= 0 || probeTimeout > maxTraceProbeTimeout {
+		probeTimeout = maxTraceProbeTimeout
+	}
+
+	start := time.Now()
+	traceResult, err := ping.NewTracer(probeTimeout).Trace(host, ping.TraceOptions{
+		Protocol: opts.Protocol,
+		Port:     opts.Port,
+		Family:   opts.Family,
+		MaxHops:  opts.MaxHops,
+		Rounds:   opts.Rounds,
+	})
+	if errors.Is(err, ping.ErrInvalidOptions) {
+		return nil, err
+	}
+	if err != nil {
+		return &types.OperationResult{
+			Type:          types.OperationTraceroute,
+			Host:          host,
+			Port:          opts.Port,
+			TraceProtocol: opts.Protocol,
+			Error:         err.Error(),
+			Details:       fmt.Sprintf("❌ TRACEROUTE FAILED - %s | Target: %s", err.Error(), host),
+			StartTime:     start,
+			EndTime:       time.Now(),
+		}, nil
+	}
+
+	result := &types.OperationResult{
+		Type:          types.OperationTraceroute,
+		Host:          host,
+		Port:          traceResult.Port,
+		Success:       traceResult.Success,
+		Error:         traceResult.Error,
+		TraceProtocol: traceResult.Protocol,
+		TraceAddress:  traceResult.Address,
+		TraceFamily:   traceResult.Family,
+		TraceReached:  traceResult.Reached,
+		StartTime:     traceResult.StartTime,
+		EndTime:       traceResult.EndTime,
+	}
+	for _, hop := range traceResult.Hops {
+		result.TraceHops = append(result.TraceHops, types.TraceHop{
+			TTL:         hop.TTL,
+			Address:     hop.Address,
+			Addresses:   hop.Addresses,
+			Hostname:    hop.Hostname,
+			Sent:        hop.Sent,
+			Received:    hop.Received,
+			Loss:        hop.Loss,
+			LastRTT:     hop.LastRTT,
+			AvgRTT:      hop.AvgRTT,
+			BestRTT:     hop.BestRTT,
+			WorstRTT:    hop.WorstRTT,
+			StdDevRTT:   hop.StdDevRTT,
+			Unreachable: hop.Unreachable,
+		})
+	}
+	if result.TraceReached && len(result.TraceHops) > 0 {
+		result.ResponseTime = result.TraceHops[len(result.TraceHops)-1].AvgRTT
+	}
+	result.Details = t.createDetailedMessage(result, traceResult.Rounds)
+
+	return result, nil
+}
+
+func (t *TracerouteOperation) createDetailedMessage(result *types.OperationResult, rounds int) string {
+	var details strings.Builder
+
+	if result.TraceReached {
+		details.WriteString(fmt.Sprintf("🛤️ TRACEROUTE COMPLETE - %s reached in %d hops",
+			result.TraceAddress, len(result.TraceHops)))
+	} else {
+		details.WriteString(fmt.Sprintf("🚧 TRACEROUTE INCOMPLETE - %s", result.Error))
+	}
+
+	if result.TraceAddress != result.Host {
+		details.WriteString(fmt.Sprintf(" | Host: %s (%s)", result.Host, result.TraceAddress))
+	} else {
+		details.WriteString(fmt.Sprintf(" | Host: %s", result.Host))
+	}
+
+	probes := strings.ToUpper(result.TraceProtocol)
+	if result.Port > 0 {
+		probes = fmt.Sprintf("%s/%d", probes, result.Port)
+	}
+	details.WriteString(fmt.Sprintf(" | Probes: %s x%d | Family: %s", probes, rounds, familyLabel(result.TraceFamily)))
+
+	var path []string
+	for _, hop := range result.TraceHops {
+		if hop.Received == 0 {
+			path = append(path, fmt.Sprintf("%d. *", hop.TTL))
+			continue
+		}
+		name := hop.Address
+		if hop.Hostname != "" {
+			name = fmt.Sprintf("%s (%s)", hop.Hostname, hop.Address)
+		}
+		path = append(path, fmt.Sprintf("%d. %s %.2fms %.0f%% loss",
+			hop.TTL, name, float64(hop.AvgRTT.Nanoseconds())/1e6, hop.Loss))
+	}
+	if len(path) > 0 {
+		details.WriteString(" | Path: " + strings.Join(path, ", "))
+	}
+
+	return details.String()
+}
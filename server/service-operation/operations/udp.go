@@ -0,0 +1,160 @@
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"service-operation/types"
+)
+
+// Reachability of a UDP port, stored in udp_data.connection
+const (
+	UDPResponded   = "responded"
+	UDPNoResponse  = "no_response"
+	UDPUnreachable = "unreachable"
+)
+
+// udpMaxDatagram is the largest reply read, the maximum UDP payload over IPv4
+const udpMaxDatagram = 65507
+
+type UDPOperation struct {
+	timeout time.Duration
+}
+
+// UDPProbeOptions configures the datagram sent to the port and how the reply is judged
+type UDPProbeOptions struct {
+	Send            string        // Payload of the probe datagram, may be empty
+	SendFormat      string        // text (with \r, \n, \t, \0 and \\ escapes) or hex
+	Expect          KeywordCheck  // Reply match, Keyword empty to accept any reply
+	ReadTimeout     time.Duration // Defaults to the operation timeout
+	AllowNoResponse bool          // Count silence as up, for services that never reply (e.g. syslog)
+}
+
+func NewUDPOperation(timeout time.Duration) *UDPOperation {
+	return &UDPOperation{timeout: timeout}
+}
+
+func (u *UDPOperation) Execute(host string, port int) (*types.OperationResult, error) {
+	return u.ExecuteWithOptions(host, port, UDPProbeOptions{})
+}
+
+// ExecuteWithOptions sends one datagram and waits for the reply. An ICMP port
+// unreachable, reported by the kernel as a refused connection, always fails the check.
+func (u *UDPOperation) ExecuteWithOptions(host string, port int, opts UDPProbeOptions) (*types.OperationResult, error) {
+	if port <= 0 {
+		return nil, fmt.Errorf("port is required for UDP probes")
+	}
+
+	result := &types.OperationResult{
+		Type:      types.OperationUDP,
+		Host:      host,
+		Port:      port,
+		StartTime: time.Now(),
+	}
+
+	payload, err := decodeTCPPayload(opts.Send, opts.SendFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	readTimeout := opts.ReadTimeout
+	if readTimeout <= 0 {
+		readTimeout = u.timeout
+	}
+
+	start := time.Now()
+
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	conn, err := net.DialTimeout("udp", address, u.timeout)
+	if err != nil {
+		result.EndTime = time.Now()
+		result.ResponseTime = time.Since(start)
+		result.Success = false
+		result.Error = err.Error()
+		result.Details = fmt.Sprintf("Failed to reach %s - %s", address, err.Error())
+		return result, nil
+	}
+	defer conn.Close()
+
+	// Only a connected socket gets the ICMP error delivered back
+	sent := time.Now()
+	conn.SetDeadline(sent.Add(readTimeout))
+
+	buf := make([]byte, udpMaxDatagram)
+	_, err = conn.Write(payload)
+	n := 0
+	if err == nil {
+		n, err = conn.Read(buf)
+	}
+
+	result.EndTime = time.Now()
+	result.ResponseTime = result.EndTime.Sub(sent)
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		result.UDPReachability = UDPUnreachable
+		result.Success = false
+		result.Error = fmt.Sprintf("port unreachable: %v", err)
+		result.Details = fmt.Sprintf("❌ UDP port %s unreachable (ICMP port unreachable)", address)
+		return result, nil
+
+	case errors.As(err, &netErr) && netErr.Timeout():
+		result.UDPReachability = UDPNoResponse
+		if opts.AllowNoResponse && opts.Expect.Keyword == "" {
+			result.Success = true
+			result.Details = fmt.Sprintf("✅ UDP probe to %s sent, no reply within %v (open or filtered)", address, readTimeout)
+			return result, nil
+		}
+		result.Success = false
+		result.Error = fmt.Sprintf("no response within %v", readTimeout)
+		result.Details = fmt.Sprintf("❌ UDP probe to %s got no reply within %v", address, readTimeout)
+		return result, nil
+
+	case err != nil:
+		result.Success = false
+		result.Error = err.Error()
+		result.Details = fmt.Sprintf("❌ UDP probe to %s failed - %v", address, err)
+		return result, nil
+	}
+
+	result.UDPReachability = UDPResponded
+	result.UDPResponse = sanitizeBanner(buf[:n])
+	result.Success = true
+	result.Details = fmt.Sprintf("✅ UDP reply from %s, %d bytes", address, n)
+
+	expect := opts.Expect
+	if expect.Keyword == "" {
+		return result, nil
+	}
+
+	found, _, err := expect.Evaluate(string(buf[:n]))
+	result.UDPExpectMatched = expect.Passed(found)
+	switch {
+	case err != nil:
+		result.Success = false
+		result.Error = err.Error()
+	case !result.UDPExpectMatched && strings.ToLower(expect.Mode) == KeywordModeNotContains:
+		result.Success = false
+		result.Error = fmt.Sprintf("reply contains forbidden \"%s\"", expect.Keyword)
+	case !result.UDPExpectMatched && strings.ToLower(expect.Mode) == KeywordModeRegex:
+		result.Success = false
+		result.Error = fmt.Sprintf("reply does not match pattern \"%s\"", expect.Keyword)
+	case !result.UDPExpectMatched:
+		result.Success = false
+		result.Error = fmt.Sprintf("reply does not contain \"%s\"", expect.Keyword)
+	}
+
+	if result.Success {
+		result.Details += ", reply matched"
+	} else {
+		result.Details = fmt.Sprintf("⚠️ UDP reply from %s but %s", address, result.Error)
+	}
+
+	return result, nil
+}
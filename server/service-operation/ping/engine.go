@@ -0,0 +1,239 @@
+package ping
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/icmp"
+)
+
+// Engine multiplexes the echo requests of every ping check over one long-lived
+// socket per address family and packet settings. Each request gets a sequence number that is unique
+// on its socket, and a single reader per socket routes replies back to the
+// waiting check by identifier and sequence number.
+type Engine struct {
+	mu      sync.Mutex
+	sockets map[socketKey]*engineSocket
+}
+
+// socketKey identifies a shared socket; TTL and don't-fragment are socket
+// options, so requests that set them differently cannot share one
+type socketKey struct {
+	family       *icmpFamily
+	ttl          int
+	dontFragment bool
+}
+
+// defaultEngine is shared by every ICMPPinger created with NewICMPPinger
+var defaultEngine = NewEngine()
+
+func NewEngine() *Engine {
+	return &Engine{sockets: make(map[socketKey]*engineSocket)}
+}
+
+// engineSocket is one shared ICMP socket and the echo requests awaiting replies on it
+type engineSocket struct {
+	engine *Engine
+	key    socketKey
+	conn   *icmpConn
+	id     int // Identifier set on requests; datagram sockets reply with localID instead
+
+	mu      sync.Mutex
+	nextSeq uint16
+	pending map[uint16]*pendingEcho
+	closed  bool
+}
+
+// pendingEcho is an echo request waiting for its reply
+type pendingEcho struct {
+	seq   uint16
+	dst   net.IP
+	token []byte
+	sent  time.Time
+	reply chan time.Time // Receives the arrival time of the first reply
+
+	replies int // Guarded by the socket mutex
+}
+
+// socket returns the shared socket for a key, opening it on first use
+func (e *Engine) socket(key socketKey) (*engineSocket, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if sock, ok := e.sockets[key]; ok {
+		return sock, nil
+	}
+
+	conn, err := listen(key.family)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.configure(key.ttl, key.dontFragment); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	sock := &engineSocket{
+		engine:  e,
+		key:     key,
+		conn:    conn,
+		id:      os.Getpid() & 0xffff,
+		pending: make(map[uint16]*pendingEcho),
+	}
+	e.sockets[key] = sock
+	go sock.read()
+
+	return sock, nil
+}
+
+// send registers an echo request and writes it to the socket
+func (s *engineSocket) send(dst net.IP, token, payload []byte) (*pendingEcho, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("ICMP socket closed")
+	}
+	if len(s.pending) > 0xffff {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("too many echo requests in flight")
+	}
+	// Skip sequence numbers still waiting for a reply after a wraparound
+	for {
+		s.nextSeq++
+		if _, busy := s.pending[s.nextSeq]; !busy {
+			break
+		}
+	}
+	echo := &pendingEcho{
+		seq:   s.nextSeq,
+		dst:   dst,
+		token: token,
+		reply: make(chan time.Time, 1),
+	}
+	s.pending[echo.seq] = echo
+	s.mu.Unlock()
+
+	msg := &icmp.Message{
+		Type: s.conn.family.echoRequest,
+		Code: 0,
+		Body: &icmp.Echo{ID: s.id, Seq: int(echo.seq), Data: payload},
+	}
+	data, err := msg.Marshal(nil)
+	if err != nil {
+		s.finish(echo)
+		return nil, err
+	}
+
+	echo.sent = time.Now()
+	if _, err := s.conn.WriteTo(data, s.conn.destination(dst)); err != nil {
+		s.finish(echo)
+		return nil, err
+	}
+	return echo, nil
+}
+
+// finish stops routing replies to a request and returns how many duplicate
+// replies it received
+func (s *engineSocket) finish(echo *pendingEcho) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending[echo.seq] == echo {
+		delete(s.pending, echo.seq)
+	}
+	if echo.replies > 1 {
+		return echo.replies - 1
+	}
+	return 0
+}
+
+// maxReadErrors is how many reads in a row may fail before the socket is replaced
+const maxReadErrors = 10
+
+// readBufferSize fits a reply to the largest payload
+const readBufferSize = 1 << 16
+
+// read routes replies to the pending requests until the socket fails, after
+// which the engine opens a new socket on the next request
+func (s *engineSocket) read() {
+	buf := make([]byte, readBufferSize)
+	failures := 0
+	for {
+		n, peer, err := s.conn.ReadFrom(buf)
+		received := time.Now()
+		if err != nil {
+			failures++
+			if errors.Is(err, net.ErrClosed) || failures >= maxReadErrors {
+				s.shutdown()
+				return
+			}
+			continue
+		}
+		failures = 0
+
+		seq, echo, ok := s.parseReply(buf[:n])
+		if !ok {
+			continue
+		}
+
+		s.mu.Lock()
+		pending, found := s.pending[seq]
+		if found && bytes.HasPrefix(echo.Data, pending.token) && peerIP(peer).Equal(pending.dst) {
+			pending.replies++
+			if pending.replies == 1 {
+				pending.reply <- received
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// parseReply checks that a packet is an echo reply to this socket
+func (s *engineSocket) parseReply(packet []byte) (uint16, *icmp.Echo, bool) {
+	echoReply := s.conn.family.echoReply
+	msg, err := icmp.ParseMessage(echoReply.Protocol(), packet)
+	if err != nil || msg.Type != echoReply {
+		return 0, nil, false
+	}
+	echo, ok := msg.Body.(*icmp.Echo)
+	if !ok {
+		return 0, nil, false
+	}
+	if echo.ID != s.id && echo.ID != s.conn.localID() {
+		return 0, nil, false
+	}
+	return uint16(echo.Seq), echo, true
+}
+
+// shutdown detaches a failed socket from the engine; waiting requests time out
+func (s *engineSocket) shutdown() {
+	s.engine.mu.Lock()
+	if s.engine.sockets[s.key] == s {
+		delete(s.engine.sockets, s.key)
+	}
+	s.engine.mu.Unlock()
+
+	s.mu.Lock()
+	s.closed = true
+	s.pending = make(map[uint16]*pendingEcho)
+	s.mu.Unlock()
+
+	s.conn.Close()
+}
+
+// Close closes the engine's sockets
+func (e *Engine) Close() {
+	e.mu.Lock()
+	sockets := make([]*engineSocket, 0, len(e.sockets))
+	for _, sock := range e.sockets {
+		sockets = append(sockets, sock)
+	}
+	e.mu.Unlock()
+
+	for _, sock := range sockets {
+		sock.shutdown()
+	}
+}
@@ -1,117 +1,452 @@
-
 package ping
 
 import (
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"math"
 	"net"
-	"os"
+	"syscall"
 	"time"
 
 	"golang.org/x/net/icmp"
 	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// ErrNoICMPSocket is returned when neither an unprivileged datagram socket nor a
+// raw socket could be opened, e.g. outside net.ipv4.ping_group_range without CAP_NET_RAW
+var ErrNoICMPSocket = errors.New("no ICMP socket available")
+
+// ErrInvalidOptions is returned for out-of-range packet parameters
+var ErrInvalidOptions = errors.New("invalid ping options")
+
+// Address families accepted by PingOptions.Family
+const (
+	FamilyAuto = "auto" // IPv4 when the host has an IPv4 address, IPv6 otherwise
+	FamilyIPv4 = "ipv4"
+	FamilyIPv6 = "ipv6"
 )
 
+// DefaultInterval is the delay between echo requests
+const DefaultInterval = time.Second
+
+// MinInterval keeps a single check from flooding the target
+const MinInterval = 10 * time.Millisecond
+
+// rawReadBuffer is the receive buffer requested for raw sockets
+const rawReadBuffer = 1 << 20
+
+// DefaultPayloadSize matches the 56 data bytes sent by the system ping
+const DefaultPayloadSize = 56
+
+// Payload size limits; the payload starts with the per-call token, and the
+// maximum fills an IPv4 packet
+const (
+	MinPayloadSize = tokenSize
+	MaxPayloadSize = 65507
+)
+
+// tokenSize is the length of the random token that starts each payload
+const tokenSize = 8
+
 type ICMPPinger struct {
-	timeout time.Duration
+	timeout  time.Duration // Per-packet reply timeout
+	interval time.Duration
+	engine   *Engine
+}
+
+// PingOptions configures a single Ping call. Zero values use the defaults.
+type PingOptions struct {
+	Family       string        // auto, ipv4 or ipv6
+	Size         int           // ICMP payload bytes, DefaultPayloadSize when zero
+	Interval     time.Duration // Delay between requests, the pinger interval when zero
+	TTL          int           // IPv4 TTL or IPv6 hop limit, the system default when zero
+	DontFragment bool          // Set DF so packets larger than the path MTU fail instead of fragmenting
+}
+
+// validate rejects out-of-range packet parameters
+func (o PingOptions) validate() error {
+	if o.Size != 0 && (o.Size < MinPayloadSize || o.Size > MaxPayloadSize) {
+		return fmt.Errorf("%w: payload size must be between %d and %d bytes", ErrInvalidOptions, MinPayloadSize, MaxPayloadSize)
+	}
+	if o.Interval != 0 && o.Interval < MinInterval {
+		return fmt.Errorf("%w: interval must be at least %v", ErrInvalidOptions, MinInterval)
+	}
+	if o.TTL < 0 || o.TTL > 255 {
+		return fmt.Errorf("%w: TTL must be between 1 and 255", ErrInvalidOptions)
+	}
+	return nil
 }
 
+// NewICMPPinger returns a pinger on the shared engine, so every check in the
+// process reuses the same sockets
 func NewICMPPinger(timeout time.Duration) *ICMPPinger {
-	return &ICMPPinger{timeout: timeout}
+	return NewICMPPingerWithEngine(timeout, defaultEngine)
+}
+
+func NewICMPPingerWithEngine(timeout time.Duration, engine *Engine) *ICMPPinger {
+	return &ICMPPinger{timeout: timeout, interval: DefaultInterval, engine: engine}
+}
+
+// icmpFamily holds what differs between ICMP and ICMPv6 echo
+type icmpFamily struct {
+	name        string
+	raw         string // Network of the raw socket
+	listenAddr  string
+	echoRequest icmp.Type
+	echoReply   icmp.Type
+
+	timeExceeded    icmp.Type
+	destUnreachable icmp.Type
+	unreachable     map[int]string // Destination unreachable codes
+}
+
+var (
+	familyIPv4 = &icmpFamily{
+		name:        FamilyIPv4,
+		raw:         "ip4:icmp",
+		listenAddr:  "0.0.0.0",
+		echoRequest: ipv4.ICMPTypeEcho,
+		echoReply:   ipv4.ICMPTypeEchoReply,
+
+		timeExceeded:    ipv4.ICMPTypeTimeExceeded,
+		destUnreachable: ipv4.ICMPTypeDestinationUnreachable,
+		unreachable: map[int]string{
+			0:  "network unreachable",
+			1:  "host unreachable",
+			2:  "protocol unreachable",
+			3:  "port unreachable",
+			4:  "fragmentation needed",
+			9:  "network administratively prohibited",
+			10: "host administratively prohibited",
+			13: "communication administratively prohibited",
+		},
+	}
+	familyIPv6 = &icmpFamily{
+		name:        FamilyIPv6,
+		raw:         "ip6:ipv6-icmp",
+		listenAddr:  "::",
+		echoRequest: ipv6.ICMPTypeEchoRequest,
+		echoReply:   ipv6.ICMPTypeEchoReply,
+
+		timeExceeded:    ipv6.ICMPTypeTimeExceeded,
+		destUnreachable: ipv6.ICMPTypeDestinationUnreachable,
+		unreachable: map[int]string{
+			0: "no route to destination",
+			1: "communication administratively prohibited",
+			3: "address unreachable",
+			4: "port unreachable",
+			5: "source address failed policy",
+			6: "reject route to destination",
+		},
+	}
+)
+
+// icmpType converts a raw ICMP type of the family
+func (f *icmpFamily) icmpType(t int) icmp.Type {
+	if f == familyIPv6 {
+		return ipv6.ICMPType(t)
+	}
+	return ipv4.ICMPType(t)
+}
+
+// unreachableReason describes a destination unreachable code
+func (f *icmpFamily) unreachableReason(code int) string {
+	if reason, ok := f.unreachable[code]; ok {
+		return reason
+	}
+	return fmt.Sprintf("destination unreachable (code %d)", code)
+}
+
+// resolve picks the address to ping in the requested family
+func resolve(host, family string) (net.IP, *icmpFamily, error) {
+	switch family {
+	case "", FamilyAuto, FamilyIPv4, FamilyIPv6:
+	default:
+		return nil, nil, fmt.Errorf("unsupported address family %q", family)
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve host %s: %v", host, err)
+	}
+
+	var v4, v6 net.IP
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			if v4 == nil {
+				v4 = ip.To4()
+			}
+		} else if v6 == nil {
+			v6 = ip
+		}
+	}
+
+	switch {
+	case family == FamilyIPv4 && v4 == nil:
+		return nil, nil, fmt.Errorf("failed to resolve host %s: no IPv4 address", host)
+	case family == FamilyIPv6 && v6 == nil:
+		return nil, nil, fmt.Errorf("failed to resolve host %s: no IPv6 address", host)
+	case family == FamilyIPv6, v4 == nil:
+		return v6, familyIPv6, nil
+	default:
+		return v4, familyIPv4, nil
+	}
+}
+
+// icmpConn is an open ICMP socket along with how it must be addressed
+type icmpConn struct {
+	net.PacketConn
+	family     *icmpFamily
+	privileged bool // Raw socket; datagram sockets get replies filtered by the kernel
+}
+
+// listen opens an unprivileged datagram ICMP socket, falling back to a raw socket
+func listen(family *icmpFamily) (*icmpConn, error) {
+	conn, dgramErr := listenDatagram(family)
+	if dgramErr == nil {
+		return &icmpConn{PacketConn: conn, family: family}, nil
+	}
+
+	raw, rawErr := listenRaw(family)
+	if rawErr == nil {
+		return raw, nil
+	}
+
+	return nil, fmt.Errorf("%w: datagram socket: %v, raw socket: %v", ErrNoICMPSocket, dgramErr, rawErr)
+}
+
+// listenRaw opens a raw ICMP socket. Raw sockets also see every other ICMP
+// packet on the host, so they get a larger receive buffer to absorb bursts.
+func listenRaw(family *icmpFamily) (*icmpConn, error) {
+	raw, err := net.ListenPacket(family.raw, family.listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	raw.(*net.IPConn).SetReadBuffer(rawReadBuffer)
+	return &icmpConn{PacketConn: raw, family: family, privileged: true}, nil
+}
+
+// configure applies the TTL and don't-fragment settings of a socket
+func (c *icmpConn) configure(ttl int, dontFragment bool) error {
+	if ttl > 0 {
+		if err := setTTL(c.PacketConn, c.family, ttl); err != nil {
+			return err
+		}
+	}
+	if dontFragment {
+		if err := setDontFragment(c.PacketConn, c.family); err != nil {
+			return fmt.Errorf("failed to set don't-fragment: %v", err)
+		}
+	}
+	return nil
+}
+
+// setTTL sets the IPv4 TTL or IPv6 hop limit of the packets sent on a socket
+func setTTL(conn net.PacketConn, family *icmpFamily, ttl int) error {
+	var err error
+	if family == familyIPv6 {
+		err = ipv6.NewPacketConn(conn).SetHopLimit(ttl)
+	} else {
+		err = ipv4.NewPacketConn(conn).SetTTL(ttl)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set TTL: %v", err)
+	}
+	return nil
+}
+
+// destination returns the address type the socket expects for WriteTo
+func (c *icmpConn) destination(ip net.IP) net.Addr {
+	if c.privileged {
+		return &net.IPAddr{IP: ip}
+	}
+	return &net.UDPAddr{IP: ip}
+}
+
+// localID is the echo identifier the kernel assigns on datagram sockets, which
+// replace the identifier we set with the socket's local port
+func (c *icmpConn) localID() int {
+	if addr, ok := c.LocalAddr().(*net.UDPAddr); ok {
+		return addr.Port
+	}
+	return -1
 }
 
 func (p *ICMPPinger) Ping(host string, count int) (*PingResult, error) {
-	dst, err := net.ResolveIPAddr("ip4", host)
+	return p.PingWithOptions(host, count, PingOptions{})
+}
+
+// PingWithOptions sends count echo requests one interval apart through the
+// pinger's engine. Each request is answered within the pinger timeout or counted
+// as lost; replies are matched on identifier, sequence number, source address
+// and a per-call payload token.
+func (p *ICMPPinger) PingWithOptions(host string, count int, opts PingOptions) (*PingResult, error) {
+	if count <= 0 {
+		count = 1
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	size := opts.Size
+	if size == 0 {
+		size = DefaultPayloadSize
+	}
+	interval := opts.Interval
+	if interval == 0 {
+		interval = p.interval
+	}
+
+	dstIP, family, err := resolve(host, opts.Family)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve host %s: %v", host, err)
+		return nil, err
 	}
 
-	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	sock, err := p.engine.socket(socketKey{family: family, ttl: opts.TTL, dontFragment: opts.DontFragment})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create ICMP connection: %v", err)
+		return nil, err
 	}
-	defer conn.Close()
+
+	token := make([]byte, tokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("failed to generate echo token: %v", err)
+	}
+	payload := make([]byte, size)
+	copy(payload, token)
 
 	result := &PingResult{
 		Host:        host,
+		Address:     dstIP.String(),
+		Family:      family.name,
+		Privileged:  sock.conn.privileged,
+		PacketSize:  size,
 		PacketsSent: count,
 		StartTime:   time.Now(),
 	}
 
-	var totalRTT time.Duration
-	var minRTT, maxRTT time.Duration
-
+	// Requests go out one interval apart while earlier ones may still be waiting
+	echoes := make([]*pendingEcho, count)
+	var sendErr error
 	for i := 0; i < count; i++ {
-		message := &icmp.Message{
-			Type: ipv4.ICMPTypeEcho,
-			Code: 0,
-			Body: &icmp.Echo{
-				ID:   os.Getpid() & 0xffff,
-				Seq:  i + 1,
-				Data: []byte("Hello, World!"),
-			},
+		if i > 0 {
+			time.Sleep(interval)
 		}
-
-		data, err := message.Marshal(nil)
-		if err != nil {
-			continue
+		if echoes[i], err = sock.send(dstIP, token, payload); err != nil {
+			sendErr = err
 		}
+	}
 
-		start := time.Now()
-		_, err = conn.WriteTo(data, dst)
-		if err != nil {
+	// Deadlines follow the send order, so waiting on each in turn is enough
+	arrivals := make([]time.Time, count)
+	var totalRTT time.Duration
+	for i, echo := range echoes {
+		if echo == nil {
 			continue
 		}
 
-		err = conn.SetReadDeadline(time.Now().Add(p.timeout))
-		if err != nil {
-			continue
+		var received time.Time
+		timer := time.NewTimer(time.Until(echo.sent.Add(p.timeout)))
+		select {
+		case received = <-echo.reply:
+		case <-timer.C:
 		}
+		timer.Stop()
 
-		reply := make([]byte, 1500)
-		_, peer, err := conn.ReadFrom(reply)
-		if err != nil {
+		if received.IsZero() || received.Sub(echo.sent) > p.timeout {
 			continue
 		}
 
-		rtt := time.Since(start)
-
-		// Parse the reply
-		rm, err := icmp.ParseMessage(ipv4.ICMPTypeEchoReply, reply)
-		if err != nil {
-			continue
+		rtt := received.Sub(echo.sent)
+		arrivals[i] = received
+		result.PacketsRecv++
+		result.RTTs = append(result.RTTs, rtt)
+		totalRTT += rtt
+		if result.PacketsRecv == 1 || rtt < result.MinRTT {
+			result.MinRTT = rtt
 		}
-
-		switch rm.Type {
-		case ipv4.ICMPTypeEchoReply:
-			if peer.String() == dst.String() {
-				result.PacketsRecv++
-				totalRTT += rtt
-
-				if result.PacketsRecv == 1 || rtt < minRTT {
-					minRTT = rtt
-				}
-				if result.PacketsRecv == 1 || rtt > maxRTT {
-					maxRTT = rtt
-				}
-
-				result.RTTs = append(result.RTTs, rtt)
-			}
+		if result.PacketsRecv == 1 || rtt > result.MaxRTT {
+			result.MaxRTT = rtt
 		}
+	}
 
-		if i < count-1 {
-			time.Sleep(1 * time.Second)
+	// Requests stay registered until here so that duplicates are still counted
+	for _, echo := range echoes {
+		if echo != nil {
+			result.Duplicates += sock.finish(echo)
 		}
 	}
+	result.OutOfOrder = countOutOfOrder(arrivals)
 
 	result.EndTime = time.Now()
 	result.PacketLoss = float64(count-result.PacketsRecv) / float64(count) * 100
 
-	if result.PacketsRecv > 0 {
-		result.MinRTT = minRTT
-		result.MaxRTT = maxRTT
+	switch {
+	case result.PacketsRecv > 0:
 		result.AvgRTT = totalRTT / time.Duration(result.PacketsRecv)
+		result.MeanDevRTT, result.StdDevRTT = Deviation(result.RTTs)
 		result.Success = true
+	case opts.DontFragment && errors.Is(sendErr, syscall.EMSGSIZE):
+		result.Error = fmt.Sprintf("message too long: %d-byte payload exceeds the path MTU with don't-fragment set", size)
+	case sendErr != nil:
+		result.Error = fmt.Sprintf("failed to send echo request: %v", sendErr)
+	default:
+		result.Error = fmt.Sprintf("timeout: no echo replies within %v", p.timeout)
 	}
 
 	return result, nil
 }
+
+// countOutOfOrder counts replies that arrived after the reply to a later
+// request, given arrival times in send order with zero for lost requests
+func countOutOfOrder(arrivals []time.Time) int {
+	outOfOrder := 0
+	var earliestLater time.Time
+	for i := len(arrivals) - 1; i >= 0; i-- {
+		arrival := arrivals[i]
+		if arrival.IsZero() {
+			continue
+		}
+		if !earliestLater.IsZero() && arrival.After(earliestLater) {
+			outOfOrder++
+		}
+		if earliestLater.IsZero() || arrival.Before(earliestLater) {
+			earliestLater = arrival
+		}
+	}
+	return outOfOrder
+}
+
+// Deviation returns the jitter of a set of round-trip times as the mean
+// absolute deviation and the population standard deviation from their mean.
+// The standard deviation is what ping reports as mdev.
+func Deviation(rtts []time.Duration) (meanDev, stdDev time.Duration) {
+	if len(rtts) == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, rtt := range rtts {
+		sum += float64(rtt)
+	}
+	mean := sum / float64(len(rtts))
+
+	var absSum, sqSum float64
+	for _, rtt := range rtts {
+		diff := float64(rtt) - mean
+		absSum += math.Abs(diff)
+		sqSum += diff * diff
+	}
+	n := float64(len(rtts))
+	return time.Duration(absSum / n), time.Duration(math.Sqrt(sqSum / n))
+}
+
+func peerIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package ping
+
+import (
+	"fmt"
+	"net"
+)
+
+// listenDatagram is unavailable without unprivileged ICMP sockets; listen falls
+// back to a raw socket
+func listenDatagram(family *icmpFamily) (net.PacketConn, error) {
+	return nil, fmt.Errorf("datagram ICMP sockets are not supported on this platform")
+}
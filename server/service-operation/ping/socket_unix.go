@@ -0,0 +1,38 @@
+//go:build unix
+
+package ping
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// listenDatagram opens an unprivileged ICMP datagram socket the way
+// icmp.ListenPacket does, but hands back the plain connection so socket
+// options such as the don't-fragment bit can be set on it
+func listenDatagram(family *icmpFamily) (net.PacketConn, error) {
+	domain, proto := syscall.AF_INET, syscall.IPPROTO_ICMP
+	var addr syscall.Sockaddr = &syscall.SockaddrInet4{}
+	if family == familyIPv6 {
+		domain, proto = syscall.AF_INET6, syscall.IPPROTO_ICMPV6
+		addr = &syscall.SockaddrInet6{}
+	}
+
+	fd, err := syscall.Socket(domain, syscall.SOCK_DGRAM, proto)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	if err := prepareDatagram(fd, family); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	if err := syscall.Bind(fd, addr); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("bind", err)
+	}
+
+	file := os.NewFile(uintptr(fd), "icmp")
+	defer file.Close()
+	return net.FilePacketConn(file)
+}
@@ -0,0 +1,31 @@
+package ping
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// Not exported by the syscall package on darwin
+const (
+	ipDontFrag   = 0x1c
+	ipv6DontFrag = 0x3e
+)
+
+// prepareDatagram makes IPv4 datagram sockets return the ICMP message without
+// the IP header, matching Linux
+func prepareDatagram(fd int, family *icmpFamily) error {
+	if family != familyIPv4 {
+		return nil
+	}
+	return os.NewSyscallError("setsockopt", syscall.SetsockoptInt(fd, syscall.IPPROTO_IP, syscall.IP_STRIPHDR, 1))
+}
+
+// setDontFragment disables fragmentation, so oversized packets fail with
+// EMSGSIZE instead of being split along the path
+func setDontFragment(conn net.PacketConn, family *icmpFamily) error {
+	if family == familyIPv6 {
+		return setSockopt(conn, syscall.IPPROTO_IPV6, ipv6DontFrag, 1)
+	}
+	return setSockopt(conn, syscall.IPPROTO_IP, ipDontFrag, 1)
+}
@@ -0,0 +1,20 @@
+package ping
+
+import (
+	"net"
+	"syscall"
+)
+
+func prepareDatagram(fd int, family *icmpFamily) error {
+	return nil
+}
+
+// setDontFragment disables fragmentation, so oversized packets fail with
+// EMSGSIZE instead of being split along the path
+func setDontFragment(conn net.PacketConn, family *icmpFamily) error {
+	level, opt, value := syscall.IPPROTO_IP, syscall.IP_MTU_DISCOVER, syscall.IP_PMTUDISC_DO
+	if family == familyIPv6 {
+		level, opt, value = syscall.IPPROTO_IPV6, syscall.IPV6_MTU_DISCOVER, syscall.IPV6_PMTUDISC_DO
+	}
+	return setSockopt(conn, level, opt, value)
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+
+package ping
+
+import (
+	"fmt"
+	"net"
+)
+
+func prepareDatagram(fd int, family *icmpFamily) error {
+	return nil
+}
+
+func setDontFragment(conn net.PacketConn, family *icmpFamily) error {
+	return fmt.Errorf("don't-fragment is not supported on this platform")
+}
@@ -0,0 +1,30 @@
+//go:build linux || darwin
+
+package ping
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// setSockopt sets an integer option on the socket behind a connection
+func setSockopt(conn net.PacketConn, level, opt, value int) error {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return fmt.Errorf("socket options are not supported on %T", conn)
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var sockErr error
+	if err := raw.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), level, opt, value)
+	}); err != nil {
+		return err
+	}
+	return os.NewSyscallError("setsockopt", sockErr)
+}
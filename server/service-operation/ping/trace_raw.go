@@ -0,0 +1,352 @@
+package ping
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/icmp"
+)
+
+// tracePayloadSize is the data carried by each traceroute probe
+const tracePayloadSize = 32
+
+// IANA protocol numbers found in quoted packets
+const (
+	protoICMP   = 1
+	protoTCP    = 6
+	protoUDP    = 17
+	protoICMPv6 = 58
+)
+
+// traceKey identifies a probe in the packet an ICMP error quotes back
+type traceKey struct {
+	proto int // IANA protocol number of the probe
+	id    int // Echo identifier, or the source port of UDP and TCP probes
+	seq   int // Echo sequence number, zero for UDP and TCP
+}
+
+// rawAnswer is an ICMP message answering a probe
+type rawAnswer struct {
+	from        net.IP
+	received    time.Time
+	unreachable string
+}
+
+// rawProber sends probes of any protocol and matches the ICMP messages they
+// cause on one raw socket, which sees every ICMP packet sent to the host
+type rawProber struct {
+	conn     *icmpConn
+	protocol string
+	dst      net.IP
+	port     int
+	timeout  time.Duration
+	id       int // Echo identifier of ICMP probes
+
+	sendMu sync.Mutex // Serializes the TTL change and write of ICMP probes
+
+	mu      sync.Mutex
+	waiting map[traceKey]chan rawAnswer
+}
+
+func newRawProber(conn *icmpConn, protocol string, dst net.IP, port int, timeout time.Duration) *rawProber {
+	p := &rawProber{
+		conn:     conn,
+		protocol: protocol,
+		dst:      dst,
+		port:     port,
+		timeout:  timeout,
+		id:       traceID(),
+		waiting:  make(map[traceKey]chan rawAnswer),
+	}
+	go p.read()
+	return p
+}
+
+func (p *rawProber) Close() {
+	p.conn.Close()
+}
+
+func (p *rawProber) probe(ttl, seq int) hopReply {
+	switch p.protocol {
+	case ProtocolUDP:
+		return p.probeUDP(ttl)
+	case ProtocolTCP:
+		return p.probeTCP(ttl)
+	default:
+		return p.probeICMP(ttl, seq)
+	}
+}
+
+// probeICMP sends an echo request through the raw socket itself
+func (p *rawProber) probeICMP(ttl, seq int) hopReply {
+	family := p.conn.family
+	key := traceKey{proto: family.echoRequest.Protocol(), id: p.id, seq: seq & 0xffff}
+	answers := p.register(key)
+	defer p.unregister(key)
+
+	msg := &icmp.Message{
+		Type: family.echoRequest,
+		Code: 0,
+		Body: &icmp.Echo{ID: key.id, Seq: key.seq, Data: make([]byte, tracePayloadSize)},
+	}
+	data, err := msg.Marshal(nil)
+	if err != nil {
+		return hopReply{ttl: ttl}
+	}
+
+	p.sendMu.Lock()
+	err = setTTL(p.conn.PacketConn, family, ttl)
+	sent := time.Now()
+	if err == nil {
+		_, err = p.conn.WriteTo(data, &net.IPAddr{IP: p.dst})
+	}
+	p.sendMu.Unlock()
+	if err != nil {
+		return hopReply{ttl: ttl}
+	}
+
+	return p.wait(ttl, sent, answers)
+}
+
+// probeUDP sends a datagram from its own socket, identified by its source port
+func (p *rawProber) probeUDP(ttl int) hopReply {
+	family := p.conn.family
+	conn, err := net.ListenPacket(family.network("udp"), "")
+	if err != nil {
+		return hopReply{ttl: ttl}
+	}
+	defer conn.Close()
+	if err := setTTL(conn, family, ttl); err != nil {
+		return hopReply{ttl: ttl}
+	}
+
+	key := traceKey{proto: protoUDP, id: conn.LocalAddr().(*net.UDPAddr).Port}
+	answers := p.register(key)
+	defer p.unregister(key)
+
+	sent := time.Now()
+	if _, err := conn.WriteTo(make([]byte, tracePayloadSize), &net.UDPAddr{IP: p.dst, Port: p.port}); err != nil {
+		return hopReply{ttl: ttl}
+	}
+	return p.wait(ttl, sent, answers)
+}
+
+// probeTCP opens a connection with a limited TTL. Routers answer the SYN with
+// ICMP errors; the destination completes or resets the handshake.
+func (p *rawProber) probeTCP(ttl int) hopReply {
+	family := p.conn.family
+	registered := make(chan chan rawAnswer, 1)
+	var key traceKey
+
+	dialer := net.Dialer{
+		// The source port is bound before connecting so the probe can be
+		// registered before its SYN goes out
+		Control: func(network, address string, c syscall.RawConn) error {
+			var port int
+			var bindErr error
+			if err := c.Control(func(fd uintptr) {
+				port, bindErr = bindTCPProbe(fd, family, ttl)
+			}); err != nil {
+				return err
+			}
+			if bindErr != nil {
+				return bindErr
+			}
+			key = traceKey{proto: protoTCP, id: port}
+			registered <- p.register(key)
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	sent := time.Now()
+	dialed := make(chan error, 1)
+	go func() {
+		conn, err := dialer.DialContext(ctx, family.network("tcp"), net.JoinHostPort(p.dst.String(), strconv.Itoa(p.port)))
+		if conn != nil {
+			conn.Close()
+		}
+		dialed <- err
+	}()
+
+	var answers chan rawAnswer
+	select {
+	case answers = <-registered:
+	case err := <-dialed:
+		// Control registers before connecting, so a dial that fails without a
+		// registration never sent its SYN
+		select {
+		case answers = <-registered:
+			dialed <- err
+		default:
+			return hopReply{ttl: ttl}
+		}
+	}
+	defer p.unregister(key)
+
+	for {
+		select {
+		case answer := <-answers:
+			return p.answer(ttl, sent, answer)
+		case err := <-dialed:
+			// A completed or refused handshake comes from the destination; other
+			// failures are the ICMP error the raw socket is about to report
+			if err == nil || errors.Is(err, syscall.ECONNREFUSED) {
+				return hopReply{ttl: ttl, ok: true, from: p.dst, rtt: time.Since(sent), reached: true}
+			}
+			dialed = nil
+		case <-ctx.Done():
+			return hopReply{ttl: ttl}
+		}
+	}
+}
+
+// wait returns the answer to a probe, or a lost probe after the timeout
+func (p *rawProber) wait(ttl int, sent time.Time, answers chan rawAnswer) hopReply {
+	timer := time.NewTimer(time.Until(sent.Add(p.timeout)))
+	defer timer.Stop()
+
+	select {
+	case answer := <-answers:
+		return p.answer(ttl, sent, answer)
+	case <-timer.C:
+		return hopReply{ttl: ttl}
+	}
+}
+
+func (p *rawProber) answer(ttl int, sent time.Time, answer rawAnswer) hopReply {
+	reply := hopReply{ttl: ttl, ok: true, from: answer.from, rtt: answer.received.Sub(sent)}
+	if answer.from.Equal(p.dst) {
+		reply.reached = true
+	} else {
+		reply.unreachable = answer.unreachable
+	}
+	return reply
+}
+
+func (p *rawProber) register(key traceKey) chan rawAnswer {
+	answers := make(chan rawAnswer, 1)
+	p.mu.Lock()
+	p.waiting[key] = answers
+	p.mu.Unlock()
+	return answers
+}
+
+func (p *rawProber) unregister(key traceKey) {
+	p.mu.Lock()
+	delete(p.waiting, key)
+	p.mu.Unlock()
+}
+
+// read routes ICMP messages to the probes they answer until the socket is closed
+func (p *rawProber) read() {
+	buf := make([]byte, readBufferSize)
+	failures := 0
+	for {
+		n, peer, err := p.conn.ReadFrom(buf)
+		received := time.Now()
+		if err != nil {
+			failures++
+			if errors.Is(err, net.ErrClosed) || failures >= maxReadErrors {
+				return
+			}
+			continue
+		}
+		failures = 0
+
+		key, answer, ok := p.parse(buf[:n], peerIP(peer))
+		if !ok {
+			continue
+		}
+		answer.received = received
+
+		p.mu.Lock()
+		if answers, found := p.waiting[key]; found {
+			select {
+			case answers <- answer:
+			default:
+			}
+		}
+		p.mu.Unlock()
+	}
+}
+
+// parse matches an echo reply from the destination, or an ICMP error quoting
+// one of the probes sent to it
+func (p *rawProber) parse(packet []byte, from net.IP) (traceKey, rawAnswer, bool) {
+	family := p.conn.family
+	msg, err := icmp.ParseMessage(family.echoReply.Protocol(), packet)
+	if err != nil || from == nil {
+		return traceKey{}, rawAnswer{}, false
+	}
+
+	answer := rawAnswer{from: from}
+	var quoted []byte
+	switch body := msg.Body.(type) {
+	case *icmp.Echo:
+		if msg.Type != family.echoReply || !from.Equal(p.dst) {
+			return traceKey{}, rawAnswer{}, false
+		}
+		return traceKey{proto: family.echoRequest.Protocol(), id: body.ID, seq: body.Seq}, answer, true
+	case *icmp.TimeExceeded:
+		quoted = body.Data
+	case *icmp.DstUnreach:
+		answer.unreachable = family.unreachableReason(msg.Code)
+		quoted = body.Data
+	default:
+		return traceKey{}, rawAnswer{}, false
+	}
+
+	key, dst, ok := quotedProbe(family, quoted)
+	if !ok || !dst.Equal(p.dst) {
+		return traceKey{}, rawAnswer{}, false
+	}
+	return key, answer, true
+}
+
+// quotedProbe identifies the probe an ICMP error refers to from the IP header
+// and first eight bytes of the original packet, which the error quotes
+func quotedProbe(family *icmpFamily, data []byte) (traceKey, net.IP, bool) {
+	var proto int
+	var dst net.IP
+	var inner []byte
+	if family == familyIPv6 {
+		if len(data) < 40+8 {
+			return traceKey{}, nil, false
+		}
+		proto = int(data[6])
+		dst = net.IP(data[24:40])
+		inner = data[40:]
+	} else {
+		if len(data) < 20 {
+			return traceKey{}, nil, false
+		}
+		headerLen := int(data[0]&0x0f) * 4
+		if len(data) < headerLen+8 {
+			return traceKey{}, nil, false
+		}
+		proto = int(data[9])
+		dst = net.IP(data[16:20])
+		inner = data[headerLen:]
+	}
+
+	switch proto {
+	case protoICMP, protoICMPv6:
+		return traceKey{
+			proto: proto,
+			id:    int(binary.BigEndian.Uint16(inner[4:6])),
+			seq:   int(binary.BigEndian.Uint16(inner[6:8])),
+		}, dst, true
+	case protoUDP, protoTCP:
+		return traceKey{proto: proto, id: int(binary.BigEndian.Uint16(inner[0:2]))}, dst, true
+	}
+	return traceKey{}, nil, false
+}
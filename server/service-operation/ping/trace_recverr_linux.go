@@ -0,0 +1,175 @@
+package ping
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/icmp"
+)
+
+// Origins of extended socket errors, from linux/errqueue.h
+const (
+	eeOriginICMP  = 2
+	eeOriginICMP6 = 3
+)
+
+// recvErrProber sends each probe from its own unprivileged socket and reads the
+// ICMP errors it causes from the socket's error queue (IP_RECVERR), the way
+// tracepath does. Only ICMP and UDP sockets queue these errors.
+type recvErrProber struct {
+	family   *icmpFamily
+	protocol string
+	dst      net.IP
+	port     int
+	timeout  time.Duration
+}
+
+func newRecvErrProber(family *icmpFamily, protocol string, dst net.IP, port int, timeout time.Duration) (prober, error) {
+	if protocol == ProtocolICMP {
+		// Datagram ICMP sockets need net.ipv4.ping_group_range
+		conn, err := listenDatagram(family)
+		if err != nil {
+			return nil, err
+		}
+		conn.Close()
+	}
+	return &recvErrProber{family: family, protocol: protocol, dst: dst, port: port, timeout: timeout}, nil
+}
+
+func (p *recvErrProber) Close() {}
+
+func (p *recvErrProber) probe(ttl, seq int) hopReply {
+	reply := hopReply{ttl: ttl}
+
+	var conn net.PacketConn
+	var payload []byte
+	var dst net.Addr
+	var err error
+	if p.protocol == ProtocolICMP {
+		conn, err = listenDatagram(p.family)
+		if err != nil {
+			return reply
+		}
+		msg := &icmp.Message{
+			Type: p.family.echoRequest,
+			Code: 0,
+			Body: &icmp.Echo{ID: 0, Seq: seq & 0xffff, Data: make([]byte, tracePayloadSize)},
+		}
+		if payload, err = msg.Marshal(nil); err != nil {
+			conn.Close()
+			return reply
+		}
+		dst = &net.UDPAddr{IP: p.dst}
+	} else {
+		conn, err = net.ListenPacket(p.family.network("udp"), "")
+		if err != nil {
+			return reply
+		}
+		payload = make([]byte, tracePayloadSize)
+		dst = &net.UDPAddr{IP: p.dst, Port: p.port}
+	}
+	defer conn.Close()
+
+	level, opt := syscall.SOL_IP, syscall.IP_RECVERR
+	if p.family == familyIPv6 {
+		level, opt = syscall.SOL_IPV6, syscall.IPV6_RECVERR
+	}
+	if setTTL(conn, p.family, ttl) != nil || setSockopt(conn, level, opt, 1) != nil {
+		return reply
+	}
+	rawConn, err := conn.(syscall.Conn).SyscallConn()
+	if err != nil {
+		return reply
+	}
+
+	sent := time.Now()
+	if _, err := conn.WriteTo(payload, dst); err != nil {
+		return reply
+	}
+	conn.SetReadDeadline(sent.Add(p.timeout))
+
+	buf := make([]byte, readBufferSize)
+	oob := make([]byte, 512)
+	rawConn.Read(func(fd uintptr) bool {
+		for {
+			_, oobn, _, _, err := syscall.Recvmsg(int(fd), buf, oob, syscall.MSG_ERRQUEUE|syscall.MSG_DONTWAIT)
+			if err != nil {
+				break
+			}
+			if from, icmpType, code, ok := p.parseRecvErr(oob[:oobn]); ok {
+				reply.ok = true
+				reply.from = from
+				reply.rtt = time.Since(sent)
+				if from.Equal(p.dst) {
+					reply.reached = true
+				} else if icmpType == p.family.destUnreachable {
+					reply.unreachable = p.family.unreachableReason(code)
+				}
+				return true
+			}
+		}
+
+		// Regular data is the echo reply, or an answer from a UDP service
+		if _, _, err := syscall.Recvfrom(int(fd), buf, syscall.MSG_DONTWAIT); err == nil {
+			reply.ok = true
+			reply.from = p.dst
+			reply.rtt = time.Since(sent)
+			reply.reached = true
+			return true
+		}
+		return false
+	})
+
+	return reply
+}
+
+// parseRecvErr reads the ICMP type and code and the address of the router that
+// sent them from an IP_RECVERR control message. Errors raised locally, such as
+// EMSGSIZE, carry no offender and are skipped.
+func (p *recvErrProber) parseRecvErr(oob []byte) (net.IP, icmp.Type, int, bool) {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil, nil, 0, false
+	}
+
+	for _, msg := range msgs {
+		isV4 := msg.Header.Level == syscall.SOL_IP && msg.Header.Type == syscall.IP_RECVERR
+		isV6 := msg.Header.Level == syscall.SOL_IPV6 && msg.Header.Type == syscall.IPV6_RECVERR
+		if !isV4 && !isV6 {
+			continue
+		}
+
+		// struct sock_extended_err is followed by the offender's sockaddr
+		data := msg.Data
+		if len(data) < 16 {
+			continue
+		}
+		origin, icmpType, code := data[4], int(data[5]), int(data[6])
+		if origin != eeOriginICMP && origin != eeOriginICMP6 {
+			continue
+		}
+
+		offender := data[16:]
+		if len(offender) < 2 {
+			continue
+		}
+		var from net.IP
+		switch binary.NativeEndian.Uint16(offender[0:2]) {
+		case syscall.AF_INET:
+			if len(offender) >= 8 {
+				from = net.IP(append([]byte(nil), offender[4:8]...))
+			}
+		case syscall.AF_INET6:
+			if len(offender) >= 24 {
+				from = net.IP(append([]byte(nil), offender[8:24]...))
+			}
+		}
+		if from == nil {
+			continue
+		}
+		return from, p.family.icmpType(icmpType), code, true
+	}
+	return nil, nil, 0, false
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package ping
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+func newRecvErrProber(family *icmpFamily, protocol string, dst net.IP, port int, timeout time.Duration) (prober, error) {
+	return nil, fmt.Errorf("unprivileged traceroute is only supported on Linux")
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package ping
+
+import "fmt"
+
+func bindTCPProbe(fd uintptr, family *icmpFamily, ttl int) (int, error) {
+	return 0, fmt.Errorf("TCP traceroute is not supported on this platform")
+}
@@ -0,0 +1,41 @@
+//go:build unix
+
+package ping
+
+import (
+	"os"
+	"syscall"
+)
+
+// bindTCPProbe sets the TTL of a TCP socket and binds it to an ephemeral port,
+// returning the port
+func bindTCPProbe(fd uintptr, family *icmpFamily, ttl int) (int, error) {
+	s := int(fd)
+	if family == familyIPv6 {
+		if err := syscall.SetsockoptInt(s, syscall.IPPROTO_IPV6, syscall.IPV6_UNICAST_HOPS, ttl); err != nil {
+			return 0, os.NewSyscallError("setsockopt", err)
+		}
+		if err := syscall.Bind(s, &syscall.SockaddrInet6{}); err != nil {
+			return 0, os.NewSyscallError("bind", err)
+		}
+	} else {
+		if err := syscall.SetsockoptInt(s, syscall.IPPROTO_IP, syscall.IP_TTL, ttl); err != nil {
+			return 0, os.NewSyscallError("setsockopt", err)
+		}
+		if err := syscall.Bind(s, &syscall.SockaddrInet4{}); err != nil {
+			return 0, os.NewSyscallError("bind", err)
+		}
+	}
+
+	addr, err := syscall.Getsockname(s)
+	if err != nil {
+		return 0, os.NewSyscallError("getsockname", err)
+	}
+	switch sa := addr.(type) {
+	case *syscall.SockaddrInet4:
+		return sa.Port, nil
+	case *syscall.SockaddrInet6:
+		return sa.Port, nil
+	}
+	return 0, syscall.EAFNOSUPPORT
+}
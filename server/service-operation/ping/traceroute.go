@@ -0,0 +1,342 @@
+package ping
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Probe protocols accepted by TraceOptions.Protocol
+const (
+	ProtocolICMP = "icmp"
+	ProtocolUDP  = "udp"
+	ProtocolTCP  = "tcp"
+)
+
+// Trace defaults
+const (
+	DefaultMaxHops = 30
+	DefaultRounds  = 3
+	MaxTraceHops   = 64
+	MaxTraceRounds = 20
+
+	DefaultTraceUDPPort = 33434 // Unlikely to be open, as in the classic traceroute
+	DefaultTraceTCPPort = 80
+)
+
+// traceSendGap spaces the probes of a round so routers and the destination do
+// not rate limit their ICMP answers
+const traceSendGap = 25 * time.Millisecond
+
+// traceRoundInterval is the minimum time between the starts of two rounds.
+// Routers commonly answer each source with about one ICMP error per second.
+const traceRoundInterval = time.Second
+
+// reverseLookupTimeout bounds the reverse DNS lookup of each hop
+const reverseLookupTimeout = 2 * time.Second
+
+// TraceOptions configures a Trace call. Zero values use the defaults.
+type TraceOptions struct {
+	Protocol string // icmp, udp or tcp
+	Port     int    // Destination port of UDP and TCP probes
+	Family   string // auto, ipv4 or ipv6
+	MaxHops  int
+	Rounds   int // Probes per hop, sent in MTR-style rounds
+}
+
+// Tracer discovers the routers on the path to a host by sending probes with
+// increasing TTL and listening for the ICMP time exceeded messages they cause
+type Tracer struct {
+	timeout time.Duration // Per-probe reply timeout
+}
+
+func NewTracer(timeout time.Duration) *Tracer {
+	return &Tracer{timeout: timeout}
+}
+
+// hopReply is what answered a single probe
+type hopReply struct {
+	ttl         int
+	ok          bool
+	from        net.IP
+	rtt         time.Duration
+	reached     bool   // The destination itself answered
+	unreachable string // Set when a router reports the destination unreachable
+}
+
+// prober sends one probe with the given TTL and waits for its answer
+type prober interface {
+	probe(ttl, seq int) hopReply
+	Close()
+}
+
+// Trace sends Rounds rounds of probes with TTL 1 up to MaxHops, stopping each
+// round at the hop where the destination answered or a router reported it
+// unreachable. Within a round probes go out a few milliseconds apart without
+// waiting for earlier answers.
+func (t *Tracer) Trace(host string, opts TraceOptions) (*TraceResult, error) {
+	protocol := strings.ToLower(opts.Protocol)
+	port := opts.Port
+	switch protocol {
+	case "", ProtocolICMP:
+		protocol = ProtocolICMP
+	case ProtocolUDP:
+		if port <= 0 {
+			port = DefaultTraceUDPPort
+		}
+	case ProtocolTCP:
+		if port <= 0 {
+			port = DefaultTraceTCPPort
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported probe protocol %q", ErrInvalidOptions, opts.Protocol)
+	}
+	if port > 65535 {
+		return nil, fmt.Errorf("%w: port must be between 1 and 65535", ErrInvalidOptions)
+	}
+
+	maxHops := opts.MaxHops
+	if maxHops <= 0 {
+		maxHops = DefaultMaxHops
+	}
+	if maxHops > MaxTraceHops {
+		return nil, fmt.Errorf("%w: at most %d hops can be traced", ErrInvalidOptions, MaxTraceHops)
+	}
+	rounds := opts.Rounds
+	if rounds <= 0 {
+		rounds = DefaultRounds
+	}
+	if rounds > MaxTraceRounds {
+		return nil, fmt.Errorf("%w: at most %d rounds can be sent", ErrInvalidOptions, MaxTraceRounds)
+	}
+
+	dstIP, family, err := resolve(host, opts.Family)
+	if err != nil {
+		return nil, err
+	}
+
+	p, privileged, err := newProber(family, protocol, dstIP, port, t.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	result := &TraceResult{
+		Host:       host,
+		Address:    dstIP.String(),
+		Family:     family.name,
+		Protocol:   protocol,
+		Port:       port,
+		Privileged: privileged,
+		Rounds:     rounds,
+		StartTime:  time.Now(),
+	}
+	if protocol == ProtocolICMP {
+		result.Port = 0
+	}
+
+	hops := make([]TraceHop, maxHops)
+	for i := range hops {
+		hops[i].TTL = i + 1
+	}
+
+	destTTL := 0 // Lowest TTL at which the destination answered
+	stopTTL := 0 // Lowest TTL at which the destination answered or was reported unreachable
+	seq := 0
+	var mu sync.Mutex
+	var roundStart time.Time
+	for round := 0; round < rounds; round++ {
+		if round > 0 {
+			time.Sleep(time.Until(roundStart.Add(traceRoundInterval)))
+		}
+		roundStart = time.Now()
+		replies := make(chan hopReply, maxHops)
+		sent := 0
+		for ttl := 1; ttl <= maxHops; ttl++ {
+			mu.Lock()
+			done := stopTTL > 0 && ttl > stopTTL
+			mu.Unlock()
+			if done {
+				break
+			}
+
+			seq++
+			go func(ttl, seq int) {
+				reply := p.probe(ttl, seq)
+				mu.Lock()
+				if reply.reached && (destTTL == 0 || ttl < destTTL) {
+					destTTL = ttl
+				}
+				if (reply.reached || reply.unreachable != "") && (stopTTL == 0 || ttl < stopTTL) {
+					stopTTL = ttl
+				}
+				mu.Unlock()
+				replies <- reply
+			}(ttl, seq)
+			sent++
+			time.Sleep(traceSendGap)
+		}
+
+		for i := 0; i < sent; i++ {
+			reply := <-replies
+			hops[reply.ttl-1].add(reply)
+		}
+	}
+
+	// Keep the hops up to the destination or the router reporting it
+	// unreachable, or else up to the first silent hop after the last router
+	// that answered, which is where the path breaks
+	last := 0
+	for i := range hops {
+		if hops[i].Received > 0 {
+			last = i + 1
+		}
+	}
+	switch {
+	case stopTTL > 0:
+		hops = hops[:stopTTL]
+		result.Reached = destTTL == stopTTL
+	case last < maxHops:
+		hops = hops[:last+1]
+	}
+	for i := range hops {
+		hops[i].finish()
+	}
+	lookupHostnames(hops)
+
+	result.Hops = hops
+	result.EndTime = time.Now()
+	result.Success = result.Reached
+	if !result.Reached {
+		result.Error = t.breakError(hops, maxHops)
+	}
+
+	return result, nil
+}
+
+// breakError describes where a path that never reached the destination stops
+func (t *Tracer) breakError(hops []TraceHop, maxHops int) string {
+	for _, hop := range hops {
+		if hop.Unreachable != "" {
+			return fmt.Sprintf("%s reported by hop %d (%s)", hop.Unreachable, hop.TTL, hop.Address)
+		}
+	}
+	if len(hops) == maxHops && hops[len(hops)-1].Received > 0 {
+		return fmt.Sprintf("destination not reached within %d hops", maxHops)
+	}
+	if len(hops) <= 1 {
+		return fmt.Sprintf("no hop answered within %v", t.timeout)
+	}
+	lastHop := hops[len(hops)-2]
+	return fmt.Sprintf("path stops after hop %d (%s)", lastHop.TTL, lastHop.Address)
+}
+
+// add records one probe of the hop
+func (h *TraceHop) add(reply hopReply) {
+	h.Sent++
+	if !reply.ok {
+		return
+	}
+
+	h.Received++
+	h.rtts = append(h.rtts, reply.rtt)
+	h.Address = reply.from.String()
+	seen := false
+	for _, addr := range h.Addresses {
+		if addr == h.Address {
+			seen = true
+			break
+		}
+	}
+	if !seen {
+		h.Addresses = append(h.Addresses, h.Address)
+	}
+	if reply.unreachable != "" {
+		h.Unreachable = reply.unreachable
+	}
+}
+
+// finish computes the hop statistics from the recorded round-trip times
+func (h *TraceHop) finish() {
+	if h.Sent > 0 {
+		h.Loss = float64(h.Sent-h.Received) / float64(h.Sent) * 100
+	}
+	if len(h.rtts) == 0 {
+		return
+	}
+
+	var total time.Duration
+	for i, rtt := range h.rtts {
+		total += rtt
+		if i == 0 || rtt < h.BestRTT {
+			h.BestRTT = rtt
+		}
+		if rtt > h.WorstRTT {
+			h.WorstRTT = rtt
+		}
+	}
+	h.LastRTT = h.rtts[len(h.rtts)-1]
+	h.AvgRTT = total / time.Duration(len(h.rtts))
+	_, h.StdDevRTT = Deviation(h.rtts)
+}
+
+// lookupHostnames fills in the reverse DNS name of every hop in parallel
+func lookupHostnames(hops []TraceHop) {
+	var wg sync.WaitGroup
+	for i := range hops {
+		if hops[i].Address == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(hop *TraceHop) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), reverseLookupTimeout)
+			defer cancel()
+			if names, err := net.DefaultResolver.LookupAddr(ctx, hop.Address); err == nil && len(names) > 0 {
+				hop.Hostname = strings.TrimSuffix(names[0], ".")
+			}
+		}(&hops[i])
+	}
+	wg.Wait()
+}
+
+// newProber prefers a raw socket, which sees the ICMP errors caused by any
+// probe protocol. Without one, Linux still reports them for ICMP and UDP probes
+// through the error queue of unprivileged sockets.
+func newProber(family *icmpFamily, protocol string, dst net.IP, port int, timeout time.Duration) (prober, bool, error) {
+	raw, rawErr := listenRaw(family)
+	if rawErr == nil {
+		return newRawProber(raw, protocol, dst, port, timeout), true, nil
+	}
+
+	if protocol == ProtocolTCP {
+		return nil, false, fmt.Errorf("%w: TCP traceroute needs a raw socket: %v", ErrNoICMPSocket, rawErr)
+	}
+	p, err := newRecvErrProber(family, protocol, dst, port, timeout)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: raw socket: %v, unprivileged probes: %v", ErrNoICMPSocket, rawErr, err)
+	}
+	return p, false, nil
+}
+
+// traceID returns a random echo identifier for the ICMP probes of a trace
+func traceID() int {
+	var b [2]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(b[:]))
+}
+
+// network returns the family-specific network name, e.g. udp4
+func (f *icmpFamily) network(protocol string) string {
+	if f == familyIPv6 {
+		return protocol + "6"
+	}
+	return protocol + "4"
+}
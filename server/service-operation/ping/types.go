@@ -5,6 +5,10 @@ import "time"
 
 type PingResult struct {
 	Host        string          `json:"host"`
+	Address     string          `json:"address"`     // Resolved IP that was pinged
+	Family      string          `json:"family"`      // ipv4 or ipv6
+	Privileged  bool            `json:"privileged"`  // Sent over a raw socket instead of a datagram socket
+	PacketSize  int             `json:"packet_size"` // ICMP payload bytes
 	Success     bool            `json:"success"`
 	PacketsSent int             `json:"packets_sent"`
 	PacketsRecv int             `json:"packets_recv"`
@@ -12,6 +16,10 @@ type PingResult struct {
 	MinRTT      time.Duration   `json:"min_rtt"`
 	MaxRTT      time.Duration   `json:"max_rtt"`
 	AvgRTT      time.Duration   `json:"avg_rtt"`
+	MeanDevRTT  time.Duration   `json:"mean_dev_rtt"` // Mean absolute deviation of the RTTs
+	StdDevRTT   time.Duration   `json:"std_dev_rtt"`  // Standard deviation of the RTTs, ping's mdev
+	Duplicates  int             `json:"duplicates"`   // Extra replies to requests already answered
+	OutOfOrder  int             `json:"out_of_order"` // Replies that arrived after the reply to a later request
 	RTTs        []time.Duration `json:"rtts"`
 	StartTime   time.Time       `json:"start_time"`
 	EndTime     time.Time       `json:"end_time"`
@@ -23,3 +31,38 @@ type PingRequest struct {
 	Count   int    `json:"count,omitempty"`
 	Timeout int    `json:"timeout,omitempty"`
 }
+
+// TraceHop holds the MTR-style statistics of one TTL on the path
+type TraceHop struct {
+	TTL         int           `json:"ttl"`
+	Address     string        `json:"address,omitempty"`   // Last router that answered
+	Addresses   []string      `json:"addresses,omitempty"` // Every router that answered, several on load-balanced paths
+	Hostname    string        `json:"hostname,omitempty"`  // Reverse DNS of Address
+	Sent        int           `json:"sent"`
+	Received    int           `json:"received"`
+	Loss        float64       `json:"loss"`
+	LastRTT     time.Duration `json:"last_rtt"`
+	AvgRTT      time.Duration `json:"avg_rtt"`
+	BestRTT     time.Duration `json:"best_rtt"`
+	WorstRTT    time.Duration `json:"worst_rtt"`
+	StdDevRTT   time.Duration `json:"std_dev_rtt"`
+	Unreachable string        `json:"unreachable,omitempty"` // Set when the hop reported the destination unreachable
+
+	rtts []time.Duration
+}
+
+type TraceResult struct {
+	Host       string     `json:"host"`
+	Address    string     `json:"address"` // Resolved IP that was traced
+	Family     string     `json:"family"`
+	Protocol   string     `json:"protocol"`       // icmp, udp or tcp
+	Port       int        `json:"port,omitempty"` // Destination port of UDP and TCP probes
+	Privileged bool       `json:"privileged"`     // Probed through a raw socket
+	Reached    bool       `json:"reached"`
+	Rounds     int        `json:"rounds"`
+	Hops       []TraceHop `json:"hops"`
+	Success    bool       `json:"success"`
+	StartTime  time.Time  `json:"start_time"`
+	EndTime    time.Time  `json:"end_time"`
+	Error      string     `json:"error,omitempty"`
+}
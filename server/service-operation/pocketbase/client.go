@@ -1,15 +1,25 @@
-
 package pocketbase
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"sync"
 	"time"
 )
 
 type PocketBaseClient struct {
 	baseURL    string
 	httpClient *http.Client
+
+	// Superuser credentials, used to read hidden service fields
+	superuserEmail    string
+	superuserPassword string
+	tokenMu           sync.Mutex
+	token             string
+	warnOnce          sync.Once
 }
 
 func NewPocketBaseClient(baseURL string) (*PocketBaseClient, error) {
@@ -28,6 +38,16 @@ func NewPocketBaseClient(baseURL string) (*PocketBaseClient, error) {
 	return client, nil
 }
 
+// SetSuperuserCredentials makes service reads authenticate as a superuser so
+// hidden fields like auth_password and auth_token are returned
+func (c *PocketBaseClient) SetSuperuserCredentials(email, password string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	c.superuserEmail = email
+	c.superuserPassword = password
+	c.token = ""
+}
+
 func (c *PocketBaseClient) GetBaseURL() string {
 	return c.baseURL
 }
@@ -49,4 +69,84 @@ func (c *PocketBaseClient) TestConnection() error {
 func (c *PocketBaseClient) IsAuthenticated() bool {
 	// Since we're using public access mode, always return true
 	return true
-}
\ No newline at end of file
+}
+
+// superuserToken returns a cached superuser token, authenticating when there
+// is none; it returns an empty token when no credentials are configured
+func (c *PocketBaseClient) superuserToken() (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.superuserEmail == "" || c.superuserPassword == "" {
+		c.warnOnce.Do(func() {
+			log.Printf("PocketBase superuser credentials are not set; service passwords and tokens cannot be read")
+		})
+		return "", nil
+	}
+	if c.token != "" {
+		return c.token, nil
+	}
+
+	jsonData, err := json.Marshal(map[string]string{
+		"identity": c.superuserEmail,
+		"password": c.superuserPassword,
+	})
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.httpClient.Post(
+		fmt.Sprintf("%s/api/collections/_superusers/auth-with-password", c.baseURL),
+		"application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("PocketBase superuser authentication failed with status: %d", resp.StatusCode)
+	}
+
+	var authResponse AuthResponse
+	if err := c.parseResponse(resp, &authResponse); err != nil {
+		return "", err
+	}
+	if authResponse.Token == "" {
+		return "", fmt.Errorf("PocketBase superuser authentication returned no token")
+	}
+	c.token = authResponse.Token
+	return c.token, nil
+}
+
+// getAsSuperuser performs a GET with the superuser token, authenticating
+// again once if the cached token has expired
+func (c *PocketBaseClient) getAsSuperuser(url string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		token, err := c.superuserToken()
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if token == "" || attempt > 0 || (resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden) {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		c.tokenMu.Lock()
+		if c.token == token {
+			c.token = ""
+		}
+		c.tokenMu.Unlock()
+	}
+}
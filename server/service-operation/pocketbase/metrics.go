@@ -19,4 +19,20 @@ func (c *PocketBaseClient) SaveDNSData(dnsData DNSDataRecord) error {
 
 func (c *PocketBaseClient) SaveTCPData(tcpData TCPDataRecord) error {
 	return c.createRecord("tcp_data", tcpData)
+}
+
+func (c *PocketBaseClient) SaveUDPData(udpData UDPDataRecord) error {
+	return c.createRecord("udp_data", udpData)
+}
+
+func (c *PocketBaseClient) SaveDatabaseData(dbData DatabaseDataRecord) error {
+	return c.createRecord("database_data", dbData)
+}
+
+func (c *PocketBaseClient) SaveMailData(mailData MailDataRecord) error {
+	return c.createRecord("mail_data", mailData)
+}
+
+func (c *PocketBaseClient) SaveTracerouteData(traceData TracerouteDataRecord) error {
+	return c.createRecord("traceroute_data", traceData)
 }
\ No newline at end of file
@@ -10,14 +10,8 @@ import (
 )
 
 func (c *PocketBaseClient) GetServices() ([]Service, error) {
-	req, err := http.NewRequest("GET", 
-		fmt.Sprintf("%s/api/collections/services/records", c.baseURL), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// No authentication header needed for public access
-	resp, err := c.httpClient.Do(req)
+	// Authenticated as a superuser so hidden credential fields are included
+	resp, err := c.getAsSuperuser(fmt.Sprintf("%s/api/collections/services/records", c.baseURL))
 	if err != nil {
 		return nil, err
 	}
@@ -36,14 +30,8 @@ func (c *PocketBaseClient) GetServices() ([]Service, error) {
 }
 
 func (c *PocketBaseClient) GetService(serviceID string) (*Service, error) {
-	req, err := http.NewRequest("GET", 
-		fmt.Sprintf("%s/api/collections/services/records/%s", c.baseURL, serviceID), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// No authentication header needed for public access
-	resp, err := c.httpClient.Do(req)
+	// Authenticated as a superuser so hidden credential fields are included
+	resp, err := c.getAsSuperuser(fmt.Sprintf("%s/api/collections/services/records/%s", c.baseURL, serviceID))
 	if err != nil {
 		return nil, err
 	}
@@ -68,15 +56,10 @@ func (c *PocketBaseClient) GetActiveServices() ([]Service, error) {
 
 	for {
 		// Fetch services page by page with filter for non-paused services
-		req, err := http.NewRequest("GET", 
+		// Authenticated as a superuser so hidden credential fields are included
+		resp, err := c.getAsSuperuser(
 			fmt.Sprintf("%s/api/collections/services/records?page=%d&perPage=%d&filter=(status!='paused')", 
-				c.baseURL, page, perPage), nil)
-		if err != nil {
-			return nil, err
-		}
-
-		// No authentication header needed for public access
-		resp, err := c.httpClient.Do(req)
+				c.baseURL, page, perPage))
 		if err != nil {
 			return nil, err
 		}
@@ -1,6 +1,11 @@
 package pocketbase
 
-import "time"
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+)
 
 type AuthResponse struct {
 	Token  string      `json:"token"`
@@ -43,6 +48,10 @@ type PingDataRecord struct {
 	PacketsRecv   string    `json:"packets_recv"`
 	AvgRTT        string    `json:"avg_rtt"`
 	RTTs          string    `json:"rtts"`
+	RTTMeanDev    string    `json:"rtt_mean_dev"`
+	RTTStdDev     string    `json:"rtt_std_dev"`
+	Duplicates    string    `json:"duplicates"`
+	OutOfOrder    string    `json:"out_of_order"`
 	Details       string    `json:"details,omitempty"`
 	ErrorMessage  string    `json:"error_message,omitempty"`
 	RegionName    string    `json:"region_name,omitempty"`
@@ -60,6 +69,11 @@ type UptimeDataRecord struct {
 	Keyword       string    `json:"keyword"`
 	ErrorMessage  string    `json:"error_message"`
 	Details       string    `json:"details"`
+	DNSLookup     string    `json:"dns_lookup,omitempty"`
+	TCPConnect    string    `json:"tcp_connect,omitempty"`
+	TLSHandshake  string    `json:"tls_handshake,omitempty"`
+	TTFB          string    `json:"ttfb,omitempty"`
+	Transfer      string    `json:"content_transfer,omitempty"`
 	Region        string    `json:"region,omitempty"`
 	RegionID      string    `json:"region_id,omitempty"`
 	RegionName    string    `json:"region_name,omitempty"`
@@ -77,6 +91,8 @@ type DNSDataRecord struct {
 	Question     string    `json:"question"`
 	Answer       string    `json:"answer"`
 	Authority    string    `json:"authority"`
+	DNSSECStatus string    `json:"dnssec_status,omitempty"`
+	Findings     interface{} `json:"findings,omitempty"`
 	ErrorMessage string    `json:"error_message,omitempty"`
 	Details      string    `json:"details,omitempty"`
 	RegionName   string    `json:"region_name,omitempty"`
@@ -97,6 +113,90 @@ type TCPDataRecord struct {
 	AgentID      string    `json:"agent_id,omitempty"`
 }
 
+// UDPDataRecord mirrors TCPDataRecord, with connection holding the reachability
+// (responded, no_response or unreachable) and response the reply payload
+type UDPDataRecord struct {
+	ServiceID    string    `json:"service_id"`
+	Timestamp    time.Time `json:"timestamp"`
+	ResponseTime int64     `json:"response_time"`
+	Status       string    `json:"status"`
+	Connection   string    `json:"connection"`
+	Latency      string    `json:"latency"`
+	Port         string    `json:"port"`
+	Response     string    `json:"response,omitempty"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	Details      string    `json:"details,omitempty"`
+	RegionName   string    `json:"region_name,omitempty"`
+	AgentID      string    `json:"agent_id,omitempty"`
+}
+
+// DatabaseDataRecord mirrors TCPDataRecord for the postgres, mysql and redis
+// probes, with the handshake and query timings and the failed stage
+type DatabaseDataRecord struct {
+	ServiceID     string    `json:"service_id"`
+	Timestamp     time.Time `json:"timestamp"`
+	ResponseTime  int64     `json:"response_time"`
+	Status        string    `json:"status"`
+	Engine        string    `json:"engine"`
+	Connection    string    `json:"connection"`
+	Latency       string    `json:"latency"`
+	Port          string    `json:"port"`
+	ServerVersion string    `json:"server_version,omitempty"`
+	HandshakeTime string    `json:"handshake_time,omitempty"`
+	QueryTime     string    `json:"query_time,omitempty"`
+	AuthMethod    string    `json:"auth_method,omitempty"`
+	Authenticated bool      `json:"authenticated"`
+	ErrorKind     string    `json:"error_kind,omitempty"`
+	ErrorMessage  string    `json:"error_message,omitempty"`
+	Details       string    `json:"details,omitempty"`
+	RegionName    string    `json:"region_name,omitempty"`
+	AgentID       string    `json:"agent_id,omitempty"`
+}
+
+// MailDataRecord mirrors TCPDataRecord for the smtp, imap and pop3 probes,
+// with the greeting, advertised capabilities and negotiated TLS version
+type MailDataRecord struct {
+	ServiceID     string    `json:"service_id"`
+	Timestamp     time.Time `json:"timestamp"`
+	ResponseTime  int64     `json:"response_time"`
+	Status        string    `json:"status"`
+	Protocol      string    `json:"protocol"`
+	Connection    string    `json:"connection"`
+	Latency       string    `json:"latency"`
+	Port          string    `json:"port"`
+	Greeting      string    `json:"greeting,omitempty"`
+	GreetingTime  string    `json:"greeting_time,omitempty"`
+	Capabilities  []string  `json:"capabilities"`
+	TLSMode       string    `json:"tls_mode"`
+	TLSVersion    string    `json:"tls_version,omitempty"`
+	Authenticated bool      `json:"authenticated"`
+	ErrorKind     string    `json:"error_kind,omitempty"`
+	ErrorMessage  string    `json:"error_message,omitempty"`
+	Details       string    `json:"details,omitempty"`
+	RegionName    string    `json:"region_name,omitempty"`
+	AgentID       string    `json:"agent_id,omitempty"`
+}
+
+// TracerouteDataRecord is one traceroute, run on demand or when a service went
+// down (trigger service_down); hops holds the per-hop statistics
+type TracerouteDataRecord struct {
+	ServiceID    string      `json:"service_id"`
+	Timestamp    time.Time   `json:"timestamp"`
+	ResponseTime int64       `json:"response_time"`
+	Status       string      `json:"status"`
+	Host         string      `json:"host"`
+	Address      string      `json:"address"`
+	Protocol     string      `json:"protocol"`
+	HopCount     int         `json:"hop_count"`
+	Reached      bool        `json:"reached"`
+	Hops         interface{} `json:"hops"`
+	Trigger      string      `json:"trigger,omitempty"`
+	ErrorMessage string      `json:"error_message,omitempty"`
+	Details      string      `json:"details,omitempty"`
+	RegionName   string      `json:"region_name,omitempty"`
+	AgentID      string      `json:"agent_id,omitempty"`
+}
+
 // SSL Data Record remains unchanged - no regional agent fields
 type SSLDataRecord struct {
 	ServiceID     string    `json:"service_id"`
@@ -156,10 +256,108 @@ type Service struct {
 	Alerts             string    `json:"alerts"`
 	StatusCodes        string    `json:"status_codes"`
 	Keyword            string    `json:"keyword"`
+	KeywordMode        string    `json:"keyword_mode"`
+	KeywordIgnoreCase  bool      `json:"keyword_ignore_case"`
+	HTTPMethod         string            `json:"http_method"`
+	RequestHeaders     map[string]string `json:"request_headers"`
+	RequestBody        string            `json:"request_body"`
+	RequestContentType string            `json:"request_content_type"`
+	AuthType           string            `json:"auth_type"`
+	AuthUsername       string            `json:"auth_username"`
+	AuthPassword       string            `json:"auth_password"`
+	AuthToken          string            `json:"auth_token"`
+	QueryParams        map[string]string `json:"query_params"`
+	IgnoreTLSErrors    bool              `json:"ignore_tls_errors"`
+	JSONAssertions     []string          `json:"json_assertions"`
+	RedirectPolicy     string            `json:"redirect_policy"`
+	MaxRedirects       int               `json:"max_redirects"`
+	ExpectedFinalURL   string            `json:"expected_final_url"`
+	IPFamily           string            `json:"ip_family"`
+	PingCount          int               `json:"ping_count"`
+	PingSize           int               `json:"ping_size"`
+	PingInterval       int               `json:"ping_interval"`
+	PingTTL            int               `json:"ping_ttl"`
+	PingDontFragment   bool              `json:"ping_dont_fragment"`
+	TracerouteOnDown   bool              `json:"traceroute_on_down"`
+	DNSQueryType       string            `json:"dns_query_type"`
+	Nameserver         string            `json:"nameserver"`
+	DNSTransport       string            `json:"dns_transport"`
+	DNSExpected        string            `json:"dns_expected"`
+	DNSMatchMode       string            `json:"dns_match_mode"`
+	DNSMode            string            `json:"dns_mode"`
+	DNSSECTrustAnchor  string            `json:"dnssec_trust_anchor"`
+	DNSSECExpiryWarnDays int             `json:"dnssec_expiry_warn_days"`
+	DKIMSelectors      string            `json:"dkim_selectors"`
+	DNSBLZones         string            `json:"dnsbl_zones"`
+	DatabaseName       string            `json:"database_name"`
+	DatabaseTLS        bool              `json:"database_tls"`
+	AllowInsecureAuth  bool              `json:"allow_insecure_auth"`
+	MailTLS            string            `json:"mail_tls"`
+	TCPSend            string            `json:"tcp_send"`
+	TCPSendFormat      string            `json:"tcp_send_format"`
+	TCPExpect          string            `json:"tcp_expect"`
+	TCPExpectMode      string            `json:"tcp_expect_mode"`
+	TCPReadBanner      bool              `json:"tcp_read_banner"`
+	TCPReadTimeout     int               `json:"tcp_read_timeout"`
+	UDPSend            string            `json:"udp_send"`
+	UDPSendFormat      string            `json:"udp_send_format"`
+	UDPExpect          string            `json:"udp_expect"`
+	UDPExpectMode      string            `json:"udp_expect_mode"`
+	UDPReadTimeout     int               `json:"udp_read_timeout"`
+	UDPAllowNoResponse bool              `json:"udp_allow_no_response"`
 	Created            string    `json:"created"`
 	Updated            string    `json:"updated"`
 }
 
+// Custom unmarshaler so a malformed request_headers or query_params value
+// only loses its own entries instead of failing the whole services list
+func (s *Service) UnmarshalJSON(data []byte) error {
+	type Alias Service
+	aux := &struct {
+		RequestHeaders interface{} `json:"request_headers"`
+		QueryParams    interface{} `json:"query_params"`
+		*Alias
+	}{
+		Alias: (*Alias)(s),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	s.RequestHeaders = stringMap(s.ID, "request_headers", aux.RequestHeaders)
+	s.QueryParams = stringMap(s.ID, "query_params", aux.QueryParams)
+	return nil
+}
+
+// stringMap converts a JSON object to string values, formatting numbers and
+// booleans and skipping anything else with a logged error
+func stringMap(serviceID, field string, value interface{}) map[string]string {
+	if value == nil {
+		return nil
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		log.Printf("Service %s: ignoring %s, expected a JSON object", serviceID, field)
+		return nil
+	}
+
+	result := make(map[string]string, len(object))
+	for key, v := range object {
+		switch v := v.(type) {
+		case string:
+			result[key] = v
+		case float64:
+			result[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			result[key] = strconv.FormatBool(v)
+		default:
+			log.Printf("Service %s: ignoring %s entry %q, expected a string, number or boolean", serviceID, field, key)
+		}
+	}
+	return result
+}
+
 type ServicesResponse struct {
 	Page       int       `json:"page"`
 	PerPage    int       `json:"perPage"`
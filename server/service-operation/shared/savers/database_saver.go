@@ -0,0 +1,44 @@
+package savers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"service-operation/pocketbase"
+	"service-operation/types"
+)
+
+// SaveDatabaseDataToPocketBase stores a postgres, mysql or redis probe in
+// database_data; connection is connected once the server answered its handshake
+func (ms *MetricsSaver) SaveDatabaseDataToPocketBase(result *types.OperationResult, serviceID string) {
+	connectionStatus := "connected"
+	if result.DBErrorKind == "connection" {
+		connectionStatus = "disconnected"
+	}
+
+	dbData := pocketbase.DatabaseDataRecord{
+		ServiceID:     serviceID,
+		Timestamp:     time.Now(),
+		ResponseTime:  result.ResponseTime.Milliseconds(),
+		Status:        GetStatusString(result.Success),
+		Engine:        string(result.Type),
+		Connection:    connectionStatus,
+		Latency:       fmt.Sprintf("%.2fms", float64(result.ResponseTime.Nanoseconds())/1000000),
+		Port:          strconv.Itoa(result.Port),
+		ServerVersion: result.DBServerVersion,
+		HandshakeTime: FormatPhaseDuration(result.DBHandshakeTime),
+		QueryTime:     FormatPhaseDuration(result.DBQueryTime),
+		AuthMethod:    result.DBAuthMethod,
+		Authenticated: result.DBAuthenticated,
+		ErrorKind:     result.DBErrorKind,
+		ErrorMessage:  result.Error,
+		Details:       result.Details,
+		RegionName:    ms.regionName,
+		AgentID:       ms.agentID,
+	}
+
+	if err := ms.pbClient.SaveDatabaseData(dbData); err != nil {
+		fmt.Printf("Failed to save database data to PocketBase: %v\n", err)
+	}
+}
@@ -14,7 +14,10 @@ func (ms *MetricsSaver) SaveDNSDataToPocketBase(result *types.OperationResult, s
 	// Create a short, professional status message
 	var details string
 	
-	if result.Success {
+	if result.DNSConsistency != "" || result.DNSSECStatus != "" {
+		// Propagation or DNSSEC check - keep the verdict
+		details = result.Details
+	} else if result.Success {
 		// Success message with record count and query info
 		recordCount := len(result.DNSRecords)
 		details = fmt.Sprintf("✅ DNS %s Query OK - %d records found", 
@@ -33,6 +36,9 @@ func (ms *MetricsSaver) SaveDNSDataToPocketBase(result *types.OperationResult, s
 					strings.Join(result.DNSRecords[:2], ", "), recordCount-2)
 			}
 		}
+	} else if result.DNSAnswerDiff != "" {
+		// Answer mismatch - keep the missing/unexpected summary
+		details = result.Details
 	} else {
 		// Error message with query type
 		details = fmt.Sprintf("❌ DNS %s Query Failed - %s", 
@@ -46,6 +52,30 @@ func (ms *MetricsSaver) SaveDNSDataToPocketBase(result *types.OperationResult, s
 		}
 	}
 
+	// Fall back to the legacy values when the response sections are unavailable
+	question := result.DNSQuestion
+	if question == "" {
+		question = result.Host
+	}
+	answer := FormatDNSRecords(result.DNSAnswers)
+	if answer == "" {
+		answer = strings.Join(result.DNSRecords, ",")
+	}
+	if len(result.DNSPropagation) > 0 {
+		answer = FormatDNSPropagation(result.DNSPropagation)
+	}
+	if result.DNSAnswerDiff != "" {
+		answer += "\n--- expected vs actual ---\n" + result.DNSAnswerDiff
+	}
+	msgSize := fmt.Sprintf("%d", result.DNSMsgSize)
+	if result.DNSMsgSize == 0 {
+		msgSize = fmt.Sprintf("%d", len(result.DNSRecords))
+	}
+
+	if result.DNSRcode != "" {
+		details += fmt.Sprintf(" | %s from %s", result.DNSRcode, result.DNSServer)
+	}
+
 	dnsData := pocketbase.DNSDataRecord{
 		ServiceID:    serviceID,
 		Timestamp:    time.Now(),
@@ -53,10 +83,11 @@ func (ms *MetricsSaver) SaveDNSDataToPocketBase(result *types.OperationResult, s
 		Status:       GetStatusString(result.Success),
 		QueryType:    result.DNSType,
 		ResolveIP:    strings.Join(result.DNSRecords, ","),
-		MsgSize:      fmt.Sprintf("%d", len(result.DNSRecords)),
-		Question:     result.Host,
-		Answer:       strings.Join(result.DNSRecords, ","),
-		Authority:    "", // Not available in current implementation
+		MsgSize:      msgSize,
+		Question:     question,
+		Answer:       answer,
+		Authority:    FormatDNSRecords(result.DNSAuthority),
+		DNSSECStatus: result.DNSSECStatus,
 		ErrorMessage: result.Error,
 		Details:      details, // Short, clean message
 		RegionName:   ms.regionName, // Add regional fields
@@ -71,4 +102,34 @@ func (ms *MetricsSaver) SaveDNSDataToPocketBase(result *types.OperationResult, s
 // Method for monitoring service usage
 func (ms *MetricsSaver) SaveDNSDataForService(service pocketbase.Service, result *types.OperationResult) {
 	ms.SaveDNSDataToPocketBase(result, service.ID)
-}
\ No newline at end of file
+}
+
+// FormatDNSRecords renders DNS section records one per line in zone file style
+func FormatDNSRecords(records []types.DNSRecord) string {
+	lines := make([]string, 0, len(records))
+	for _, record := range records {
+		lines = append(lines, fmt.Sprintf("%s %d IN %s %s", record.Name, record.TTL, record.Type, record.Value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatDNSPropagation renders one line per nameserver of a propagation check
+func FormatDNSPropagation(answers []types.DNSServerAnswer) string {
+	lines := make([]string, 0, len(answers))
+	for _, answer := range answers {
+		line := fmt.Sprintf("%s [%s]", answer.Server, answer.Source)
+		if answer.Error != "" {
+			lines = append(lines, line+" ERROR "+answer.Error)
+			continue
+		}
+		line += " " + answer.Rcode
+		if answer.SOASerial != 0 {
+			line += fmt.Sprintf(" serial=%d", answer.SOASerial)
+		}
+		if len(answer.Answers) > 0 {
+			line += " " + strings.Join(answer.Answers, ", ")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
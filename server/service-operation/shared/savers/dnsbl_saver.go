@@ -0,0 +1,44 @@
+package savers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"service-operation/pocketbase"
+	"service-operation/types"
+)
+
+// SaveDNSBLDataToPocketBase stores a blocklist check in dns_data, one answer
+// line per listing and the listings as structured findings
+func (ms *MetricsSaver) SaveDNSBLDataToPocketBase(result *types.OperationResult, serviceID string) {
+	lines := make([]string, 0, len(result.DNSBLListings))
+	for _, listing := range result.DNSBLListings {
+		line := fmt.Sprintf("%s listed on %s (%s)", listing.IP, listing.Zone, strings.Join(listing.Codes, ", "))
+		if listing.Reason != "" {
+			line += " - " + listing.Reason
+		}
+		lines = append(lines, line)
+	}
+
+	dnsData := pocketbase.DNSDataRecord{
+		ServiceID:    serviceID,
+		Timestamp:    time.Now(),
+		ResponseTime: result.ResponseTime.Milliseconds(),
+		Status:       GetStatusString(result.Success),
+		QueryType:    "DNSBL",
+		ResolveIP:    strings.Join(result.DNSBLAddresses, ","),
+		Question:     strings.Join(result.DNSBLZones, ","),
+		Answer:       strings.Join(lines, "\n"),
+		Authority:    strings.Join(result.DNSBLErrors, "\n"),
+		ErrorMessage: result.Error,
+		Details:      result.Details,
+		Findings:     result.DNSBLListings,
+		RegionName:   ms.regionName,
+		AgentID:      ms.agentID,
+	}
+
+	if err := ms.pbClient.SaveDNSData(dnsData); err != nil {
+		println("Failed to save DNSBL data to PocketBase:", err.Error())
+	}
+}
@@ -0,0 +1,45 @@
+package savers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"service-operation/pocketbase"
+	"service-operation/types"
+)
+
+// SaveMailAuthDataToPocketBase stores a mail authentication audit in dns_data,
+// with the fetched records as the answer and the findings as structured data
+func (ms *MetricsSaver) SaveMailAuthDataToPocketBase(result *types.OperationResult, serviceID string) {
+	names := make([]string, 0, len(result.MailAuthRecords))
+	for name := range result.MailAuthRecords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	records := make([]string, 0, len(names))
+	for _, name := range names {
+		records = append(records, fmt.Sprintf("%s: %s", name, result.MailAuthRecords[name]))
+	}
+
+	dnsData := pocketbase.DNSDataRecord{
+		ServiceID:    serviceID,
+		Timestamp:    time.Now(),
+		ResponseTime: result.ResponseTime.Milliseconds(),
+		Status:       GetStatusString(result.Success),
+		QueryType:    "MAIL_AUTH",
+		Question:     result.Host,
+		Answer:       strings.Join(records, "\n"),
+		ErrorMessage: result.Error,
+		Details:      result.Details,
+		Findings:     result.MailAuthFindings,
+		RegionName:   ms.regionName,
+		AgentID:      ms.agentID,
+	}
+
+	if err := ms.pbClient.SaveDNSData(dnsData); err != nil {
+		println("Failed to save mail auth data to PocketBase:", err.Error())
+	}
+}
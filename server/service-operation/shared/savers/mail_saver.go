@@ -0,0 +1,45 @@
+package savers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"service-operation/pocketbase"
+	"service-operation/types"
+)
+
+// SaveMailDataToPocketBase stores an smtp, imap or pop3 probe in mail_data;
+// connection is connected once the TCP connection was established
+func (ms *MetricsSaver) SaveMailDataToPocketBase(result *types.OperationResult, serviceID string) {
+	connectionStatus := "connected"
+	if result.MailErrorKind == "connection" {
+		connectionStatus = "disconnected"
+	}
+
+	mailData := pocketbase.MailDataRecord{
+		ServiceID:     serviceID,
+		Timestamp:     time.Now(),
+		ResponseTime:  result.ResponseTime.Milliseconds(),
+		Status:        GetStatusString(result.Success),
+		Protocol:      string(result.Type),
+		Connection:    connectionStatus,
+		Latency:       fmt.Sprintf("%.2fms", float64(result.ResponseTime.Nanoseconds())/1000000),
+		Port:          strconv.Itoa(result.Port),
+		Greeting:      result.MailGreeting,
+		GreetingTime:  FormatPhaseDuration(result.MailGreetingTime),
+		Capabilities:  result.MailCapabilities,
+		TLSMode:       result.MailTLSMode,
+		TLSVersion:    result.TLSVersion,
+		Authenticated: result.MailAuthenticated,
+		ErrorKind:     result.MailErrorKind,
+		ErrorMessage:  result.Error,
+		Details:       result.Details,
+		RegionName:    ms.regionName,
+		AgentID:       ms.agentID,
+	}
+
+	if err := ms.pbClient.SaveMailData(mailData); err != nil {
+		fmt.Printf("Failed to save mail data to PocketBase: %v\n", err)
+	}
+}
@@ -62,8 +62,20 @@ func (ms *MetricsSaver) SaveMetricsToPocketBase(result *types.OperationResult, s
 			ms.SaveDNSDataToPocketBase(result, serviceID)
 		case types.OperationTCP:
 			ms.SaveTCPDataToPocketBase(result, serviceID)
+		case types.OperationUDP:
+			ms.SaveUDPDataToPocketBase(result, serviceID)
 		case types.OperationSSL:
 			ms.SaveSSLDataToPocketBase(result, serviceID)
+		case types.OperationMailAuth:
+			ms.SaveMailAuthDataToPocketBase(result, serviceID)
+		case types.OperationDNSBL:
+			ms.SaveDNSBLDataToPocketBase(result, serviceID)
+		case types.OperationPostgres, types.OperationMySQL, types.OperationRedis:
+			ms.SaveDatabaseDataToPocketBase(result, serviceID)
+		case types.OperationSMTP, types.OperationIMAP, types.OperationPOP3:
+			ms.SaveMailDataToPocketBase(result, serviceID)
+		case types.OperationTraceroute:
+			ms.SaveTracerouteDataToPocketBase(result, serviceID, "")
 		}
 	}
 }
@@ -100,8 +112,18 @@ func (ms *MetricsSaver) SaveMetricsForService(service pocketbase.Service, result
 		ms.SaveUptimeDataToPocketBase(result, service.ID)
 	case "tcp":
 		ms.SaveTCPDataToPocketBase(result, service.ID)
+	case "udp":
+		ms.SaveUDPDataToPocketBase(result, service.ID)
 	case "ssl":
 		ms.SaveSSLDataToPocketBase(result, service.ID)
+	case "mail_auth":
+		ms.SaveMailAuthDataToPocketBase(result, service.ID)
+	case "dnsbl":
+		ms.SaveDNSBLDataToPocketBase(result, service.ID)
+	case "postgres", "mysql", "redis":
+		ms.SaveDatabaseDataToPocketBase(result, service.ID)
+	case "smtp", "imap", "pop3":
+		ms.SaveMailDataToPocketBase(result, service.ID)
 	}
 }
 
@@ -120,6 +142,12 @@ func (ms *MetricsSaver) SaveSSLDataToPocketBase(result *types.OperationResult, s
 	} else {
 		details = fmt.Sprintf("❌ SSL Certificate Issue - %s", GetShortErrorMessage(result.Error))
 	}
+	if result.SSLChainStatus != "" && result.SSLChainStatus != "valid" {
+		details += fmt.Sprintf(" | Chain: %s", result.SSLChainStatus)
+	}
+	if result.SSLRevocationStatus != "" && result.SSLRevocationStatus != "good" {
+		details += fmt.Sprintf(" | Revocation: %s", result.SSLRevocationStatus)
+	}
 
 	sslData := pocketbase.SSLDataRecord{
 		ServiceID:     serviceID,
@@ -33,6 +33,16 @@ func (ms *MetricsSaver) SavePingDataToPocketBase(result *types.OperationResult,
 				float64(result.MinRTT.Nanoseconds())/1000000,
 				float64(result.MaxRTT.Nanoseconds())/1000000)
 		}
+
+		// Add jitter once there are enough replies to measure it
+		if result.PacketsRecv > 1 {
+			details += fmt.Sprintf(" | Jitter: %.2fms",
+				float64(result.RTTMeanDev.Nanoseconds())/1000000)
+		}
+		if result.PingDuplicates > 0 || result.PingOutOfOrder > 0 {
+			details += fmt.Sprintf(" | %d dup, %d out of order",
+				result.PingDuplicates, result.PingOutOfOrder)
+		}
 	} else {
 		// Error message
 		if result.PacketLoss >= 100 {
@@ -56,6 +66,10 @@ func (ms *MetricsSaver) SavePingDataToPocketBase(result *types.OperationResult,
 		MaxRTT:       fmt.Sprintf("%.2fms", float64(result.MaxRTT.Nanoseconds())/1000000),
 		AvgRTT:       fmt.Sprintf("%.2fms", float64(result.AvgRTT.Nanoseconds())/1000000),
 		RTTs:         "", // Not currently tracked
+		RTTMeanDev:   fmt.Sprintf("%.2fms", float64(result.RTTMeanDev.Nanoseconds())/1000000),
+		RTTStdDev:    fmt.Sprintf("%.2fms", float64(result.RTTStdDev.Nanoseconds())/1000000),
+		Duplicates:   fmt.Sprintf("%d", result.PingDuplicates),
+		OutOfOrder:   fmt.Sprintf("%d", result.PingOutOfOrder),
 		Latency:      fmt.Sprintf("%.2fms", float64(result.AvgRTT.Nanoseconds())/1000000),
 		ErrorMessage: result.Error,
 		Details:      details, // Short, clean message
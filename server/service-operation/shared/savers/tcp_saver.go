@@ -21,6 +21,16 @@ func (ms *MetricsSaver) SaveTCPDataToPocketBase(result *types.OperationResult, s
 		// Add response time
 		details += fmt.Sprintf(" | Connection time: %.2fms", 
 			float64(result.ResponseTime.Nanoseconds())/1000000)
+		if result.TCPExpectMatched {
+			details += " | Response matched"
+		}
+	} else if result.TCPConnected {
+		// Connected, but the payload exchange or response check failed
+		details = fmt.Sprintf("⚠️ TCP Response Check Failed - Port %d accepted the connection", result.Port)
+		
+		if result.Error != "" {
+			details += fmt.Sprintf(" (%s)", result.Error)
+		}
 	} else {
 		// Error message with port info
 		details = fmt.Sprintf("❌ TCP Connection Failed - Port %d unreachable", result.Port)
@@ -36,6 +46,11 @@ func (ms *MetricsSaver) SaveTCPDataToPocketBase(result *types.OperationResult, s
 		}
 	}
 
+	// Keep the banner so a degraded service can be told apart from a healthy one
+	if result.TCPBanner != "" {
+		details += fmt.Sprintf(" | Banner: %s", result.TCPBanner)
+	}
+
 	connectionStatus := "disconnected"
 	if result.TCPConnected {
 		connectionStatus = "connected"
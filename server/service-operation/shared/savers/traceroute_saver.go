@@ -0,0 +1,35 @@
+package savers
+
+import (
+	"fmt"
+	"time"
+
+	"service-operation/pocketbase"
+	"service-operation/types"
+)
+
+// SaveTracerouteDataToPocketBase stores a traceroute; trigger records why it
+// ran, e.g. service_down for the automatic trace of a failing service
+func (ms *MetricsSaver) SaveTracerouteDataToPocketBase(result *types.OperationResult, serviceID, trigger string) {
+	traceData := pocketbase.TracerouteDataRecord{
+		ServiceID:    serviceID,
+		Timestamp:    time.Now(),
+		ResponseTime: result.ResponseTime.Milliseconds(),
+		Status:       GetStatusString(result.Success),
+		Host:         result.Host,
+		Address:      result.TraceAddress,
+		Protocol:     result.TraceProtocol,
+		HopCount:     len(result.TraceHops),
+		Reached:      result.TraceReached,
+		Hops:         result.TraceHops,
+		Trigger:      trigger,
+		ErrorMessage: result.Error,
+		Details:      result.Details,
+		RegionName:   ms.regionName,
+		AgentID:      ms.agentID,
+	}
+
+	if err := ms.pbClient.SaveTracerouteData(traceData); err != nil {
+		fmt.Printf("Failed to save traceroute data to PocketBase: %v\n", err)
+	}
+}
@@ -0,0 +1,31 @@
+package savers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"service-operation/pocketbase"
+	"service-operation/types"
+)
+
+func (ms *MetricsSaver) SaveUDPDataToPocketBase(result *types.OperationResult, serviceID string) {
+	udpData := pocketbase.UDPDataRecord{
+		ServiceID:    serviceID,
+		Timestamp:    time.Now(),
+		ResponseTime: result.ResponseTime.Milliseconds(),
+		Status:       GetStatusString(result.Success),
+		Connection:   result.UDPReachability,
+		Latency:      fmt.Sprintf("%.2fms", float64(result.ResponseTime.Nanoseconds())/1000000),
+		Port:         strconv.Itoa(result.Port),
+		Response:     result.UDPResponse,
+		ErrorMessage: result.Error,
+		Details:      result.Details,
+		RegionName:   ms.regionName,
+		AgentID:      ms.agentID,
+	}
+
+	if err := ms.pbClient.SaveUDPData(udpData); err != nil {
+		fmt.Printf("Failed to save UDP data to PocketBase: %v\n", err)
+	}
+}
@@ -28,6 +28,11 @@ func (ms *MetricsSaver) SaveUptimeDataToPocketBase(result *types.OperationResult
 		if server, exists := result.HTTPHeaders["Server"]; exists {
 			details += fmt.Sprintf(" | Server: %s", server)
 		}
+
+		// Add redirect info if the check followed redirects
+		if len(result.RedirectChain) > 1 {
+			details += fmt.Sprintf(" | Redirects: %d → %s", len(result.RedirectChain)-1, result.FinalURL)
+		}
 	} else {
 		// Error message with status code if available
 		if result.HTTPStatusCode > 0 {
@@ -46,6 +51,12 @@ func (ms *MetricsSaver) SaveUptimeDataToPocketBase(result *types.OperationResult
 		}
 	}
 
+	// Record the accepted status code rule the response was evaluated against
+	statusCodes := fmt.Sprintf("%d", result.HTTPStatusCode)
+	if result.StatusCodes != "" {
+		statusCodes = fmt.Sprintf("%d (%s)", result.HTTPStatusCode, result.StatusCodes)
+	}
+
 	uptimeData := pocketbase.UptimeDataRecord{
 		ServiceID:    serviceID,
 		Timestamp:    time.Now(),
@@ -53,10 +64,15 @@ func (ms *MetricsSaver) SaveUptimeDataToPocketBase(result *types.OperationResult
 		Status:       GetStatusString(result.Success),
 		Packets:      "N/A", // Not applicable for HTTP
 		Latency:      fmt.Sprintf("%.2fms", float64(result.ResponseTime.Nanoseconds())/1000000),
-		StatusCodes:  fmt.Sprintf("%d", result.HTTPStatusCode),
-		Keyword:      "", // Can be populated later if needed
+		StatusCodes:  statusCodes,
+		Keyword:      result.Keyword,
 		ErrorMessage: result.Error,
 		Details:      details, // Short, clean message
+		DNSLookup:    FormatPhaseDuration(result.DNSLookupTime),
+		TCPConnect:   FormatPhaseDuration(result.TCPConnectTime),
+		TLSHandshake: FormatPhaseDuration(result.TLSHandshakeTime),
+		TTFB:         FormatPhaseDuration(result.TimeToFirstByte),
+		Transfer:     FormatPhaseDuration(result.ContentTransferTime),
 		Region:       ms.regionName, // Legacy field
 		RegionID:     ms.agentID,    // Legacy field
 		RegionName:   ms.regionName, // Add regional fields
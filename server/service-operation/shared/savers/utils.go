@@ -4,6 +4,7 @@ package savers
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"service-operation/types"
 )
@@ -54,6 +55,14 @@ func GetShortErrorMessage(errorMessage string) string {
 	return shortMsg
 }
 
+// Helper function to format a request phase duration, empty when the phase did not happen
+func FormatPhaseDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.2fms", float64(d.Nanoseconds())/1000000)
+}
+
 func GetStatusString(success bool) string {
 	if success {
 		return "up"
@@ -83,6 +92,31 @@ func FormatResultDetails(result *types.OperationResult) string {
 			return fmt.Sprintf("DNS %s query successful - %d records found", result.DNSType, len(result.DNSRecords))
 		}
 		return fmt.Sprintf("DNS query failed - %s", result.Error)
+	case types.OperationMailAuth:
+		if result.Success {
+			return fmt.Sprintf("Mail authentication OK - %d findings", len(result.MailAuthFindings))
+		}
+		return fmt.Sprintf("Mail authentication issues - %s", result.Error)
+	case types.OperationDNSBL:
+		if result.Success {
+			return fmt.Sprintf("Not listed - %d addresses on %d blocklists", len(result.DNSBLAddresses), len(result.DNSBLZones))
+		}
+		return fmt.Sprintf("DNSBL check failed - %s", result.Error)
+	case types.OperationPostgres, types.OperationMySQL, types.OperationRedis:
+		if result.Success {
+			return fmt.Sprintf("%s OK - handshake %.2fms", result.Type, float64(result.DBHandshakeTime.Nanoseconds())/1000000)
+		}
+		return fmt.Sprintf("%s %s error - %s", result.Type, result.DBErrorKind, result.Error)
+	case types.OperationSMTP, types.OperationIMAP, types.OperationPOP3:
+		if result.Success {
+			return fmt.Sprintf("%s OK - greeting %.2fms, %d capabilities", result.Type, float64(result.MailGreetingTime.Nanoseconds())/1000000, len(result.MailCapabilities))
+		}
+		return fmt.Sprintf("%s %s error - %s", result.Type, result.MailErrorKind, result.Error)
+	case types.OperationTraceroute:
+		if result.Success {
+			return fmt.Sprintf("Traceroute reached %s in %d hops", result.TraceAddress, len(result.TraceHops))
+		}
+		return fmt.Sprintf("Traceroute incomplete - %s", result.Error)
 	default:
 		return "Operation completed"
 	}
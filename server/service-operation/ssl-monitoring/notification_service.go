@@ -38,6 +38,11 @@ func (sns *SSLNotificationService) CheckAndNotifySSLCertificate(cert SSLCertific
 	// Determine current status based on thresholds with calculated days left
 	currentStatus := sns.determineSSLStatus(cert.DaysLeft, cert.WarningThreshold, cert.ExpiryThreshold)
 
+	// A revoked certificate must not be trusted whatever its expiry date
+	if cert.RevocationStatus == "revoked" {
+		currentStatus = "revoked"
+	}
+
 	// Check if notification should be sent
 	if !sns.shouldSendNotification(cert.ID, currentStatus) {
 		return nil
@@ -134,6 +139,8 @@ func (sns *SSLNotificationService) sendSSLNotification(cert SSLCertificate, stat
 		DaysLeft:        strconv.Itoa(cert.DaysLeft), // Use the calculated value
 		IssuerCN:        issuerValue,
 		SerialNumber:    cert.SerialNumber,
+		RevocationReason: cert.RevocationReason,
+		RevokedAt:        cert.RevokedAt,
 	}
 
 	// Send SSL notification using the manager
@@ -143,6 +150,8 @@ func (sns *SSLNotificationService) sendSSLNotification(cert SSLCertificate, stat
 // generateStatusMessage creates appropriate message based on certificate status using calculated days
 func (sns *SSLNotificationService) generateStatusMessage(cert SSLCertificate, status string) string {
 	switch status {
+	case "revoked":
+		return fmt.Sprintf("SSL certificate for %s was revoked on %s (%s)", cert.Domain, cert.RevokedAt, cert.RevocationReason)
 	case "expired":
 		return fmt.Sprintf("SSL certificate for %s expired on %s", cert.Domain, cert.ValidTill)
 	case "expiring_soon":
@@ -164,8 +173,8 @@ func (sns *SSLNotificationService) shouldSendNotification(certID, currentStatus
 		return true
 	}
 
-	// For critical statuses (expired/expiring_soon), resend after 24 hours
-	if (currentStatus == "expired" || currentStatus == "expiring_soon") && 
+	// For critical statuses (revoked/expired/expiring_soon), resend after 24 hours
+	if (currentStatus == "revoked" || currentStatus == "expired" || currentStatus == "expiring_soon") && 
 		!lastNotified.IsZero() && time.Since(lastNotified) > 24*time.Hour {
 		return true
 	}
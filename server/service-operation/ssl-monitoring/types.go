@@ -33,6 +33,9 @@ type SSLCertificate struct {
 	CertSans             string    `json:"cert_sans"`
 	CheckInterval        int       `json:"check_interval"`
 	CheckAt              string    `json:"check_at"`
+	RevocationStatus     string    `json:"revocation_status"` // good, revoked or unknown
+	RevocationReason     string    `json:"revocation_reason"`
+	RevokedAt            string    `json:"revoked_at"`
 	Created              string    `json:"created"`
 	Updated              string    `json:"updated"`
 }
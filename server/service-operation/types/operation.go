@@ -10,18 +10,163 @@ const (
 	OperationTCP  OperationType = "tcp"
 	OperationHTTP OperationType = "http"
 	OperationSSL  OperationType = "ssl"
+	OperationUDP  OperationType = "udp"
+
+	OperationMailAuth OperationType = "mail_auth"
+	OperationDNSBL    OperationType = "dnsbl"
+
+	OperationTraceroute OperationType = "traceroute"
+
+	OperationPostgres OperationType = "postgres"
+	OperationMySQL    OperationType = "mysql"
+	OperationRedis    OperationType = "redis"
+
+	OperationSMTP OperationType = "smtp"
+	OperationIMAP OperationType = "imap"
+	OperationPOP3 OperationType = "pop3"
 )
 
 type OperationRequest struct {
 	Type      OperationType `json:"type"`
 	Host      string        `json:"host"`
 	Port      int           `json:"port,omitempty"`    // For TCP
+	TCPSend        string   `json:"tcp_send,omitempty"`         // For TCP: payload sent after connecting
+	TCPSendFormat  string   `json:"tcp_send_format,omitempty"`  // For TCP: text or hex
+	TCPExpect      string   `json:"tcp_expect,omitempty"`       // For TCP: expected response
+	TCPExpectMode  string   `json:"tcp_expect_mode,omitempty"`  // For TCP: contains, not_contains or regex
+	TCPReadBanner  bool     `json:"tcp_read_banner,omitempty"`  // For TCP: read the banner without sending
+	TCPReadTimeout int      `json:"tcp_read_timeout,omitempty"` // For TCP: in seconds, defaults to timeout
+	UDPSend            string `json:"udp_send,omitempty"`              // For UDP: probe payload
+	UDPSendFormat      string `json:"udp_send_format,omitempty"`       // For UDP: text or hex
+	UDPExpect          string `json:"udp_expect,omitempty"`            // For UDP: expected reply
+	UDPExpectMode      string `json:"udp_expect_mode,omitempty"`       // For UDP: contains, not_contains or regex
+	UDPReadTimeout     int    `json:"udp_read_timeout,omitempty"`      // For UDP: in seconds, defaults to timeout
+	UDPAllowNoResponse bool   `json:"udp_allow_no_response,omitempty"` // For UDP: only port unreachable is down
 	Count     int           `json:"count,omitempty"`   // For ping
+	IPFamily  string        `json:"ip_family,omitempty"` // For ping: auto, ipv4 or ipv6
+	PingSize         int  `json:"ping_size,omitempty"`          // For ping: ICMP payload bytes, default 56
+	PingInterval     int  `json:"ping_interval,omitempty"`      // For ping: milliseconds between packets, default 1000
+	PingTTL          int  `json:"ping_ttl,omitempty"`           // For ping: IPv4 TTL or IPv6 hop limit
+	PingDontFragment bool `json:"ping_dont_fragment,omitempty"` // For ping: set DF for MTU troubleshooting
+	TraceProtocol string `json:"trace_protocol,omitempty"` // For traceroute: icmp, udp or tcp; uses port and ip_family
+	TraceMaxHops  int    `json:"trace_max_hops,omitempty"` // For traceroute: default 30
+	TraceRounds   int    `json:"trace_rounds,omitempty"`   // For traceroute: probes per hop, default 3
 	Timeout   int           `json:"timeout,omitempty"` // In seconds
 	Query     string        `json:"query,omitempty"`   // For DNS
+	Nameserver   string     `json:"nameserver,omitempty"`    // For DNS, e.g. 1.1.1.1 or 10.0.0.2:53
+	DNSTransport string     `json:"dns_transport,omitempty"` // For DNS: auto, udp or tcp
+	DNSExpected  []string   `json:"dns_expected,omitempty"`   // For DNS: expected answers, CIDRs or patterns
+	DNSMatchMode string     `json:"dns_match_mode,omitempty"` // For DNS: exact, subset, cidr or regex
+	DNSMode      string     `json:"dns_mode,omitempty"`       // For DNS: query, propagation or dnssec
+	DNSSECTrustAnchor     string `json:"dnssec_trust_anchor,omitempty"`      // For DNSSEC: DS records, one per line
+	DNSSECExpiryWarnDays int    `json:"dnssec_expiry_warn_days,omitempty"` // For DNSSEC: warn before signatures expire
+	DKIMSelectors []string      `json:"dkim_selectors,omitempty"` // For mail_auth
+	DNSBLZones    []string      `json:"dnsbl_zones,omitempty"`    // For dnsbl, defaults to well-known lists
+	Database      string        `json:"database,omitempty"`       // For postgres, mysql and redis: database, schema or Redis db number; logs in with username and password
+	DatabaseTLS   bool          `json:"database_tls,omitempty"`   // For redis: connect over TLS
+	AllowInsecureAuth bool      `json:"allow_insecure_auth,omitempty"` // For postgres, mysql and redis: allow sending passwords without TLS
+	SSLProtocol   string        `json:"ssl_protocol,omitempty"`   // For ssl: tls, smtp, imap, pop3, ftp, ldap or postgres; STARTTLS is negotiated on all but tls
+	CABundle      string        `json:"ca_bundle,omitempty"`      // For ssl: PEM certificates trusted in addition to the system roots
+	SSLSkipRevocation bool      `json:"ssl_skip_revocation,omitempty"` // For ssl: skip OCSP and CRL checks
+	MailTLS       string        `json:"mail_tls,omitempty"`       // For smtp, imap and pop3: none, starttls or implicit; logs in with username and password over TLS
 	URL       string        `json:"url,omitempty"`     // For HTTP
 	Method    string        `json:"method,omitempty"`  // For HTTP (GET, POST, etc.)
 	ServiceID string        `json:"service_id,omitempty"` // For linking to specific service
+
+	// HTTP request customization
+	Headers            map[string]string `json:"headers,omitempty"`
+	Body               string            `json:"body,omitempty"`
+	ContentType        string            `json:"content_type,omitempty"`
+	AuthType           string            `json:"auth_type,omitempty"` // basic or bearer
+	Username           string            `json:"username,omitempty"`
+	Password           string            `json:"password,omitempty"`
+	BearerToken        string            `json:"bearer_token,omitempty"`
+	QueryParams        map[string]string `json:"query_params,omitempty"`
+	InsecureSkipVerify bool              `json:"insecure_skip_verify,omitempty"`
+	JSONAssertions     []string          `json:"json_assertions,omitempty"` // e.g. $.status == "ok"
+	RedirectPolicy     string            `json:"redirect_policy,omitempty"`    // follow or none
+	MaxRedirects       int               `json:"max_redirects,omitempty"`
+	ExpectedFinalURL   string            `json:"expected_final_url,omitempty"` // Regex the final URL must match
+}
+
+// DNSRecord is a resource record from a DNS response section
+type DNSRecord struct {
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	TTL    uint32            `json:"ttl"`
+	Value  string            `json:"value"`
+	Fields map[string]string `json:"fields,omitempty"` // Structured data, e.g. SOA serial or SRV port
+}
+
+// SSLChainCert is one certificate of a verified chain, or of the chain as
+// presented when it could not be verified
+type SSLChainCert struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	Role      string    `json:"role"` // leaf, intermediate or root
+	ValidFrom time.Time `json:"valid_from"`
+	ValidTill time.Time `json:"valid_till"`
+	DaysLeft  int       `json:"days_left"`
+	Sent      bool      `json:"sent"` // Presented by the server, rather than taken from the trust store or fetched
+}
+
+// DNSServerAnswer is the reply of one nameserver in a DNS propagation check
+type DNSServerAnswer struct {
+	Server       string        `json:"server"`  // Nameserver as configured or discovered
+	Address      string        `json:"address"` // IP and port that was queried
+	Source       string        `json:"source"` // authoritative or resolver
+	Rcode        string        `json:"rcode,omitempty"`
+	Answers      []string      `json:"answers,omitempty"`
+	SOASerial    uint32        `json:"soa_serial,omitempty"`
+	ResponseTime time.Duration `json:"response_time"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// MailAuthFinding is a single issue found by a mail authentication audit
+type MailAuthFinding struct {
+	Check    string `json:"check"`    // spf, dmarc, dkim or mta-sts
+	Severity string `json:"severity"` // error, warning or info
+	Message  string `json:"message"`
+}
+
+// DNSBLListing is an address found on a DNS blocklist
+type DNSBLListing struct {
+	IP     string   `json:"ip"`
+	Zone   string   `json:"zone"`
+	Codes  []string `json:"codes"`            // Return addresses such as 127.0.0.2
+	Reason string   `json:"reason,omitempty"` // TXT record published by the list
+}
+
+// TraceHop is one router on a traceroute path, with statistics across rounds
+type TraceHop struct {
+	TTL         int           `json:"ttl"`
+	Address     string        `json:"address,omitempty"`
+	Addresses   []string      `json:"addresses,omitempty"` // Several on load-balanced paths
+	Hostname    string        `json:"hostname,omitempty"`
+	Sent        int           `json:"sent"`
+	Received    int           `json:"received"`
+	Loss        float64       `json:"loss"`
+	LastRTT     time.Duration `json:"last_rtt"`
+	AvgRTT      time.Duration `json:"avg_rtt"`
+	BestRTT     time.Duration `json:"best_rtt"`
+	WorstRTT    time.Duration `json:"worst_rtt"`
+	StdDevRTT   time.Duration `json:"std_dev_rtt"`
+	Unreachable string        `json:"unreachable,omitempty"` // e.g. "host unreachable" reported by this hop
+}
+
+// RedirectHop is a single request in an HTTP redirect chain
+type RedirectHop struct {
+	URL        string        `json:"url"`
+	StatusCode int           `json:"status_code"`
+	Latency    time.Duration `json:"latency"`
+}
+
+// AssertionResult is the outcome of a single response assertion
+type AssertionResult struct {
+	Expression string `json:"expression"`
+	Passed     bool   `json:"passed"`
+	Actual     string `json:"actual,omitempty"`
+	Error      string `json:"error,omitempty"`
 }
 
 type OperationResult struct {
@@ -41,21 +186,104 @@ type OperationResult struct {
 	MaxRTT      time.Duration   `json:"max_rtt,omitempty"`
 	AvgRTT      time.Duration   `json:"avg_rtt,omitempty"`
 	RTTs        []time.Duration `json:"rtts,omitempty"`
+	PingAddress string          `json:"ping_address,omitempty"` // Resolved IP that was pinged
+	PingFamily  string          `json:"ping_family,omitempty"`  // ipv4 or ipv6
+	PingPacketSize int           `json:"ping_packet_size,omitempty"`  // ICMP payload bytes
+	RTTMeanDev     time.Duration `json:"rtt_mean_dev,omitempty"`      // Jitter as mean absolute deviation
+	RTTStdDev      time.Duration `json:"rtt_std_dev,omitempty"`       // Jitter as standard deviation, ping's mdev
+	PingDuplicates int           `json:"ping_duplicates,omitempty"`   // Duplicate replies
+	PingOutOfOrder int           `json:"ping_out_of_order,omitempty"` // Replies overtaken by a later one
 	
 	// DNS specific fields
-	DNSRecords  []string        `json:"dns_records,omitempty"`
-	DNSType     string          `json:"dns_type,omitempty"`
+	DNSRecords       []string    `json:"dns_records,omitempty"`
+	DNSType          string      `json:"dns_type,omitempty"`
+	DNSServer        string      `json:"dns_server,omitempty"`
+	DNSTransport     string      `json:"dns_transport,omitempty"`
+	DNSRcode         string      `json:"dns_rcode,omitempty"`
+	DNSAuthoritative bool        `json:"dns_authoritative,omitempty"`
+	DNSMsgSize       int         `json:"dns_msg_size,omitempty"`
+	DNSQuestion      string      `json:"dns_question,omitempty"`
+	DNSAnswers       []DNSRecord `json:"dns_answers,omitempty"`
+	DNSAuthority     []DNSRecord `json:"dns_authority,omitempty"`
+	DNSAdditional    []DNSRecord `json:"dns_additional,omitempty"`
+	DNSAnswerDiff    string      `json:"dns_answer_diff,omitempty"` // "- missing" and "+ unexpected" lines
+	DNSPropagation   []DNSServerAnswer `json:"dns_propagation,omitempty"`
+	DNSConsistency   string            `json:"dns_consistency,omitempty"` // consistent or inconsistent
+	DNSSECStatus     string            `json:"dnssec_status,omitempty"`   // secure, insecure or bogus
+	DNSSECChain      []string          `json:"dnssec_chain,omitempty"`
+	DNSSECWarnings   []string          `json:"dnssec_warnings,omitempty"`
+	DNSSECExpiration time.Time         `json:"dnssec_expiration,omitempty"` // Earliest signature expiration
+
+	// Mail authentication audit fields
+	MailAuthRecords  map[string]string `json:"mail_auth_records,omitempty"` // spf, dmarc, dkim:<selector>, mta-sts
+	MailAuthFindings []MailAuthFinding `json:"mail_auth_findings,omitempty"`
+	SPFLookups       int               `json:"spf_lookups,omitempty"`
+
+	// DNSBL fields
+	DNSBLAddresses []string       `json:"dnsbl_addresses,omitempty"` // Addresses that were checked
+	DNSBLZones     []string       `json:"dnsbl_zones,omitempty"`
+	DNSBLListings  []DNSBLListing `json:"dnsbl_listings,omitempty"`
+	DNSBLErrors    []string       `json:"dnsbl_errors,omitempty"` // Lists that could not be queried
+
+	// Traceroute fields
+	TraceProtocol string     `json:"trace_protocol,omitempty"`
+	TraceAddress  string     `json:"trace_address,omitempty"` // Resolved IP that was traced
+	TraceFamily   string     `json:"trace_family,omitempty"`
+	TraceReached  bool       `json:"trace_reached,omitempty"`
+	TraceHops     []TraceHop `json:"trace_hops,omitempty"`
+	
+	// Database probe fields (postgres, mysql, redis)
+	DBServerVersion string        `json:"db_server_version,omitempty"`
+	DBAuthMethod    string        `json:"db_auth_method,omitempty"` // e.g. scram-sha-256 or caching_sha2_password
+	DBAuthenticated bool          `json:"db_authenticated,omitempty"`
+	DBLoginSkipped  bool          `json:"db_login_skipped,omitempty"` // No credentials configured, handshake only
+	DBHandshakeTime time.Duration `json:"db_handshake_time,omitempty"` // From dialing until the session was ready
+	DBQueryTime     time.Duration `json:"db_query_time,omitempty"`     // SELECT 1 or ping round trip
+	DBErrorKind     string        `json:"db_error_kind,omitempty"`     // connection, handshake, auth or query
+
+	// Mail server probe fields (smtp, imap, pop3)
+	MailGreeting      string        `json:"mail_greeting,omitempty"`
+	MailGreetingTime  time.Duration `json:"mail_greeting_time,omitempty"` // From dialing until the greeting arrived
+	MailCapabilities  []string      `json:"mail_capabilities,omitempty"`  // EHLO extensions, IMAP or POP3 capabilities
+	MailTLSMode       string        `json:"mail_tls_mode,omitempty"`      // none, starttls or implicit
+	MailAuthenticated bool          `json:"mail_authenticated,omitempty"`
+	MailAuthMechanism string        `json:"mail_auth_mechanism,omitempty"`
+	MailErrorKind     string        `json:"mail_error_kind,omitempty"` // connection, greeting, tls, auth or protocol
+	TLSVersion        string        `json:"tls_version,omitempty"`
+	TLSCipherSuite    string        `json:"tls_cipher_suite,omitempty"`
 	
 	// TCP specific fields
 	TCPConnected bool           `json:"tcp_connected,omitempty"`
+	TCPBanner        string        `json:"tcp_banner,omitempty"` // Response or banner, control characters escaped
+	TCPExpectMatched bool          `json:"tcp_expect_matched,omitempty"`
+	TCPExchangeTime  time.Duration `json:"tcp_exchange_time,omitempty"` // Send and read time after connecting
+
+	// UDP specific fields
+	UDPReachability  string `json:"udp_reachability,omitempty"` // responded, no_response or unreachable
+	UDPResponse      string `json:"udp_response,omitempty"`     // Reply, control characters escaped
+	UDPExpectMatched bool   `json:"udp_expect_matched,omitempty"`
 	
 	// HTTP specific fields
 	HTTPStatusCode int          `json:"http_status_code,omitempty"`
 	HTTPMethod     string       `json:"http_method,omitempty"`
 	HTTPHeaders    map[string]string `json:"http_headers,omitempty"`
+	StatusCodes    string       `json:"status_codes,omitempty"`
 	ContentLength  int64        `json:"content_length,omitempty"`
 	ResponseBody   string       `json:"response_body,omitempty"`
-	
+	Keyword        string       `json:"keyword,omitempty"`
+	KeywordFound   bool         `json:"keyword_found,omitempty"`
+	Assertions     []AssertionResult `json:"assertions,omitempty"`
+	FinalURL       string        `json:"final_url,omitempty"`
+	RedirectChain  []RedirectHop `json:"redirect_chain,omitempty"`
+
+	// HTTP timing breakdown; time to first byte is measured from the request being written
+	DNSLookupTime       time.Duration `json:"dns_lookup_time,omitempty"`
+	TCPConnectTime      time.Duration `json:"tcp_connect_time,omitempty"`
+	TLSHandshakeTime    time.Duration `json:"tls_handshake_time,omitempty"`
+	TimeToFirstByte     time.Duration `json:"time_to_first_byte,omitempty"`
+	ContentTransferTime time.Duration `json:"content_transfer_time,omitempty"`
+	ConnectionReused    bool          `json:"connection_reused,omitempty"`
+
 	// SSL specific fields
 	SSLValidFrom     time.Time   `json:"ssl_valid_from,omitempty"`
 	SSLValidTill     time.Time   `json:"ssl_valid_till,omitempty"`
@@ -66,6 +294,17 @@ type OperationResult struct {
 	SSLAlgorithm     string      `json:"ssl_algorithm,omitempty"`
 	SSLSANs          string      `json:"ssl_sans,omitempty"`
 	SSLResolvedIP    string      `json:"ssl_resolved_ip,omitempty"`
+	SSLProtocol      string      `json:"ssl_protocol,omitempty"` // tls or the STARTTLS protocol
+	SSLChainStatus   string         `json:"ssl_chain_status,omitempty"` // valid, incomplete, untrusted or invalid
+	SSLChain         []SSLChainCert `json:"ssl_chain,omitempty"`
+	SSLChainIssues   []string       `json:"ssl_chain_issues,omitempty"` // CA certificates expiring before the leaf
+	SSLRevocationStatus     string    `json:"ssl_revocation_status,omitempty"` // good, revoked or unknown
+	SSLRevocationSource     string    `json:"ssl_revocation_source,omitempty"` // ocsp_stapled, ocsp or crl
+	SSLRevocationReason     string    `json:"ssl_revocation_reason,omitempty"`
+	SSLRevokedAt            time.Time `json:"ssl_revoked_at,omitempty"`
+	SSLRevocationThisUpdate time.Time `json:"ssl_revocation_this_update,omitempty"`
+	SSLRevocationNextUpdate time.Time `json:"ssl_revocation_next_update,omitempty"`
+	SSLRevocationError      string    `json:"ssl_revocation_error,omitempty"` // Why the status is unknown
 	
 	StartTime   time.Time       `json:"start_time"`
 	EndTime     time.Time       `json:"end_time"`
@@ -12,6 +12,9 @@ type SSLCertificate struct {
 	CollectionID         string    `json:"collectionId"`
 	CollectionName       string    `json:"collectionName"`
 	Domain               string    `json:"domain"`
+	Protocol             string    `json:"protocol"` // tls, or the protocol to negotiate STARTTLS with
+	CABundle             string    `json:"ca_bundle"` // PEM certificates of a private CA to trust
+	SkipRevocation       bool      `json:"skip_revocation"` // Skips OCSP and CRL checks
 	IssuerO              string    `json:"issuer_o"`
 	Status               string    `json:"status"`
 	LastNotified         string    `json:"last_notified"`
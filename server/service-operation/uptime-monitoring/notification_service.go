@@ -163,10 +163,16 @@ func (uns *UptimeNotificationService) getCollectionForServiceType(serviceType st
 	switch strings.ToLower(serviceType) {
 	case "ping", "icmp":
 		return "ping_data"
-	case "dns":
+	case "dns", "mail_auth", "dnsbl":
 		return "dns_data"
 	case "tcp":
 		return "tcp_data"
+	case "udp":
+		return "udp_data"
+	case "postgres", "mysql", "redis":
+		return "database_data"
+	case "smtp", "imap", "pop3":
+		return "mail_data"
 	case "http", "https":
 		return "uptime_data"
 	default: